@@ -0,0 +1,35 @@
+// Команда rebuild-reputation пересчитывает журнал репутации и репутацию пользователей
+// по текущим голосам и принятым ответам с весами из конфигурации
+package main
+
+import (
+	"HiTalent_TestTask/backend/config"
+	"HiTalent_TestTask/backend/internal/adapter/repo/postgres"
+	"HiTalent_TestTask/backend/internal/cases"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		panic("failed to create logger: " + err.Error())
+	}
+	defer logger.Sync()
+
+	cfg, err := config.NewConfig(logger)
+	if err != nil {
+		logger.Fatal("error creating config", zap.Error(err))
+	}
+
+	db, err := postgres.NewGormDB(cfg.PgConnStr)
+	if err != nil {
+		logger.Fatal("failed to connect to postgres", zap.Error(err))
+	}
+
+	reputationCase := cases.NewReputationCase(postgres.NewReputationRepo(db), cfg.ReputationWeights, logger)
+	if err := reputationCase.RebuildReputation(context.Background()); err != nil {
+		logger.Fatal("failed to rebuild reputation", zap.Error(err))
+	}
+}
@@ -1,18 +1,93 @@
 package config
 
 import (
+	"HiTalent_TestTask/backend/internal/entity"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
-const DefaultHTTPPort = ":8080"
+const (
+	DefaultHTTPPort       = ":8080"
+	DefaultSearchLanguage = "simple"
+
+	DefaultHTTPReadTimeout  = 10 * time.Second
+	DefaultHTTPWriteTimeout = 30 * time.Second
+	DefaultHTTPIdleTimeout  = 2 * time.Minute
+	DefaultShutdownTimeout  = 20 * time.Second
+
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval = time.Hour
+
+	DefaultCommentMaxDepth = 3
+
+	DefaultIdempotencyKeyTTL        = 24 * time.Hour
+	DefaultIdempotencyPurgeInterval = time.Hour
+
+	DefaultSSEHeartbeatInterval = 15 * time.Second
+	DefaultSSEReplaySize        = 256
+	DefaultSSEClientBuffer      = 32
+
+	// DefaultRateLimits - правила ограничения частоты запросов, если RATE_LIMITS не задана
+	DefaultRateLimits = "POST /questions=20/1m; POST /questions/{id}/answers=20/1m"
+	// DefaultAuthRateLimit - лимит запросов с токеном с одного IP, если AUTH_RATE_LIMIT не задана
+	DefaultAuthRateLimit = "300/1m"
+
+	DefaultTracingExporter = "none"
+	DefaultServiceName     = "hitalent-backend"
+
+	DefaultReputationUpvote          = 10
+	DefaultReputationDownvotePenalty = 2
+	DefaultReputationAccepted        = 15
+	DefaultReputationAccept          = 2
+)
 
 type Config struct {
-	PgConnStr string
-	HTTPPort  string
+	PgConnStr      string
+	HTTPPort       string
+	MetricsAddr    string // отдельный admin-листенер для /metrics, пустой - метрики на основном порту
+	SearchLanguage string // конфигурация текстового поиска postgres по умолчанию
+
+	HTTPReadTimeout  time.Duration // чтение запроса целиком, включая тело
+	HTTPWriteTimeout time.Duration // от конца чтения заголовков до конца записи ответа
+	HTTPIdleTimeout  time.Duration // простой keep-alive соединения
+	ShutdownTimeout  time.Duration // сколько ждать завершения текущих запросов при остановке
+	ShutdownDelay    time.Duration // сколько отвечать 503 на /readyz перед закрытием листенеров, 0 - не ждать
+
+	// Проверка JWT: можно задать любое сочетание источников ключей
+	JWTSecret        string // секрет HS256
+	JWTPublicKeyFile string // PEM-файл с публичным ключом RS256
+	JWKSFile         string // локальный JWKS-файл
+	JWTIssuer        string // ожидаемый claim iss, пустой - не проверяется
+	JWTAudience      string // ожидаемый claim aud, пустой - не проверяется
+
+	PolicyFile string // JSON-файл политики доступа, пустой - встроенная политика
+
+	TrashRetention     time.Duration // срок хранения удаленных записей в корзине
+	TrashPurgeInterval time.Duration // период очистки корзины
+
+	CommentMaxDepth int // максимальная вложенность ответов на комментарии, 0 - без ответов
+
+	IdempotencyKeyTTL        time.Duration // сколько хранится ответ на запрос с Idempotency-Key
+	IdempotencyPurgeInterval time.Duration // период удаления истекших ключей
+
+	SSEHeartbeatInterval time.Duration // период комментариев-heartbeat в потоке событий вопроса
+	SSEReplaySize        int           // сколько последних событий хранится для возобновления по Last-Event-ID
+	SSEClientBuffer      int           // сколько событий ждет медленного клиента, прежде чем он будет отключен
+
+	ReputationWeights entity.ReputationWeights // очки репутации за голоса и принятые ответы
+
+	RateLimits     string // правила "METHOD /route=REQUESTS/PERIOD" через ";", пустая строка выключает лимиты
+	AuthRateLimit  string // лимит "REQUESTS/PERIOD" на проверку токенов с одного IP, пустая строка выключает его
+	TrustedProxies string // адреса и подсети прокси через запятую, от которых принимается X-Forwarded-For
+
+	TracingExporter string // куда отправлять спаны: none, stdout или otlp
+	ServiceName     string // service.name в спанах
 }
 
 func NewConfig(logger *zap.Logger) (Config, error) {
@@ -42,5 +117,148 @@ func NewConfig(logger *zap.Logger) (Config, error) {
 			cfg.HTTPPort = httpPort
 		}
 	}
+
+	if metricsAddr := os.Getenv("METRICS_ADDR"); metricsAddr != "" && metricsAddr[0] != ':' && !strings.Contains(metricsAddr, ":") {
+		cfg.MetricsAddr = ":" + metricsAddr
+	} else {
+		cfg.MetricsAddr = metricsAddr
+	}
+
+	cfg.SearchLanguage = os.Getenv("SEARCH_LANGUAGE")
+	if cfg.SearchLanguage == "" {
+		cfg.SearchLanguage = DefaultSearchLanguage
+	}
+
+	cfg.JWTSecret = os.Getenv("JWT_HS256_SECRET")
+	cfg.JWTPublicKeyFile = os.Getenv("JWT_RS256_PUBLIC_KEY_FILE")
+	cfg.JWKSFile = os.Getenv("JWT_JWKS_FILE")
+	cfg.JWTIssuer = os.Getenv("JWT_ISSUER")
+	cfg.JWTAudience = os.Getenv("JWT_AUDIENCE")
+
+	cfg.PolicyFile = os.Getenv("POLICY_FILE")
+
+	// Заданная, но пустая RATE_LIMITS выключает лимиты, поэтому отличаем ее от отсутствующей
+	if rateLimits, ok := os.LookupEnv("RATE_LIMITS"); ok {
+		cfg.RateLimits = rateLimits
+	} else {
+		cfg.RateLimits = DefaultRateLimits
+	}
+	if authRateLimit, ok := os.LookupEnv("AUTH_RATE_LIMIT"); ok {
+		cfg.AuthRateLimit = authRateLimit
+	} else {
+		cfg.AuthRateLimit = DefaultAuthRateLimit
+	}
+	cfg.TrustedProxies = os.Getenv("TRUSTED_PROXIES")
+
+	cfg.TracingExporter = os.Getenv("TRACING_EXPORTER")
+	if cfg.TracingExporter == "" {
+		cfg.TracingExporter = DefaultTracingExporter
+	}
+	cfg.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+
+	var err error
+	if cfg.HTTPReadTimeout, err = durationEnv("HTTP_READ_TIMEOUT", DefaultHTTPReadTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.HTTPWriteTimeout, err = durationEnv("HTTP_WRITE_TIMEOUT", DefaultHTTPWriteTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.HTTPIdleTimeout, err = durationEnv("HTTP_IDLE_TIMEOUT", DefaultHTTPIdleTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.ShutdownTimeout, err = durationEnv("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.ShutdownDelay, err = nonNegativeDurationEnv("SHUTDOWN_DELAY", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.TrashRetention, err = durationEnv("TRASH_RETENTION", DefaultTrashRetention); err != nil {
+		return cfg, err
+	}
+	if cfg.TrashPurgeInterval, err = durationEnv("TRASH_PURGE_INTERVAL", DefaultTrashPurgeInterval); err != nil {
+		return cfg, err
+	}
+	if cfg.IdempotencyKeyTTL, err = durationEnv("IDEMPOTENCY_KEY_TTL", DefaultIdempotencyKeyTTL); err != nil {
+		return cfg, err
+	}
+	if cfg.IdempotencyPurgeInterval, err = durationEnv("IDEMPOTENCY_PURGE_INTERVAL", DefaultIdempotencyPurgeInterval); err != nil {
+		return cfg, err
+	}
+	if cfg.CommentMaxDepth, err = intEnv("COMMENT_MAX_DEPTH", DefaultCommentMaxDepth); err != nil {
+		return cfg, err
+	}
+	if cfg.SSEHeartbeatInterval, err = durationEnv("SSE_HEARTBEAT_INTERVAL", DefaultSSEHeartbeatInterval); err != nil {
+		return cfg, err
+	}
+	// С нулевым буфером клиента брокер отключал бы его на первом же событии
+	if cfg.SSEReplaySize, err = positiveIntEnv("SSE_REPLAY_SIZE", DefaultSSEReplaySize); err != nil {
+		return cfg, err
+	}
+	if cfg.SSEClientBuffer, err = positiveIntEnv("SSE_CLIENT_BUFFER", DefaultSSEClientBuffer); err != nil {
+		return cfg, err
+	}
+	if cfg.ReputationWeights.Upvote, err = intEnv("REPUTATION_UPVOTE", DefaultReputationUpvote); err != nil {
+		return cfg, err
+	}
+	if cfg.ReputationWeights.DownvotePenalty, err = intEnv("REPUTATION_DOWNVOTE_PENALTY", DefaultReputationDownvotePenalty); err != nil {
+		return cfg, err
+	}
+	if cfg.ReputationWeights.Accepted, err = intEnv("REPUTATION_ACCEPTED", DefaultReputationAccepted); err != nil {
+		return cfg, err
+	}
+	if cfg.ReputationWeights.Accept, err = intEnv("REPUTATION_ACCEPT", DefaultReputationAccept); err != nil {
+		return cfg, err
+	}
 	return cfg, nil
 }
+
+// intEnv читает неотрицательное целое число
+func intEnv(key string, defaultValue int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// positiveIntEnv читает целое число не меньше 1
+func positiveIntEnv(key string, defaultValue int) (int, error) {
+	value, err := intEnv(key, defaultValue)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, os.Getenv(key))
+	}
+	return value, nil
+}
+
+// durationEnv читает положительную длительность в формате time.ParseDuration (например, 720h)
+func durationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// nonNegativeDurationEnv читает длительность, для которой 0 - допустимое значение (например, "не ждать")
+func nonNegativeDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, got %q", key, raw)
+	}
+	return value, nil
+}
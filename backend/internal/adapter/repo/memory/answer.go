@@ -2,11 +2,13 @@ package memory
 
 import (
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
 	"context"
-	"errors"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 var _ repo.AnswerRepo = (*AnswerRepo)(nil)
@@ -14,13 +16,18 @@ var _ repo.AnswerRepo = (*AnswerRepo)(nil)
 type AnswerRepo struct {
 	mu           sync.RWMutex
 	answers      map[int]*entity.Answer
+	votes        map[int]map[string]entity.AnswerVote // id ответа -> пользователь -> голос
+	revisions    map[int][]entity.AnswerRevision      // id ответа -> версии по возрастанию номера
 	nextID       int
 	questionRepo *QuestionRepo // Для проверки существования вопроса
+	commentRepo  *CommentRepo  // Для каскадного удаления комментариев
 }
 
 func NewAnswerRepo(questionRepo *QuestionRepo) *AnswerRepo {
 	repo := &AnswerRepo{
 		answers:      make(map[int]*entity.Answer),
+		votes:        make(map[int]map[string]entity.AnswerVote),
+		revisions:    make(map[int][]entity.AnswerRevision),
 		nextID:       1,
 		questionRepo: questionRepo,
 	}
@@ -29,26 +36,32 @@ func NewAnswerRepo(questionRepo *QuestionRepo) *AnswerRepo {
 	return repo
 }
 
-func (a *AnswerRepo) CreateAnswer(ctx context.Context, answer *entity.Answer) error {
+func (a *AnswerRepo) CreateAnswer(ctx context.Context, answer *entity.Answer, version int) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	// Проверяем существование вопроса
 	a.questionRepo.mu.RLock()
-	_, exists := a.questionRepo.questions[answer.QuestionId]
+	question, exists := a.questionRepo.questions[answer.QuestionId]
 	a.questionRepo.mu.RUnlock()
 
-	if !exists {
-		return errors.New("question not found")
+	if !exists || question.DeletedAt.Valid {
+		return errs.NotFound("question %d not found", answer.QuestionId)
 	}
 
-	answer.ID = a.nextID
-	a.nextID++
-	if answer.CreatedAt.IsZero() {
-		answer.CreatedAt = time.Now()
-	}
-	a.answers[answer.ID] = answer
-	return nil
+	return a.questionRepo.versions.change(answer.QuestionId, version, func() {
+		answer.ID = a.nextID
+		a.nextID++
+		if answer.CreatedAt.IsZero() {
+			answer.CreatedAt = time.Now()
+		}
+		if answer.UpdatedAt.IsZero() {
+			answer.UpdatedAt = answer.CreatedAt
+		}
+		a.answers[answer.ID] = answer
+		a.addRevisionLocked(answer.ID, answer.Text, answer.UserId, answer.CreatedAt)
+		a.questionRepo.index.indexAnswer(answer.ID, answer.QuestionId, answer.Text)
+	})
 }
 
 func (a *AnswerRepo) GetAnswer(ctx context.Context, answerId int) (*entity.Answer, error) {
@@ -56,31 +69,178 @@ func (a *AnswerRepo) GetAnswer(ctx context.Context, answerId int) (*entity.Answe
 	defer a.mu.RUnlock()
 
 	answer, exists := a.answers[answerId]
-	if !exists {
-		return nil, errors.New("answer not found")
+	if !exists || answer.DeletedAt.Valid {
+		return nil, errs.NotFound("answer %d not found", answerId)
 	}
 
 	result := *answer
 	return &result, nil
 }
 
-func (a *AnswerRepo) DeleteAnswer(ctx context.Context, answerId int) error {
+func (a *AnswerRepo) UpdateAnswer(ctx context.Context, answer *entity.Answer, editorId string) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if _, exists := a.answers[answerId]; !exists {
-		return errors.New("answer not found")
+	stored, exists := a.answers[answer.ID]
+	if !exists || stored.DeletedAt.Valid {
+		return errs.NotFound("answer %d not found", answer.ID)
 	}
 
-	delete(a.answers, answerId)
+	answer.UpdatedAt = time.Now()
+	stored.Text = answer.Text
+	stored.UpdatedAt = answer.UpdatedAt
+	a.addRevisionLocked(stored.ID, stored.Text, editorId, stored.UpdatedAt)
+	a.questionRepo.index.indexAnswer(stored.ID, stored.QuestionId, stored.Text)
+	a.questionRepo.versions.bump(stored.QuestionId)
 	return nil
 }
 
-// SetAnswerForTesting устанавливает ответ для тестирования
+func (a *AnswerRepo) GetAnswerRevisions(ctx context.Context, answerId int) ([]entity.AnswerRevision, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	answer, exists := a.answers[answerId]
+	if !exists || answer.DeletedAt.Valid {
+		return nil, errs.NotFound("answer %d not found", answerId)
+	}
+	return append([]entity.AnswerRevision{}, a.revisions[answerId]...), nil
+}
+
+func (a *AnswerRepo) GetAnswerRevision(ctx context.Context, answerId int, number int) (*entity.AnswerRevision, error) {
+	revisions, err := a.GetAnswerRevisions(ctx, answerId)
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(revisions) {
+		return nil, errs.NotFound("revision %d of answer %d not found", number, answerId)
+	}
+	revision := revisions[number-1]
+	return &revision, nil
+}
+
+// addRevisionLocked добавляет следующую версию ответа, вызывается под блокировкой ответов
+func (a *AnswerRepo) addRevisionLocked(answerId int, text string, editorId string, createdAt time.Time) {
+	a.revisions[answerId] = append(a.revisions[answerId], entity.AnswerRevision{
+		AnswerId:  answerId,
+		Number:    len(a.revisions[answerId]) + 1,
+		Text:      text,
+		EditorId:  editorId,
+		CreatedAt: createdAt,
+	})
+}
+
+func (a *AnswerRepo) DeleteAnswer(ctx context.Context, answerId int) (*entity.AnswerUnaccepted, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	answer, exists := a.answers[answerId]
+	if !exists || answer.DeletedAt.Valid {
+		return nil, errs.NotFound("answer %d not found", answerId)
+	}
+	deletedAt := time.Now()
+
+	// Снимаем отметку о принятом ответе, как при удалении в postgres
+	var unaccepted *entity.AnswerUnaccepted
+	a.questionRepo.mu.Lock()
+	if question, ok := a.questionRepo.questions[answer.QuestionId]; ok &&
+		question.AcceptedAnswerId != nil && *question.AcceptedAnswerId == answerId {
+		question.AcceptedAnswerId = nil
+		question.AcceptedAt = nil
+		unaccepted = &entity.AnswerUnaccepted{
+			QuestionId:     question.Id,
+			AnswerId:       answerId,
+			AnswerAuthorId: answer.UserId,
+			At:             deletedAt,
+		}
+		if question.AuthorId != nil {
+			unaccepted.QuestionAuthorId = *question.AuthorId
+		}
+	}
+	a.questionRepo.mu.Unlock()
+
+	answer.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+	a.questionRepo.index.removeAnswer(answerId)
+	a.questionRepo.versions.bump(answer.QuestionId)
+	return unaccepted, nil
+}
+
+func (a *AnswerRepo) RestoreAnswer(ctx context.Context, answerId int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	answer, exists := a.answers[answerId]
+	if !exists || !answer.DeletedAt.Valid {
+		return errs.NotFound("answer %d not found in trash", answerId)
+	}
+
+	a.questionRepo.mu.RLock()
+	question, exists := a.questionRepo.questions[answer.QuestionId]
+	a.questionRepo.mu.RUnlock()
+	if !exists || question.DeletedAt.Valid {
+		return errs.Conflict("question %d is deleted, restore it first", answer.QuestionId)
+	}
+
+	answer.DeletedAt = gorm.DeletedAt{}
+	a.questionRepo.index.indexAnswer(answer.ID, answer.QuestionId, answer.Text)
+	a.questionRepo.versions.bump(answer.QuestionId)
+	return nil
+}
+
+func (a *AnswerRepo) VoteAnswer(ctx context.Context, vote *entity.AnswerVote) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	answer, exists := a.answers[vote.AnswerId]
+	if !exists || answer.DeletedAt.Valid {
+		return 0, errs.NotFound("answer %d not found", vote.AnswerId)
+	}
+
+	votes, ok := a.votes[vote.AnswerId]
+	if !ok {
+		votes = make(map[string]entity.AnswerVote)
+		a.votes[vote.AnswerId] = votes
+	}
+
+	existing, voted := votes[vote.UserId]
+	previous := existing.Value
+	if vote.Value == 0 {
+		delete(votes, vote.UserId)
+	} else {
+		vote.UpdatedAt = time.Now()
+		vote.CreatedAt = vote.UpdatedAt
+		if voted {
+			vote.CreatedAt = existing.CreatedAt
+		}
+		votes[vote.UserId] = *vote
+	}
+	answer.Score += vote.Value - previous
+	a.questionRepo.versions.bump(answer.QuestionId)
+	return previous, nil
+}
+
+func (a *AnswerRepo) SetCommentRepo(commentRepo *CommentRepo) {
+	a.commentRepo = commentRepo
+}
+
+// purgeLocked окончательно удаляет ответ с голосами, версиями и комментариями.
+// Вызывается под блокировкой ответов.
+func (a *AnswerRepo) purgeLocked(answerId int) {
+	delete(a.answers, answerId)
+	delete(a.votes, answerId)
+	delete(a.revisions, answerId)
+	if a.commentRepo != nil {
+		a.commentRepo.deleteAnswerComments(answerId)
+	}
+}
+
+// SetAnswerForTesting устанавливает ответ для тестирования, его текст становится первой версией
 func (a *AnswerRepo) SetAnswerForTesting(answer *entity.Answer) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.answers[answer.ID] = answer
+	delete(a.revisions, answer.ID)
+	a.addRevisionLocked(answer.ID, answer.Text, answer.UserId, answer.CreatedAt)
+	a.questionRepo.index.indexAnswer(answer.ID, answer.QuestionId, answer.Text)
 	if answer.ID >= a.nextID {
 		a.nextID = answer.ID + 1
 	}
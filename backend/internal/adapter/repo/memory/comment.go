@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repo.CommentRepo = (*CommentRepo)(nil)
+
+// CommentRepo хранит комментарии. Порядок блокировок: ответы, вопросы, затем комментарии.
+type CommentRepo struct {
+	mu         sync.RWMutex
+	comments   map[int]*entity.Comment
+	nextID     int
+	answerRepo *AnswerRepo // Для проверки, что ответ не удален
+}
+
+func NewCommentRepo(answerRepo *AnswerRepo) *CommentRepo {
+	repo := &CommentRepo{
+		comments:   make(map[int]*entity.Comment),
+		nextID:     1,
+		answerRepo: answerRepo,
+	}
+	// Устанавливаем обратную ссылку для каскадного удаления
+	answerRepo.SetCommentRepo(repo)
+	return repo
+}
+
+func (c *CommentRepo) CreateComment(ctx context.Context, comment *entity.Comment) error {
+	c.answerRepo.mu.RLock()
+	defer c.answerRepo.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.answerVisibleLocked(comment.AnswerId) {
+		return errs.NotFound("answer %d not found", comment.AnswerId)
+	}
+	if comment.ParentId != nil {
+		parent, exists := c.comments[*comment.ParentId]
+		if !exists || parent.AnswerId != comment.AnswerId {
+			return errs.NotFound("comment %d not found", *comment.ParentId)
+		}
+	}
+
+	comment.Id = c.nextID
+	c.nextID++
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	stored := *comment
+	stored.Replies = nil
+	c.comments[comment.Id] = &stored
+	c.bumpQuestionLocked(comment.AnswerId)
+	return nil
+}
+
+func (c *CommentRepo) GetComment(ctx context.Context, commentId int) (*entity.Comment, error) {
+	c.answerRepo.mu.RLock()
+	defer c.answerRepo.mu.RUnlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	comment, exists := c.comments[commentId]
+	if !exists || !c.answerVisibleLocked(comment.AnswerId) {
+		return nil, errs.NotFound("comment %d not found", commentId)
+	}
+	result := *comment
+	return &result, nil
+}
+
+func (c *CommentRepo) GetAnswerComments(ctx context.Context, answerId int) ([]entity.Comment, error) {
+	c.answerRepo.mu.RLock()
+	defer c.answerRepo.mu.RUnlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.answerVisibleLocked(answerId) {
+		return nil, errs.NotFound("answer %d not found", answerId)
+	}
+	return c.collectLocked(func(comment *entity.Comment) bool {
+		return comment.AnswerId == answerId
+	}), nil
+}
+
+func (c *CommentRepo) GetQuestionComments(ctx context.Context, questionId int) ([]entity.Comment, error) {
+	c.answerRepo.mu.RLock()
+	defer c.answerRepo.mu.RUnlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collectLocked(func(comment *entity.Comment) bool {
+		answer := c.answerRepo.answers[comment.AnswerId]
+		return answer != nil && answer.QuestionId == questionId && !answer.DeletedAt.Valid
+	}), nil
+}
+
+func (c *CommentRepo) DeleteComment(ctx context.Context, commentId int) error {
+	c.answerRepo.mu.RLock()
+	defer c.answerRepo.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	comment, exists := c.comments[commentId]
+	if !exists || !c.answerVisibleLocked(comment.AnswerId) {
+		return errs.NotFound("comment %d not found", commentId)
+	}
+	c.deleteTreeLocked(commentId)
+	c.bumpQuestionLocked(comment.AnswerId)
+	return nil
+}
+
+// bumpQuestionLocked поднимает версию вопроса ответа answerId, вызывается под блокировкой ответов
+func (c *CommentRepo) bumpQuestionLocked(answerId int) {
+	if answer, exists := c.answerRepo.answers[answerId]; exists {
+		c.answerRepo.questionRepo.versions.bump(answer.QuestionId)
+	}
+}
+
+// deleteTreeLocked удаляет комментарий и ответы на него, как ON DELETE CASCADE по parent_id
+func (c *CommentRepo) deleteTreeLocked(commentId int) {
+	delete(c.comments, commentId)
+	for id, comment := range c.comments {
+		if comment.ParentId != nil && *comment.ParentId == commentId {
+			c.deleteTreeLocked(id)
+		}
+	}
+}
+
+// deleteAnswerComments удаляет все комментарии ответа, вызывается при окончательном удалении ответа
+func (c *CommentRepo) deleteAnswerComments(answerId int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, comment := range c.comments {
+		if comment.AnswerId == answerId {
+			delete(c.comments, id)
+		}
+	}
+}
+
+// answerVisibleLocked проверяет, что ответ существует и не в корзине; вызывается под блокировкой ответов
+func (c *CommentRepo) answerVisibleLocked(answerId int) bool {
+	answer, exists := c.answerRepo.answers[answerId]
+	return exists && !answer.DeletedAt.Valid
+}
+
+func (c *CommentRepo) collectLocked(match func(comment *entity.Comment) bool) []entity.Comment {
+	comments := []entity.Comment{}
+	for _, comment := range c.comments {
+		if match(comment) {
+			comments = append(comments, *comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Id < comments[j].Id
+	})
+	return comments
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sync"
+	"time"
+)
+
+var _ repo.IdempotencyRepo = (*IdempotencyRepo)(nil)
+
+// idempotencyKey - ключи разных пользователей не пересекаются
+type idempotencyKey struct {
+	userId string
+	key    string
+}
+
+type IdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]*entity.IdempotencyRecord
+}
+
+func NewIdempotencyRepo() *IdempotencyRepo {
+	return &IdempotencyRepo{
+		records: make(map[idempotencyKey]*entity.IdempotencyRecord),
+	}
+}
+
+func (i *IdempotencyRepo) ReserveKey(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	id := idempotencyKey{userId: record.UserId, key: record.Key}
+	if stored, exists := i.records[id]; exists && stored.ExpiresAt.After(record.CreatedAt) {
+		result := *stored
+		return &result, nil
+	}
+	stored := *record
+	stored.StatusCode = 0
+	stored.ContentType = ""
+	stored.Body = nil
+	i.records[id] = &stored
+	return nil, nil
+}
+
+func (i *IdempotencyRepo) CompleteKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	stored, exists := i.records[idempotencyKey{userId: record.UserId, key: record.Key}]
+	if !exists {
+		return errs.NotFound("idempotency key %q not found", record.Key)
+	}
+	stored.StatusCode = record.StatusCode
+	stored.ContentType = record.ContentType
+	stored.Body = append([]byte(nil), record.Body...)
+	return nil
+}
+
+func (i *IdempotencyRepo) ReleaseKey(ctx context.Context, userId string, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	id := idempotencyKey{userId: userId, key: key}
+	if stored, exists := i.records[id]; exists && !stored.Completed() {
+		delete(i.records, id)
+	}
+	return nil
+}
+
+func (i *IdempotencyRepo) PurgeKeys(ctx context.Context, before time.Time) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	purged := 0
+	for id, stored := range i.records {
+		if !stored.ExpiresAt.After(before) {
+			delete(i.records, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,26 @@
+package memory_test
+
+import (
+	"HiTalent_TestTask/backend/internal/adapter/repo/memory"
+	"HiTalent_TestTask/backend/internal/adapter/repo/repotest"
+	"testing"
+)
+
+func TestRepoContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		questionRepo := memory.NewQuestionRepo()
+		answerRepo := memory.NewAnswerRepo(questionRepo)
+		userRepo := memory.NewUserRepo(answerRepo)
+		return repotest.Repos{
+			Questions:   questionRepo,
+			Answers:     answerRepo,
+			Search:      memory.NewSearchRepo(questionRepo),
+			Trash:       memory.NewTrashRepo(questionRepo),
+			Tags:        memory.NewTagRepo(questionRepo),
+			Comments:    memory.NewCommentRepo(answerRepo),
+			Users:       userRepo,
+			Reputation:  memory.NewReputationRepo(userRepo),
+			Idempotency: memory.NewIdempotencyRepo(),
+		}
+	})
+}
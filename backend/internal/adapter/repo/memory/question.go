@@ -2,11 +2,15 @@ package memory
 
 import (
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
 	"context"
-	"errors"
+	"slices"
+	"sort"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 var _ repo.QuestionRepo = (*QuestionRepo)(nil)
@@ -15,27 +19,110 @@ type QuestionRepo struct {
 	mu         sync.RWMutex
 	questions  map[int]*entity.Question
 	nextID     int
-	answerRepo *AnswerRepo // Для загрузки ответов
+	answerRepo *AnswerRepo  // Для загрузки ответов
+	tagRepo    *TagRepo     // Для актуальных имен тегов
+	index      *searchIndex // Полнотекстовый индекс вопросов и ответов
+	versions   *questionVersions
+}
+
+// questionVersions - версии вопросов. Их поднимают репозитории вопросов, ответов, комментариев и тегов
+// под своими блокировками, поэтому у версий отдельная блокировка, которая всегда берется последней.
+// Версия поднимается после изменения, а GetQuestion читает ее до ответов, как триггеры в postgres.
+type questionVersions struct {
+	mu       sync.Mutex
+	versions map[int]entity.QuestionVersion
+}
+
+func (v *questionVersions) get(questionId int) entity.QuestionVersion {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.versions[questionId]
+}
+
+// check проверяет, что версия вопроса равна expected; 0 - любая версия
+func (v *questionVersions) check(questionId int, expected int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.checkLocked(questionId, expected)
+}
+
+func (v *questionVersions) checkLocked(questionId int, expected int) error {
+	if current := v.versions[questionId].Version; expected != 0 && current != expected {
+		return errs.PreconditionFailed("question %d has version %d, expected %d", questionId, current, expected)
+	}
+	return nil
+}
+
+// change применяет apply, если версия вопроса равна expected, и поднимает версию.
+// Проверка и изменение выполняются под блокировкой версий, поэтому другие изменения не вклинятся между ними.
+func (v *questionVersions) change(questionId int, expected int, apply func()) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := v.checkLocked(questionId, expected); err != nil {
+		return err
+	}
+	apply()
+	v.bumpLocked(questionId)
+	return nil
+}
+
+// bump поднимает версии вопросов после изменения их представления
+func (v *questionVersions) bump(questionIds ...int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, questionId := range questionIds {
+		v.bumpLocked(questionId)
+	}
+}
+
+func (v *questionVersions) bumpLocked(questionId int) {
+	v.versions[questionId] = entity.QuestionVersion{
+		Version:    v.versions[questionId].Version + 1,
+		ModifiedAt: time.Now(),
+	}
+}
+
+func (v *questionVersions) remove(questionId int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.versions, questionId)
 }
 
 func (q *QuestionRepo) SetAnswerRepo(answerRepo *AnswerRepo) {
 	q.answerRepo = answerRepo
 }
 
+func (q *QuestionRepo) SetTagRepo(tagRepo *TagRepo) {
+	q.tagRepo = tagRepo
+}
+
 func NewQuestionRepo() *QuestionRepo {
 	return &QuestionRepo{
 		questions: make(map[int]*entity.Question),
 		nextID:    1,
+		index:     newSearchIndex(),
+		versions:  &questionVersions{versions: make(map[int]entity.QuestionVersion)},
 	}
 }
 
-func (q *QuestionRepo) GetQuestionList(ctx context.Context) (*[]entity.Question, error) {
+func (q *QuestionRepo) GetQuestionList(ctx context.Context, filter repo.QuestionListFilter) (*[]entity.Question, error) {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 
 	questions := make([]entity.Question, 0, len(q.questions))
 	for _, question := range q.questions {
-		questions = append(questions, *question)
+		if question.Id > filter.AfterId && !question.DeletedAt.Valid && matchTags(question, filter) {
+			result := *question
+			result.Tags = q.tagsLocked(question)
+			questions = append(questions, result)
+		}
+	}
+	// Порядок обхода map случаен, поэтому сортируем по id как в postgres
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Id < questions[j].Id
+	})
+	if filter.Limit > 0 && len(questions) > filter.Limit {
+		questions = questions[:filter.Limit]
 	}
 	return &questions, nil
 }
@@ -49,21 +136,30 @@ func (q *QuestionRepo) CreateQuestion(ctx context.Context, question *entity.Ques
 	if question.CreatedAt.IsZero() {
 		question.CreatedAt = time.Now()
 	}
+	if question.UpdatedAt.IsZero() {
+		question.UpdatedAt = question.CreatedAt
+	}
 	q.questions[question.Id] = question
+	q.index.indexQuestion(question.Id, question.Text)
+	q.versions.bump(question.Id)
 	return nil
 }
 
 func (q *QuestionRepo) GetQuestion(ctx context.Context, questionId int) (*entity.Question, error) {
+	version := q.versions.get(questionId)
 	q.mu.RLock()
 	question, exists := q.questions[questionId]
-	if !exists {
+	if !exists || question.DeletedAt.Valid {
 		q.mu.RUnlock()
-		return nil, errors.New("question not found")
+		return nil, errs.NotFound("question %d not found", questionId)
 	}
 
 	// Копируем вопрос
 	result := *question
 	result.Answers = []entity.Answer{}
+	result.Tags = q.tagsLocked(question)
+	result.Version = version.Version
+	result.ModifiedAt = version.ModifiedAt
 	q.mu.RUnlock()
 
 	// Загружаем ответы, если answerRepo установлен
@@ -71,27 +167,253 @@ func (q *QuestionRepo) GetQuestion(ctx context.Context, questionId int) (*entity
 		q.answerRepo.mu.RLock()
 		answers := make([]entity.Answer, 0)
 		for _, answer := range q.answerRepo.answers {
-			if answer.QuestionId == questionId {
+			if answer.QuestionId == questionId && !answer.DeletedAt.Valid {
 				answers = append(answers, *answer)
 			}
 		}
 		q.answerRepo.mu.RUnlock()
+		sort.Slice(answers, func(i, j int) bool {
+			return answers[i].ID < answers[j].ID
+		})
 		result.Answers = answers
 	}
 
 	return &result, nil
 }
 
-func (q *QuestionRepo) DeleteQuestion(ctx context.Context, questionId int) error {
+func (q *QuestionRepo) GetQuestionVersion(ctx context.Context, questionId int) (entity.QuestionVersion, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	question, exists := q.questions[questionId]
+	if !exists || question.DeletedAt.Valid {
+		return entity.QuestionVersion{}, errs.NotFound("question %d not found", questionId)
+	}
+	return q.versions.get(questionId), nil
+}
+
+func (q *QuestionRepo) UpdateQuestion(ctx context.Context, question *entity.Question) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, exists := q.questions[question.Id]
+	if !exists || stored.DeletedAt.Valid {
+		return errs.NotFound("question %d not found", question.Id)
+	}
+
+	// Изменения ответов не берут блокировку вопросов, поэтому версия сверяется под блокировкой версий
+	return q.versions.change(question.Id, question.Version, func() {
+		question.UpdatedAt = time.Now()
+		stored.Text = question.Text
+		stored.UpdatedAt = question.UpdatedAt
+		q.index.indexQuestion(stored.Id, stored.Text)
+	})
+}
+
+func (q *QuestionRepo) SetAcceptedAnswer(ctx context.Context, questionId int, answerId *int, version int) (*int, error) {
+	// Порядок блокировок как в AnswerRepo: сначала ответы, затем вопросы
+	if q.answerRepo != nil {
+		q.answerRepo.mu.RLock()
+		defer q.answerRepo.mu.RUnlock()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	question, exists := q.questions[questionId]
+	if !exists || question.DeletedAt.Valid {
+		if answerId != nil {
+			return nil, errs.NotFound("answer %d not found in question %d", *answerId, questionId)
+		}
+		return nil, errs.NotFound("question %d not found", questionId)
+	}
+	previous := question.AcceptedAnswerId
+
+	if answerId == nil {
+		err := q.versions.change(questionId, version, func() {
+			question.AcceptedAnswerId = nil
+			question.AcceptedAt = nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return previous, nil
+	}
+
+	var answer *entity.Answer
+	if q.answerRepo != nil {
+		answer = q.answerRepo.answers[*answerId]
+	}
+	if answer == nil || answer.QuestionId != questionId || answer.DeletedAt.Valid {
+		return nil, errs.NotFound("answer %d not found in question %d", *answerId, questionId)
+	}
+	accepted := *answerId
+	acceptedAt := time.Now()
+	err := q.versions.change(questionId, version, func() {
+		question.AcceptedAnswerId = &accepted
+		question.AcceptedAt = &acceptedAt
+	})
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (q *QuestionRepo) DeleteQuestion(ctx context.Context, questionId int, version int) ([]int, error) {
+	// Порядок блокировок как в AnswerRepo.CreateAnswer: сначала ответы, затем вопросы
+	if q.answerRepo != nil {
+		q.answerRepo.mu.Lock()
+		defer q.answerRepo.mu.Unlock()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	question, exists := q.questions[questionId]
+	if !exists || question.DeletedAt.Valid {
+		return nil, errs.NotFound("question %d not found", questionId)
+	}
+	// Ответы и комментарии меняются под блокировкой ответов, которую держит удаление, поэтому достаточно проверки
+	if err := q.versions.check(questionId, version); err != nil {
+		return nil, err
+	}
+
+	// Ответы уходят в корзину с тем же временем, что и вопрос
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	question.DeletedAt = deletedAt
+	q.index.removeQuestion(questionId)
+	answerIds := []int{}
+	if q.answerRepo != nil {
+		for _, answer := range q.answerRepo.answers {
+			if answer.QuestionId == questionId && !answer.DeletedAt.Valid {
+				answer.DeletedAt = deletedAt
+				answerIds = append(answerIds, answer.ID)
+			}
+		}
+	}
+	q.versions.bump(questionId)
+	sort.Ints(answerIds)
+	return answerIds, nil
+}
+
+func (q *QuestionRepo) RestoreQuestion(ctx context.Context, questionId int) error {
+	if q.answerRepo != nil {
+		q.answerRepo.mu.Lock()
+		defer q.answerRepo.mu.Unlock()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	question, exists := q.questions[questionId]
+	if !exists || !question.DeletedAt.Valid {
+		return errs.NotFound("question %d not found in trash", questionId)
+	}
+
+	deletedAt := question.DeletedAt.Time
+	question.DeletedAt = gorm.DeletedAt{}
+	q.index.indexQuestion(question.Id, question.Text)
+	if q.answerRepo != nil {
+		for _, answer := range q.answerRepo.answers {
+			if answer.QuestionId == questionId && answer.DeletedAt.Valid && answer.DeletedAt.Time.Equal(deletedAt) {
+				answer.DeletedAt = gorm.DeletedAt{}
+				q.index.indexAnswer(answer.ID, answer.QuestionId, answer.Text)
+			}
+		}
+	}
+	q.versions.bump(questionId)
+	return nil
+}
+
+func (q *QuestionRepo) PurgeQuestion(ctx context.Context, questionId int, version int) ([]int, error) {
+	if q.answerRepo != nil {
+		q.answerRepo.mu.Lock()
+		defer q.answerRepo.mu.Unlock()
+	}
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	if _, exists := q.questions[questionId]; !exists {
-		return errors.New("question not found")
+		return nil, errs.NotFound("question %d not found", questionId)
 	}
+	if err := q.versions.check(questionId, version); err != nil {
+		return nil, err
+	}
+	return q.purgeLocked(questionId), nil
+}
 
+// purgeLocked удаляет вопрос с ответами и всем, что к ним относится, как ON DELETE CASCADE в postgres,
+// и возвращает id удаленных ответов. Вызывается под блокировками ответов и вопросов.
+func (q *QuestionRepo) purgeLocked(questionId int) []int {
 	delete(q.questions, questionId)
-	return nil
+	q.index.removeQuestion(questionId)
+	q.versions.remove(questionId)
+	answerIds := []int{}
+	if q.answerRepo != nil {
+		for id, answer := range q.answerRepo.answers {
+			if answer.QuestionId == questionId {
+				q.answerRepo.purgeLocked(id)
+				answerIds = append(answerIds, id)
+			}
+		}
+	}
+	sort.Ints(answerIds)
+	return answerIds
+}
+
+// matchTags проверяет фильтр списка вопросов по тегам
+func matchTags(question *entity.Question, filter repo.QuestionListFilter) bool {
+	if len(filter.TagIds) == 0 {
+		return true
+	}
+	matched := 0
+	for _, tagId := range filter.TagIds {
+		for _, tag := range question.Tags {
+			if tag.Id == tagId {
+				matched++
+				break
+			}
+		}
+	}
+	if filter.TagMode == entity.TagModeAny {
+		return matched > 0
+	}
+	return matched == len(filter.TagIds)
+}
+
+// tagsLocked возвращает теги вопроса с актуальными именами, вызывается под блокировкой вопросов
+func (q *QuestionRepo) tagsLocked(question *entity.Question) []entity.Tag {
+	if q.tagRepo == nil {
+		return question.Tags
+	}
+	return q.tagRepo.tagsLocked(question.Tags)
+}
+
+// bumpTagged поднимает версии вопросов с тегом tagId: имя тега входит в их представление
+func (q *QuestionRepo) bumpTagged(tagId int) {
+	q.mu.RLock()
+	var questionIds []int
+	for _, question := range q.questions {
+		if slices.ContainsFunc(question.Tags, func(tag entity.Tag) bool { return tag.Id == tagId }) {
+			questionIds = append(questionIds, question.Id)
+		}
+	}
+	q.mu.RUnlock()
+	q.versions.bump(questionIds...)
+}
+
+// tagCounts возвращает число вопросов вне корзины для каждого тега
+func (q *QuestionRepo) tagCounts() map[int]int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, question := range q.questions {
+		if question.DeletedAt.Valid {
+			continue
+		}
+		for _, tag := range question.Tags {
+			counts[tag.Id]++
+		}
+	}
+	return counts
 }
 
 // SetQuestionForTesting устанавливает вопрос для тестирования
@@ -99,6 +421,8 @@ func (q *QuestionRepo) SetQuestionForTesting(question *entity.Question) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	q.questions[question.Id] = question
+	q.index.indexQuestion(question.Id, question.Text)
+	q.versions.bump(question.Id)
 	if question.Id >= q.nextID {
 		q.nextID = question.Id + 1
 	}
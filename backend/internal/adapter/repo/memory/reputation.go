@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repo.ReputationRepo = (*ReputationRepo)(nil)
+
+// ReputationRepo хранит журнал репутации. Порядок блокировок: журнал, затем пользователи;
+// с блокировками ответов и вопросов журнал не пересекается.
+type ReputationRepo struct {
+	mu       sync.RWMutex
+	entries  []entity.ReputationEntry
+	nextID   int
+	userRepo *UserRepo // Для суммы очков в профиле и, через него, голосов и принятых ответов
+}
+
+func NewReputationRepo(userRepo *UserRepo) *ReputationRepo {
+	return &ReputationRepo{
+		nextID:   1,
+		userRepo: userRepo,
+	}
+}
+
+func (r *ReputationRepo) AddReputation(ctx context.Context, entries []entity.ReputationEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.userRepo.mu.Lock()
+	defer r.userRepo.mu.Unlock()
+
+	for _, entry := range entries {
+		r.appendLocked(entry)
+		// Как UPDATE в postgres: пользователя без профиля обновлять нечего
+		if user, exists := r.userRepo.users[entry.UserId]; exists {
+			user.Reputation += entry.Points
+		}
+	}
+	return nil
+}
+
+func (r *ReputationRepo) ReplaceReputation(ctx context.Context, entries []entity.ReputationEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.userRepo.mu.Lock()
+	defer r.userRepo.mu.Unlock()
+
+	r.entries = nil
+	totals := make(map[string]int)
+	for _, entry := range entries {
+		r.appendLocked(entry)
+		totals[entry.UserId] += entry.Points
+	}
+	for id, user := range r.userRepo.users {
+		user.Reputation = totals[id]
+	}
+	return nil
+}
+
+func (r *ReputationRepo) appendLocked(entry entity.ReputationEntry) {
+	entry.Id = r.nextID
+	r.nextID++
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	r.entries = append(r.entries, entry)
+}
+
+func (r *ReputationRepo) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]entity.LeaderboardEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.userRepo.mu.RLock()
+	defer r.userRepo.mu.RUnlock()
+
+	totals := make(map[string]int)
+	for _, entry := range r.entries {
+		if !entry.CreatedAt.Before(since) {
+			totals[entry.UserId] += entry.Points
+		}
+	}
+
+	leaders := []entity.LeaderboardEntry{}
+	for id, points := range totals {
+		user, exists := r.userRepo.users[id]
+		if !exists || points <= 0 {
+			continue
+		}
+		leaders = append(leaders, entity.LeaderboardEntry{UserId: id, DisplayName: user.DisplayName, Reputation: points})
+	}
+	sort.Slice(leaders, func(i, j int) bool {
+		if leaders[i].Reputation != leaders[j].Reputation {
+			return leaders[i].Reputation > leaders[j].Reputation
+		}
+		return leaders[i].UserId < leaders[j].UserId
+	})
+	if limit > 0 && len(leaders) > limit {
+		leaders = leaders[:limit]
+	}
+	return leaders, nil
+}
+
+// GetAnswerReputation суммирует журнал так же, как GROUP BY в postgres
+func (r *ReputationRepo) GetAnswerReputation(ctx context.Context, answerIds []int) ([]entity.ReputationEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type key struct {
+		userId   string
+		reason   entity.ReputationReason
+		answerId int
+	}
+	matches := answerFilter(answerIds)
+	totals := make(map[key]int)
+	for _, entry := range r.entries {
+		if matches(entry.AnswerId) {
+			totals[key{entry.UserId, entry.Reason, entry.AnswerId}] += entry.Points
+		}
+	}
+
+	entries := []entity.ReputationEntry{}
+	for k, points := range totals {
+		if points != 0 {
+			entries = append(entries, entity.ReputationEntry{UserId: k.userId, Points: points, Reason: k.reason, AnswerId: k.answerId})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].AnswerId != entries[j].AnswerId {
+			return entries[i].AnswerId < entries[j].AnswerId
+		}
+		if entries[i].UserId != entries[j].UserId {
+			return entries[i].UserId < entries[j].UserId
+		}
+		return entries[i].Reason < entries[j].Reason
+	})
+	return entries, nil
+}
+
+// answerFilter проверяет id ответа по фильтру answerIds; пустой фильтр пропускает все ответы
+func answerFilter(answerIds []int) func(answerId int) bool {
+	if len(answerIds) == 0 {
+		return func(int) bool { return true }
+	}
+	set := make(map[int]bool, len(answerIds))
+	for _, id := range answerIds {
+		set[id] = true
+	}
+	return func(answerId int) bool { return set[answerId] }
+}
+
+func (r *ReputationRepo) GetVotes(ctx context.Context, answerIds []int) ([]entity.AnswerVoted, error) {
+	answerRepo := r.userRepo.answerRepo
+	answerRepo.mu.RLock()
+	defer answerRepo.mu.RUnlock()
+
+	matches := answerFilter(answerIds)
+	votes := []entity.AnswerVoted{}
+	for answerId, answerVotes := range answerRepo.votes {
+		answer, exists := answerRepo.answers[answerId]
+		if !exists || answer.DeletedAt.Valid || !matches(answerId) {
+			continue
+		}
+		for _, vote := range answerVotes {
+			votes = append(votes, entity.AnswerVoted{
+				AnswerId:   answerId,
+				QuestionId: answer.QuestionId,
+				AuthorId:   answer.UserId,
+				VoterId:    vote.UserId,
+				Value:      vote.Value,
+				At:         vote.UpdatedAt,
+			})
+		}
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].AnswerId != votes[j].AnswerId {
+			return votes[i].AnswerId < votes[j].AnswerId
+		}
+		return votes[i].VoterId < votes[j].VoterId
+	})
+	return votes, nil
+}
+
+func (r *ReputationRepo) GetAcceptedAnswers(ctx context.Context, answerIds []int) ([]entity.AnswerAccepted, error) {
+	// Порядок блокировок как в AnswerRepo: сначала ответы, затем вопросы
+	answerRepo := r.userRepo.answerRepo
+	answerRepo.mu.RLock()
+	defer answerRepo.mu.RUnlock()
+	questionRepo := answerRepo.questionRepo
+	questionRepo.mu.RLock()
+	defer questionRepo.mu.RUnlock()
+
+	matches := answerFilter(answerIds)
+	accepted := []entity.AnswerAccepted{}
+	for _, question := range questionRepo.questions {
+		if question.DeletedAt.Valid || question.AcceptedAnswerId == nil || !matches(*question.AcceptedAnswerId) {
+			continue
+		}
+		answer, exists := answerRepo.answers[*question.AcceptedAnswerId]
+		if !exists || answer.DeletedAt.Valid {
+			continue
+		}
+		event := entity.AnswerAccepted{
+			QuestionId:     question.Id,
+			AnswerId:       answer.ID,
+			AnswerAuthorId: answer.UserId,
+			At:             question.UpdatedAt,
+		}
+		if question.AuthorId != nil {
+			event.QuestionAuthorId = *question.AuthorId
+		}
+		if question.AcceptedAt != nil {
+			event.At = *question.AcceptedAt
+		}
+		accepted = append(accepted, event)
+	}
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].QuestionId < accepted[j].QuestionId
+	})
+	return accepted, nil
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var _ repo.SearchRepo = (*SearchRepo)(nil)
+
+// searchIndex - инвертированный индекс по текстам вопросов и ответов.
+// Обновляется репозиториями при каждом изменении текста.
+type searchIndex struct {
+	mu             sync.RWMutex
+	questionTerms  map[string]map[int]int // термин -> id вопроса -> число вхождений
+	answerTerms    map[string]map[int]int // термин -> id ответа -> число вхождений
+	questionLen    map[int]int
+	answerLen      map[int]int
+	answerQuestion map[int]int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		questionTerms:  make(map[string]map[int]int),
+		answerTerms:    make(map[string]map[int]int),
+		questionLen:    make(map[int]int),
+		answerLen:      make(map[int]int),
+		answerQuestion: make(map[int]int),
+	}
+}
+
+// tokenize разбивает текст на термины. Стемминга нет, поведение соответствует
+// конфигурации simple в postgres.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func addPostings(postings map[string]map[int]int, id int, terms []string) {
+	for _, term := range terms {
+		docs, ok := postings[term]
+		if !ok {
+			docs = make(map[int]int)
+			postings[term] = docs
+		}
+		docs[id]++
+	}
+}
+
+func removePostings(postings map[string]map[int]int, id int) {
+	for term, docs := range postings {
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(postings, term)
+		}
+	}
+}
+
+func (i *searchIndex) indexQuestion(questionId int, text string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	removePostings(i.questionTerms, questionId)
+	terms := tokenize(text)
+	addPostings(i.questionTerms, questionId, terms)
+	i.questionLen[questionId] = len(terms)
+}
+
+func (i *searchIndex) removeQuestion(questionId int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	removePostings(i.questionTerms, questionId)
+	delete(i.questionLen, questionId)
+	for answerId, ownerId := range i.answerQuestion {
+		if ownerId == questionId {
+			removePostings(i.answerTerms, answerId)
+			delete(i.answerLen, answerId)
+			delete(i.answerQuestion, answerId)
+		}
+	}
+}
+
+func (i *searchIndex) indexAnswer(answerId int, questionId int, text string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	removePostings(i.answerTerms, answerId)
+	terms := tokenize(text)
+	addPostings(i.answerTerms, answerId, terms)
+	i.answerLen[answerId] = len(terms)
+	i.answerQuestion[answerId] = questionId
+}
+
+func (i *searchIndex) removeAnswer(answerId int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	removePostings(i.answerTerms, answerId)
+	delete(i.answerLen, answerId)
+	delete(i.answerQuestion, answerId)
+}
+
+// match возвращает документы, содержащие все термины запроса, с их релевантностью
+func match(postings map[string]map[int]int, docLen map[int]int, terms []string) map[int]float64 {
+	ranks := make(map[int]float64)
+	for n, term := range terms {
+		docs := postings[term]
+		if n == 0 {
+			for id, count := range docs {
+				ranks[id] = float64(count)
+			}
+			continue
+		}
+		for id := range ranks {
+			count, ok := docs[id]
+			if !ok {
+				delete(ranks, id)
+				continue
+			}
+			ranks[id] += float64(count)
+		}
+	}
+	for id := range ranks {
+		ranks[id] /= float64(docLen[id])
+	}
+	return ranks
+}
+
+// search считает релевантность вопросов так же, как postgres-адаптер:
+// совпадение в вопросе плюс лучшее совпадение среди ответов с весом AnswerRankWeight
+func (i *searchIndex) search(text string) map[int]float64 {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ranks := match(i.questionTerms, i.questionLen, terms)
+
+	bestAnswer := make(map[int]float64)
+	for answerId, rank := range match(i.answerTerms, i.answerLen, terms) {
+		questionId := i.answerQuestion[answerId]
+		if rank > bestAnswer[questionId] {
+			bestAnswer[questionId] = rank
+		}
+	}
+	for questionId, rank := range bestAnswer {
+		ranks[questionId] += rank * repo.AnswerRankWeight
+	}
+	return ranks
+}
+
+type SearchRepo struct {
+	questionRepo *QuestionRepo
+}
+
+func NewSearchRepo(questionRepo *QuestionRepo) *SearchRepo {
+	return &SearchRepo{
+		questionRepo: questionRepo,
+	}
+}
+
+func (s *SearchRepo) Search(ctx context.Context, query repo.SearchQuery) ([]entity.SearchHit, error) {
+	ranks := s.questionRepo.index.search(query.Text)
+
+	s.questionRepo.mu.RLock()
+	hits := make([]entity.SearchHit, 0, len(ranks))
+	for questionId, rank := range ranks {
+		question, exists := s.questionRepo.questions[questionId]
+		if !exists {
+			continue
+		}
+		hit := entity.SearchHit{Question: *question, Rank: rank}
+		hit.Answers = nil
+		hits = append(hits, hit)
+	}
+	s.questionRepo.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Id < hits[j].Id
+	})
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repo.TagRepo = (*TagRepo)(nil)
+
+type TagRepo struct {
+	mu           sync.RWMutex
+	tags         map[int]*entity.Tag
+	synonyms     map[string]int // синоним -> id тега
+	nextID       int
+	questionRepo *QuestionRepo // Для подсчета вопросов с тегом
+}
+
+func NewTagRepo(questionRepo *QuestionRepo) *TagRepo {
+	repo := &TagRepo{
+		tags:         make(map[int]*entity.Tag),
+		synonyms:     make(map[string]int),
+		nextID:       1,
+		questionRepo: questionRepo,
+	}
+	// Устанавливаем обратную ссылку, чтобы вопросы отдавали актуальные имена тегов
+	questionRepo.SetTagRepo(repo)
+	return repo
+}
+
+func (t *TagRepo) GetTags(ctx context.Context) ([]entity.TagInfo, error) {
+	// Порядок блокировок как в QuestionRepo: сначала вопросы, затем теги
+	counts := t.questionRepo.tagCounts()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tags := make([]entity.TagInfo, 0, len(t.tags))
+	for _, tag := range t.tags {
+		tags = append(tags, t.infoLocked(tag, counts))
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (t *TagRepo) GetTag(ctx context.Context, tagId int) (*entity.TagInfo, error) {
+	counts := t.questionRepo.tagCounts()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tag, exists := t.tags[tagId]
+	if !exists {
+		return nil, errs.NotFound("tag %d not found", tagId)
+	}
+	info := t.infoLocked(tag, counts)
+	return &info, nil
+}
+
+func (t *TagRepo) CreateTag(ctx context.Context, tag *entity.Tag) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkNameLocked(tag.Name, 0); err != nil {
+		return err
+	}
+	tag.Id = t.nextID
+	t.nextID++
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = time.Now()
+	}
+	stored := *tag
+	t.tags[tag.Id] = &stored
+	return nil
+}
+
+func (t *TagRepo) RenameTag(ctx context.Context, tagId int, name string) error {
+	if err := t.rename(tagId, name); err != nil {
+		return err
+	}
+	// Блокировка тегов уже отпущена: вопросы блокируются раньше тегов
+	t.questionRepo.bumpTagged(tagId)
+	return nil
+}
+
+func (t *TagRepo) rename(tagId int, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tag, exists := t.tags[tagId]
+	if !exists {
+		return errs.NotFound("tag %d not found", tagId)
+	}
+	if err := t.checkNameLocked(name, tagId); err != nil {
+		return err
+	}
+	delete(t.synonyms, name)
+	tag.Name = name
+	return nil
+}
+
+func (t *TagRepo) AddTagSynonym(ctx context.Context, tagId int, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.tags[tagId]; !exists {
+		return errs.NotFound("tag %d not found", tagId)
+	}
+	if err := t.checkNameLocked(name, 0); err != nil {
+		return err
+	}
+	t.synonyms[name] = tagId
+	return nil
+}
+
+func (t *TagRepo) ResolveTags(ctx context.Context, names []string) (map[string]entity.Tag, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	resolved := make(map[string]entity.Tag, len(names))
+	for _, name := range names {
+		if tag := t.findLocked(name); tag != nil {
+			resolved[name] = *tag
+		}
+	}
+	return resolved, nil
+}
+
+// checkNameLocked проверяет, что имя не занято тегом или синонимом.
+// Синоним тега ownerId с этим именем не считается конфликтом, так тег можно переименовать в свой синоним.
+func (t *TagRepo) checkNameLocked(name string, ownerId int) error {
+	for _, tag := range t.tags {
+		if tag.Name == name && tag.Id != ownerId {
+			return errs.Conflict("tag %q already exists", name)
+		}
+	}
+	if tagId, exists := t.synonyms[name]; exists && (ownerId == 0 || tagId != ownerId) {
+		return errs.Conflict("tag %q is already a synonym", name)
+	}
+	return nil
+}
+
+func (t *TagRepo) findLocked(name string) *entity.Tag {
+	if tagId, exists := t.synonyms[name]; exists {
+		return t.tags[tagId]
+	}
+	for _, tag := range t.tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+func (t *TagRepo) infoLocked(tag *entity.Tag, counts map[int]int) entity.TagInfo {
+	synonyms := []string{}
+	for name, tagId := range t.synonyms {
+		if tagId == tag.Id {
+			synonyms = append(synonyms, name)
+		}
+	}
+	sort.Strings(synonyms)
+	return entity.TagInfo{Tag: *tag, QuestionCount: counts[tag.Id], Synonyms: synonyms}
+}
+
+// tagsLocked возвращает теги по id с актуальными именами, вызывается под блокировкой вопросов
+func (t *TagRepo) tagsLocked(tags []entity.Tag) []entity.Tag {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]entity.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if stored, exists := t.tags[tag.Id]; exists {
+			result = append(result, *stored)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"time"
+)
+
+var _ repo.TrashRepo = (*TrashRepo)(nil)
+
+type TrashRepo struct {
+	questionRepo *QuestionRepo
+}
+
+func NewTrashRepo(questionRepo *QuestionRepo) *TrashRepo {
+	return &TrashRepo{
+		questionRepo: questionRepo,
+	}
+}
+
+func (t *TrashRepo) GetTrash(ctx context.Context, limit int) (*entity.Trash, error) {
+	q := t.questionRepo
+	if q.answerRepo != nil {
+		q.answerRepo.mu.RLock()
+		defer q.answerRepo.mu.RUnlock()
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	trash := &entity.Trash{Questions: []entity.Question{}, Answers: []entity.Answer{}}
+	for _, question := range q.questions {
+		if question.DeletedAt.Valid {
+			trash.Questions = append(trash.Questions, *question)
+		}
+	}
+	if q.answerRepo != nil {
+		for _, answer := range q.answerRepo.answers {
+			// Ответы удаленных вопросов восстанавливаются только вместе с вопросом
+			if question, ok := q.questions[answer.QuestionId]; ok && answer.DeletedAt.Valid && !question.DeletedAt.Valid {
+				trash.Answers = append(trash.Answers, *answer)
+			}
+		}
+	}
+
+	sort.Slice(trash.Questions, func(i, j int) bool {
+		return deletedLater(trash.Questions[i].DeletedAt.Time, trash.Questions[i].Id,
+			trash.Questions[j].DeletedAt.Time, trash.Questions[j].Id)
+	})
+	sort.Slice(trash.Answers, func(i, j int) bool {
+		return deletedLater(trash.Answers[i].DeletedAt.Time, trash.Answers[i].ID,
+			trash.Answers[j].DeletedAt.Time, trash.Answers[j].ID)
+	})
+	if len(trash.Questions) > limit {
+		trash.Questions = trash.Questions[:limit]
+	}
+	if len(trash.Answers) > limit {
+		trash.Answers = trash.Answers[:limit]
+	}
+	return trash, nil
+}
+
+// deletedLater задает порядок корзины: сначала удаленные последними, при равенстве - больший id
+func deletedLater(a time.Time, aId int, b time.Time, bId int) bool {
+	if !a.Equal(b) {
+		return a.After(b)
+	}
+	return aId > bId
+}
+
+func (t *TrashRepo) PurgeTrash(ctx context.Context, before time.Time) (entity.PurgeResult, error) {
+	q := t.questionRepo
+	if q.answerRepo != nil {
+		q.answerRepo.mu.Lock()
+		defer q.answerRepo.mu.Unlock()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var result entity.PurgeResult
+	for id, question := range q.questions {
+		if question.DeletedAt.Valid && question.DeletedAt.Time.Before(before) {
+			q.purgeLocked(id)
+			result.Questions++
+		}
+	}
+	if q.answerRepo != nil {
+		for id, answer := range q.answerRepo.answers {
+			if answer.DeletedAt.Valid && answer.DeletedAt.Time.Before(before) {
+				q.answerRepo.purgeLocked(id)
+				result.Answers++
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repo.UserRepo = (*UserRepo)(nil)
+
+// UserRepo хранит профили. Блокировка пользователей не вкладывается в блокировки ответов и вопросов.
+type UserRepo struct {
+	mu         sync.RWMutex
+	users      map[string]*entity.User
+	answerRepo *AnswerRepo // Для ответов и, через него, вопросов пользователя
+}
+
+func NewUserRepo(answerRepo *AnswerRepo) *UserRepo {
+	return &UserRepo{
+		users:      make(map[string]*entity.User),
+		answerRepo: answerRepo,
+	}
+}
+
+func (u *UserRepo) EnsureUser(ctx context.Context, user *entity.User) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if stored, exists := u.users[user.Id]; exists {
+		*user = *stored
+		return nil
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	stored := *user
+	u.users[user.Id] = &stored
+	return nil
+}
+
+func (u *UserRepo) GetUser(ctx context.Context, userId string) (*entity.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	user, exists := u.users[userId]
+	if !exists {
+		return nil, errs.NotFound("user %q not found", userId)
+	}
+	result := *user
+	return &result, nil
+}
+
+func (u *UserRepo) UpdateUser(ctx context.Context, user *entity.User) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stored, exists := u.users[user.Id]
+	if !exists {
+		return errs.NotFound("user %q not found", user.Id)
+	}
+	stored.DisplayName = user.DisplayName
+	stored.Bio = user.Bio
+	return nil
+}
+
+func (u *UserRepo) GetUserAnswers(ctx context.Context, userId string, filter repo.UserListFilter) ([]entity.Answer, error) {
+	if _, err := u.GetUser(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	u.answerRepo.mu.RLock()
+	defer u.answerRepo.mu.RUnlock()
+
+	answers := []entity.Answer{}
+	for _, answer := range u.answerRepo.answers {
+		if answer.UserId == userId && answer.ID > filter.AfterId && !answer.DeletedAt.Valid {
+			answers = append(answers, *answer)
+		}
+	}
+	sort.Slice(answers, func(i, j int) bool {
+		return answers[i].ID < answers[j].ID
+	})
+	if filter.Limit > 0 && len(answers) > filter.Limit {
+		answers = answers[:filter.Limit]
+	}
+	return answers, nil
+}
+
+func (u *UserRepo) GetUserQuestions(ctx context.Context, userId string, filter repo.UserListFilter) ([]entity.Question, error) {
+	if _, err := u.GetUser(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	questionRepo := u.answerRepo.questionRepo
+	questionRepo.mu.RLock()
+	defer questionRepo.mu.RUnlock()
+
+	questions := []entity.Question{}
+	for _, question := range questionRepo.questions {
+		if question.AuthorId != nil && *question.AuthorId == userId &&
+			question.Id > filter.AfterId && !question.DeletedAt.Valid {
+			result := *question
+			result.Tags = questionRepo.tagsLocked(question)
+			questions = append(questions, result)
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Id < questions[j].Id
+	})
+	if filter.Limit > 0 && len(questions) > filter.Limit {
+		questions = questions[:filter.Limit]
+	}
+	return questions, nil
+}
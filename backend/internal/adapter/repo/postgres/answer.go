@@ -2,11 +2,14 @@ package postgres
 
 import (
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ repo.AnswerRepo = (*AnswerRepo)(nil)
@@ -21,40 +24,213 @@ func NewAnswerRepo(db *gorm.DB) *AnswerRepo {
 	}
 }
 
-func (a *AnswerRepo) CreateAnswer(ctx context.Context, answer *entity.Answer) error {
-	// Проверяем, существует ли вопрос
-	var question entity.Question
-	if err := a.db.WithContext(ctx).First(&question, answer.QuestionId).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("question not found")
+func (a *AnswerRepo) CreateAnswer(ctx context.Context, answer *entity.Answer, version int) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем строку вопроса: его не удалят и версия не изменится до вставки ответа
+		var question entity.Question
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "version").First(&question, answer.QuestionId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.NotFound("question %d not found", answer.QuestionId)
+			}
+			return err
+		}
+		if version != 0 && question.Version != version {
+			return errs.PreconditionFailed("question %d has version %d, expected %d", answer.QuestionId, question.Version, version)
 		}
-		return err
-	}
 
-	if err := a.db.WithContext(ctx).Create(answer).Error; err != nil {
-		return err
-	}
-	return nil
+		if err := tx.Create(answer).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.AnswerRevision{
+			AnswerId:  answer.ID,
+			Number:    1,
+			Text:      answer.Text,
+			EditorId:  answer.UserId,
+			CreatedAt: answer.CreatedAt,
+		}).Error
+	})
 }
 
 func (a *AnswerRepo) GetAnswer(ctx context.Context, answerId int) (*entity.Answer, error) {
 	var answer entity.Answer
 	if err := a.db.WithContext(ctx).First(&answer, answerId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("answer not found")
+			return nil, errs.NotFound("answer %d not found", answerId)
 		}
 		return nil, err
 	}
 	return &answer, nil
 }
 
-func (a *AnswerRepo) DeleteAnswer(ctx context.Context, answerId int) error {
-	result := a.db.WithContext(ctx).Delete(&entity.Answer{}, answerId)
+func (a *AnswerRepo) UpdateAnswer(ctx context.Context, answer *entity.Answer, editorId string) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем строку ответа, чтобы параллельные правки получили разные номера версий
+		var stored entity.Answer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&stored, answer.ID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.NotFound("answer %d not found", answer.ID)
+			}
+			return err
+		}
+
+		answer.UpdatedAt = time.Now()
+		if err := tx.Model(answer).Select("text", "updated_at").Updates(answer).Error; err != nil {
+			return err
+		}
+
+		var last int
+		err = tx.Model(&entity.AnswerRevision{}).Where("answer_id = ?", answer.ID).
+			Select("COALESCE(MAX(number), 0)").Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&entity.AnswerRevision{
+			AnswerId:  answer.ID,
+			Number:    last + 1,
+			Text:      answer.Text,
+			EditorId:  editorId,
+			CreatedAt: answer.UpdatedAt,
+		}).Error
+	})
+}
+
+func (a *AnswerRepo) GetAnswerRevisions(ctx context.Context, answerId int) ([]entity.AnswerRevision, error) {
+	if _, err := a.GetAnswer(ctx, answerId); err != nil {
+		return nil, err
+	}
+	var revisions []entity.AnswerRevision
+	err := a.db.WithContext(ctx).Where("answer_id = ?", answerId).Order("number").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (a *AnswerRepo) GetAnswerRevision(ctx context.Context, answerId int, number int) (*entity.AnswerRevision, error) {
+	if _, err := a.GetAnswer(ctx, answerId); err != nil {
+		return nil, err
+	}
+	var revision entity.AnswerRevision
+	err := a.db.WithContext(ctx).Where("answer_id = ? AND number = ?", answerId, number).Take(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("revision %d of answer %d not found", number, answerId)
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (a *AnswerRepo) DeleteAnswer(ctx context.Context, answerId int) (*entity.AnswerUnaccepted, error) {
+	var unaccepted *entity.AnswerUnaccepted
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем строку ответа: параллельное удаление не должно второй раз снять отметку
+		var answer entity.Answer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "user_id").First(&answer, answerId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.NotFound("answer %d not found", answerId)
+			}
+			return err
+		}
+		deletedAt := time.Now()
+		if err := tx.Model(&answer).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+
+		// ON DELETE SET NULL не срабатывает при мягком удалении, снимаем отметку сами
+		var questions []entity.AnswerUnaccepted
+		err = tx.Raw(`
+			UPDATE questions SET accepted_answer_id = NULL, accepted_at = NULL
+			WHERE accepted_answer_id = ?
+			RETURNING id AS question_id, COALESCE(author_id, '') AS question_author_id`, answerId).
+			Scan(&questions).Error
+		if err != nil {
+			return err
+		}
+		if len(questions) > 0 {
+			unaccepted = &questions[0]
+			unaccepted.AnswerId = answerId
+			unaccepted.AnswerAuthorId = answer.UserId
+			unaccepted.At = deletedAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unaccepted, nil
+}
+
+func (a *AnswerRepo) RestoreAnswer(ctx context.Context, answerId int) error {
+	result := a.db.WithContext(ctx).Unscoped().Model(&entity.Answer{}).
+		Where("id = ? AND deleted_at IS NOT NULL", answerId).
+		Where("question_id IN (SELECT id FROM questions WHERE deleted_at IS NULL)").
+		UpdateColumn("deleted_at", nil)
 	if result.Error != nil {
 		return result.Error
 	}
-	if result.RowsAffected == 0 {
-		return errors.New("answer not found")
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var answer entity.Answer
+	err := a.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&answer, answerId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("answer %d not found in trash", answerId)
+		}
+		return err
+	}
+	return errs.Conflict("question %d is deleted, restore it first", answer.QuestionId)
+}
+
+func (a *AnswerRepo) VoteAnswer(ctx context.Context, vote *entity.AnswerVote) (int, error) {
+	previous := 0
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем строку ответа, чтобы голоса за один ответ применялись последовательно
+		var answer entity.Answer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&answer, vote.AnswerId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.NotFound("answer %d not found", vote.AnswerId)
+			}
+			return err
+		}
+
+		var existing entity.AnswerVote
+		err = tx.Where("answer_id = ? AND user_id = ?", vote.AnswerId, vote.UserId).Take(&existing).Error
+		switch {
+		case err == nil:
+			previous = existing.Value
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if vote.Value == 0 {
+			err = tx.Where("answer_id = ? AND user_id = ?", vote.AnswerId, vote.UserId).
+				Delete(&entity.AnswerVote{}).Error
+		} else {
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "answer_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(vote).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		// Счет меняется без изменения updated_at: голос не правка ответа
+		if delta := vote.Value - previous; delta != 0 {
+			return tx.Model(&entity.Answer{}).Where("id = ?", vote.AnswerId).
+				UpdateColumn("score", gorm.Expr("score + ?", delta)).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return previous, nil
 }
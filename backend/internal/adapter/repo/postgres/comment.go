@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var _ repo.CommentRepo = (*CommentRepo)(nil)
+
+// visibleAnswers ограничивает комментарии ответами вне корзины
+const visibleAnswers = "answer_id IN (SELECT id FROM answers WHERE deleted_at IS NULL)"
+
+type CommentRepo struct {
+	db *gorm.DB
+}
+
+func NewCommentRepo(db *gorm.DB) *CommentRepo {
+	return &CommentRepo{
+		db: db,
+	}
+}
+
+func (c *CommentRepo) CreateComment(ctx context.Context, comment *entity.Comment) error {
+	var answer entity.Answer
+	if err := c.db.WithContext(ctx).Select("id").First(&answer, comment.AnswerId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("answer %d not found", comment.AnswerId)
+		}
+		return err
+	}
+	if comment.ParentId != nil {
+		var parents int64
+		err := c.db.WithContext(ctx).Model(&entity.Comment{}).
+			Where("id = ? AND answer_id = ?", *comment.ParentId, comment.AnswerId).
+			Count(&parents).Error
+		if err != nil {
+			return err
+		}
+		if parents == 0 {
+			return errs.NotFound("comment %d not found", *comment.ParentId)
+		}
+	}
+
+	if err := c.db.WithContext(ctx).Create(comment).Error; err != nil {
+		// Ответ или родительский комментарий могли удалить между проверкой и вставкой
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return errs.NotFound("answer %d not found", comment.AnswerId)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *CommentRepo) GetComment(ctx context.Context, commentId int) (*entity.Comment, error) {
+	var comment entity.Comment
+	if err := c.db.WithContext(ctx).Where(visibleAnswers).First(&comment, commentId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("comment %d not found", commentId)
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *CommentRepo) GetAnswerComments(ctx context.Context, answerId int) ([]entity.Comment, error) {
+	var answer entity.Answer
+	if err := c.db.WithContext(ctx).Select("id").First(&answer, answerId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("answer %d not found", answerId)
+		}
+		return nil, err
+	}
+
+	comments := []entity.Comment{}
+	err := c.db.WithContext(ctx).Where("answer_id = ?", answerId).Order("id ASC").Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (c *CommentRepo) GetQuestionComments(ctx context.Context, questionId int) ([]entity.Comment, error) {
+	comments := []entity.Comment{}
+	err := c.db.WithContext(ctx).
+		Where("answer_id IN (SELECT id FROM answers WHERE question_id = ? AND deleted_at IS NULL)", questionId).
+		Order("id ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (c *CommentRepo) DeleteComment(ctx context.Context, commentId int) error {
+	// Ответы на комментарий удаляет ON DELETE CASCADE по parent_id
+	result := c.db.WithContext(ctx).Where(visibleAnswers).Delete(&entity.Comment{}, commentId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound("comment %d not found", commentId)
+	}
+	return nil
+}
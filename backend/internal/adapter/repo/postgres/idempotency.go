@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repo.IdempotencyRepo = (*IdempotencyRepo)(nil)
+
+type IdempotencyRepo struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepo(db *gorm.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		db: db,
+	}
+}
+
+func (i *IdempotencyRepo) ReserveKey(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	reserved := *record
+	reserved.StatusCode = 0
+	reserved.ContentType = ""
+	reserved.Body = nil
+
+	// Вставка и замена истекшего ключа - один оператор, поэтому из параллельных запросов
+	// с одним ключом ключ резервирует только один
+	result := i.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "idempotency_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "status_code", "content_type", "body", "created_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "idempotency_keys.expires_at <= excluded.created_at"},
+		}},
+	}).Create(&reserved)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var stored entity.IdempotencyRecord
+	if err := i.db.WithContext(ctx).
+		First(&stored, "user_id = ? AND idempotency_key = ?", record.UserId, record.Key).Error; err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (i *IdempotencyRepo) CompleteKey(ctx context.Context, record *entity.IdempotencyRecord) error {
+	result := i.db.WithContext(ctx).Model(&entity.IdempotencyRecord{}).
+		Where("user_id = ? AND idempotency_key = ?", record.UserId, record.Key).
+		Updates(map[string]any{
+			"status_code":  record.StatusCode,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound("idempotency key %q not found", record.Key)
+	}
+	return nil
+}
+
+func (i *IdempotencyRepo) ReleaseKey(ctx context.Context, userId string, key string) error {
+	return i.db.WithContext(ctx).
+		Where("user_id = ? AND idempotency_key = ? AND status_code = 0", userId, key).
+		Delete(&entity.IdempotencyRecord{}).Error
+}
+
+func (i *IdempotencyRepo) PurgeKeys(ctx context.Context, before time.Time) (int, error) {
+	result := i.db.WithContext(ctx).Where("expires_at <= ?", before).Delete(&entity.IdempotencyRecord{})
+	return int(result.RowsAffected), result.Error
+}
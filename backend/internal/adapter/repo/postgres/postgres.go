@@ -26,10 +26,14 @@ func NewGormDB(pgConnStr string) (*gorm.DB, error) {
 	}
 
 	// Затем создаем GORM подключение
-	gormDB, err := gorm.Open(postgres.Open(pgConnStr), &gorm.Config{})
+	// TranslateError приводит ошибки ограничений postgres к gorm.ErrDuplicatedKey/ErrForeignKeyViolated
+	gormDB, err := gorm.Open(postgres.Open(pgConnStr), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if err := gormDB.Use(TracingPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
 
 	return gormDB, nil
 }
@@ -0,0 +1,83 @@
+package postgres_test
+
+import (
+	"HiTalent_TestTask/backend/internal/adapter/repo/postgres"
+	"HiTalent_TestTask/backend/internal/adapter/repo/repotest"
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// TestRepoContract запускается только при заданной TEST_POSTGRES_DSN.
+// Таблицы очищаются перед каждым тестом, поэтому используйте отдельную БД.
+func TestRepoContract(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := postgres.NewGormDB(dsn)
+	require.NoError(t, err)
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		require.NoError(t, db.Exec("TRUNCATE questions, tags, users, idempotency_keys RESTART IDENTITY CASCADE").Error)
+		return repotest.Repos{
+			Questions:   postgres.NewQuestionRepo(db),
+			Answers:     postgres.NewAnswerRepo(db),
+			Search:      postgres.NewSearchRepo(db),
+			Trash:       postgres.NewTrashRepo(db),
+			Tags:        postgres.NewTagRepo(db),
+			Comments:    postgres.NewCommentRepo(db),
+			Users:       postgres.NewUserRepo(db),
+			Reputation:  postgres.NewReputationRepo(db),
+			Idempotency: postgres.NewIdempotencyRepo(db),
+		}
+	})
+}
+
+// TestTracingPlugin проверяет спаны GORM без подключения к БД: DryRun только строит SQL
+func TestTracingPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := gorm.Open(gormpostgres.New(gormpostgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(postgres.TracingPlugin{}))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	var question entity.Question
+	require.NoError(t, db.WithContext(ctx).Where("id = ?", 1).Find(&question).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "gorm.query", query.Name())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range query.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "postgresql", attributes["db.system.name"].AsString())
+	assert.Equal(t, "questions", attributes["db.collection.name"].AsString())
+	// Значения параметров в спан не попадают
+	assert.Contains(t, attributes["db.query.text"].AsString(), "id = $1")
+}
@@ -2,11 +2,15 @@ package postgres
 
 import (
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
 	"context"
 	"errors"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ repo.QuestionRepo = (*QuestionRepo)(nil)
@@ -21,39 +25,236 @@ func NewQuestionRepo(db *gorm.DB) *QuestionRepo {
 	}
 }
 
-func (q *QuestionRepo) GetQuestionList(ctx context.Context) (*[]entity.Question, error) {
+func (q *QuestionRepo) GetQuestionList(ctx context.Context, filter repo.QuestionListFilter) (*[]entity.Question, error) {
 	var questions []entity.Question
-	if err := q.db.WithContext(ctx).Find(&questions).Error; err != nil {
+	query := q.db.WithContext(ctx).
+		Preload("Tags", orderTags).
+		Where("id > ?", filter.AfterId).
+		Order("id ASC")
+	if len(filter.TagIds) > 0 {
+		if filter.TagMode == entity.TagModeAny {
+			query = query.Where("id IN (SELECT question_id FROM question_tags WHERE tag_id IN ?)", filter.TagIds)
+		} else {
+			query = query.Where("id IN (SELECT question_id FROM question_tags WHERE tag_id IN ? "+
+				"GROUP BY question_id HAVING COUNT(*) = ?)", filter.TagIds, len(filter.TagIds))
+		}
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&questions).Error; err != nil {
 		return nil, err
 	}
 	return &questions, nil
 }
 
 func (q *QuestionRepo) CreateQuestion(ctx context.Context, question *entity.Question) error {
-	if err := q.db.WithContext(ctx).Create(question).Error; err != nil {
+	// Теги уже существуют: создаются только связи в question_tags
+	if err := q.db.WithContext(ctx).Omit("Tags.*").Create(question).Error; err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return errs.NotFound("tag not found")
+		}
 		return err
 	}
 	return nil
 }
 
+func orderTags(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name ASC")
+}
+
 func (q *QuestionRepo) GetQuestion(ctx context.Context, questionId int) (*entity.Question, error) {
 	var question entity.Question
-	if err := q.db.WithContext(ctx).Preload("Answers").First(&question, questionId).Error; err != nil {
+	err := q.db.WithContext(ctx).
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("answers.id ASC")
+		}).
+		Preload("Tags", orderTags).
+		First(&question, questionId).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("question not found")
+			return nil, errs.NotFound("question %d not found", questionId)
 		}
 		return nil, err
 	}
 	return &question, nil
 }
 
-func (q *QuestionRepo) DeleteQuestion(ctx context.Context, questionId int) error {
-	result := q.db.WithContext(ctx).Delete(&entity.Question{}, questionId)
+func (q *QuestionRepo) GetQuestionVersion(ctx context.Context, questionId int) (entity.QuestionVersion, error) {
+	var version entity.QuestionVersion
+	result := q.db.WithContext(ctx).Model(&entity.Question{}).
+		Select("version", "modified_at").
+		Where("id = ?", questionId).
+		Limit(1).
+		Scan(&version)
+	if result.Error != nil {
+		return entity.QuestionVersion{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.QuestionVersion{}, errs.NotFound("question %d not found", questionId)
+	}
+	return version, nil
+}
+
+// whereVersion добавляет к изменению вопроса проверку версии; 0 - любая версия
+func whereVersion(query *gorm.DB, version int) *gorm.DB {
+	if version == 0 {
+		return query
+	}
+	return query.Where("version = ?", version)
+}
+
+// missingOrChanged объясняет, почему изменение с проверкой версии не затронуло вопрос:
+// вопроса нет или его версия уже другая
+func (q *QuestionRepo) missingOrChanged(ctx context.Context, query *gorm.DB, questionId int, version int) error {
+	var current int
+	result := query.WithContext(ctx).Model(&entity.Question{}).Select("version").
+		Where("id = ?", questionId).Limit(1).Scan(&current)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 || version == 0 {
+		return errs.NotFound("question %d not found", questionId)
+	}
+	return errs.PreconditionFailed("question %d has version %d, expected %d", questionId, current, version)
+}
+
+func (q *QuestionRepo) UpdateQuestion(ctx context.Context, question *entity.Question) error {
+	question.UpdatedAt = time.Now()
+	// Версию поднимает триггер, сравнение с ожидаемой - в том же UPDATE
+	result := whereVersion(q.db.WithContext(ctx).Model(question), question.Version).
+		Select("text", "updated_at").Updates(question)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("question not found")
+		return q.missingOrChanged(ctx, q.db, question.Id, question.Version)
 	}
 	return nil
 }
+
+func (q *QuestionRepo) SetAcceptedAnswer(ctx context.Context, questionId int, answerId *int, version int) (*int, error) {
+	var previous *int
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем строку вопроса, чтобы параллельный выбор ответа прочитал уже новую отметку,
+		// а версия не изменилась между проверкой и изменением: триггеры тоже ждут блокировку
+		var question entity.Question
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "accepted_answer_id", "version").First(&question, questionId).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if answerId != nil {
+				return errs.NotFound("answer %d not found in question %d", *answerId, questionId)
+			}
+			return errs.NotFound("question %d not found", questionId)
+		}
+		if version != 0 && question.Version != version {
+			return errs.PreconditionFailed("question %d has version %d, expected %d", questionId, question.Version, version)
+		}
+
+		query := tx.Model(&entity.Question{}).Where("id = ?", questionId)
+		var acceptedAt *time.Time
+		if answerId != nil {
+			// Проверка принадлежности в том же запросе: ответ могли удалить или он из другого вопроса
+			query = query.Where("EXISTS (SELECT 1 FROM answers "+
+				"WHERE answers.id = ? AND answers.question_id = ? AND answers.deleted_at IS NULL)",
+				*answerId, questionId)
+			now := time.Now()
+			acceptedAt = &now
+		}
+		result := query.UpdateColumns(map[string]any{"accepted_answer_id": answerId, "accepted_at": acceptedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 && answerId != nil {
+			return errs.NotFound("answer %d not found in question %d", *answerId, questionId)
+		}
+		previous = question.AcceptedAnswerId
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (q *QuestionRepo) DeleteQuestion(ctx context.Context, questionId int, version int) ([]int, error) {
+	// Общее время удаления отличает ответы, ушедшие в корзину вместе с вопросом,
+	// от удаленных раньше по отдельности
+	deletedAt := time.Now()
+	answerIds := []int{}
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := whereVersion(tx.Model(&entity.Question{}).Where("id = ?", questionId), version).
+			UpdateColumn("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return q.missingOrChanged(ctx, tx, questionId, version)
+		}
+		return tx.Raw("UPDATE answers SET deleted_at = ? WHERE question_id = ? AND deleted_at IS NULL RETURNING id",
+			deletedAt, questionId).Scan(&answerIds).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Ints(answerIds)
+	return answerIds, nil
+}
+
+func (q *QuestionRepo) RestoreQuestion(ctx context.Context, questionId int) error {
+	return q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Ответы восстанавливаются первыми, пока у вопроса еще есть время удаления
+		err := tx.Unscoped().Model(&entity.Answer{}).
+			Where("question_id = ? AND deleted_at = (SELECT deleted_at FROM questions WHERE id = ?)", questionId, questionId).
+			UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&entity.Question{}).
+			Where("id = ? AND deleted_at IS NOT NULL", questionId).
+			UpdateColumn("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errs.NotFound("question %d not found in trash", questionId)
+		}
+		return nil
+	})
+}
+
+func (q *QuestionRepo) PurgeQuestion(ctx context.Context, questionId int, version int) ([]int, error) {
+	answerIds := []int{}
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокировка строки вопроса не дает добавить ответ, пока собираются id удаляемых ответов
+		var question entity.Question
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&question, questionId).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.NotFound("question %d not found", questionId)
+			}
+			return err
+		}
+		err = tx.Unscoped().Model(&entity.Answer{}).Where("question_id = ?", questionId).Order("id").Pluck("id", &answerIds).Error
+		if err != nil {
+			return err
+		}
+
+		// Ответы и голоса удаляются каскадно внешними ключами
+		result := whereVersion(tx.Unscoped(), version).Delete(&entity.Question{}, questionId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return q.missingOrChanged(ctx, tx.Unscoped(), questionId, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return answerIds, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ repo.ReputationRepo = (*ReputationRepo)(nil)
+
+type ReputationRepo struct {
+	db *gorm.DB
+}
+
+func NewReputationRepo(db *gorm.DB) *ReputationRepo {
+	return &ReputationRepo{
+		db: db,
+	}
+}
+
+func (r *ReputationRepo) AddReputation(ctx context.Context, entries []entity.ReputationEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+		totals := make(map[string]int)
+		for _, entry := range entries {
+			totals[entry.UserId] += entry.Points
+		}
+		for userId, points := range totals {
+			err := tx.Model(&entity.User{}).Where("id = ?", userId).
+				UpdateColumn("reputation", gorm.Expr("reputation + ?", points)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ReputationRepo) ReplaceReputation(ctx context.Context, entries []entity.ReputationEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Начисления, пришедшие во время пересчета, ждут его окончания, а не теряются в удаленном журнале
+		if err := tx.Exec("LOCK TABLE reputation_ledger IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM reputation_ledger").Error; err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			if err := tx.CreateInBatches(&entries, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("UPDATE users SET reputation = COALESCE(" +
+			"(SELECT SUM(points) FROM reputation_ledger WHERE reputation_ledger.user_id = users.id), 0)").Error
+	})
+}
+
+func (r *ReputationRepo) GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]entity.LeaderboardEntry, error) {
+	leaders := []entity.LeaderboardEntry{}
+	query := r.db.WithContext(ctx).
+		Table("reputation_ledger").
+		Select("reputation_ledger.user_id, users.display_name, SUM(reputation_ledger.points) AS reputation").
+		Joins("JOIN users ON users.id = reputation_ledger.user_id").
+		Where("reputation_ledger.created_at >= ?", since).
+		Group("reputation_ledger.user_id, users.display_name").
+		Having("SUM(reputation_ledger.points) > 0").
+		Order("reputation DESC, reputation_ledger.user_id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Scan(&leaders).Error; err != nil {
+		return nil, err
+	}
+	return leaders, nil
+}
+
+// whereAnswers добавляет к запросу фильтр по id ответов; пустой answerIds - все ответы
+func whereAnswers(query *gorm.DB, column string, answerIds []int) *gorm.DB {
+	if len(answerIds) == 0 {
+		return query
+	}
+	return query.Where(column+" IN ?", answerIds)
+}
+
+func (r *ReputationRepo) GetAnswerReputation(ctx context.Context, answerIds []int) ([]entity.ReputationEntry, error) {
+	entries := []entity.ReputationEntry{}
+	query := whereAnswers(r.db.WithContext(ctx).Table("reputation_ledger"), "answer_id", answerIds).
+		Select("user_id, reason, answer_id, SUM(points) AS points").
+		Group("user_id, reason, answer_id").
+		Having("SUM(points) <> 0").
+		Order("answer_id, user_id, reason")
+	if err := query.Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ReputationRepo) GetVotes(ctx context.Context, answerIds []int) ([]entity.AnswerVoted, error) {
+	votes := []entity.AnswerVoted{}
+	query := whereAnswers(r.db.WithContext(ctx).Table("answer_votes"), "answer_votes.answer_id", answerIds).
+		Select("answer_votes.answer_id, answers.question_id, answers.user_id AS author_id, " +
+			"answer_votes.user_id AS voter_id, answer_votes.value, answer_votes.updated_at AS at").
+		Joins("JOIN answers ON answers.id = answer_votes.answer_id").
+		Where("answers.deleted_at IS NULL").
+		Order("answer_votes.answer_id, answer_votes.user_id")
+	if err := query.Scan(&votes).Error; err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (r *ReputationRepo) GetAcceptedAnswers(ctx context.Context, answerIds []int) ([]entity.AnswerAccepted, error) {
+	accepted := []entity.AnswerAccepted{}
+	query := whereAnswers(r.db.WithContext(ctx).Table("questions"), "answers.id", answerIds).
+		Select("questions.id AS question_id, answers.id AS answer_id, answers.user_id AS answer_author_id, " +
+			"COALESCE(questions.author_id, '') AS question_author_id, " +
+			"COALESCE(questions.accepted_at, questions.updated_at) AS at").
+		Joins("JOIN answers ON answers.id = questions.accepted_answer_id").
+		Where("questions.deleted_at IS NULL AND answers.deleted_at IS NULL").
+		Order("questions.id")
+	if err := query.Scan(&accepted).Error; err != nil {
+		return nil, err
+	}
+	return accepted, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ repo.SearchRepo = (*SearchRepo)(nil)
+
+type SearchRepo struct {
+	db *gorm.DB
+}
+
+func NewSearchRepo(db *gorm.DB) *SearchRepo {
+	return &SearchRepo{
+		db: db,
+	}
+}
+
+// searchQuery ранжирует вопросы по совпадению в собственном тексте и лучшему совпадению среди ответов.
+// Векторы построены сразу в нескольких конфигурациях (см. миграцию 00003), поэтому
+// язык запроса можно выбирать без переиндексации.
+const searchQuery = `
+WITH query AS (
+	SELECT websearch_to_tsquery(CAST(@language AS regconfig), @text) AS q
+),
+answer_ranks AS (
+	SELECT answers.question_id, MAX(ts_rank(answers.search_vector, query.q)) AS rank
+	FROM answers, query
+	WHERE answers.search_vector @@ query.q AND answers.deleted_at IS NULL
+	GROUP BY answers.question_id
+)
+SELECT questions.id, questions.text, questions.created_at, questions.updated_at,
+	ts_rank(questions.search_vector, query.q) + COALESCE(answer_ranks.rank, 0) * @answer_weight AS rank
+FROM questions
+CROSS JOIN query
+LEFT JOIN answer_ranks ON answer_ranks.question_id = questions.id
+WHERE questions.deleted_at IS NULL
+	AND (questions.search_vector @@ query.q OR answer_ranks.question_id IS NOT NULL)
+ORDER BY rank DESC, questions.id ASC
+LIMIT @limit`
+
+// searchRow - строка результата поиска
+type searchRow struct {
+	Id        int
+	Text      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Rank      float64
+}
+
+func (s *SearchRepo) Search(ctx context.Context, query repo.SearchQuery) ([]entity.SearchHit, error) {
+	var rows []searchRow
+	err := s.db.WithContext(ctx).Raw(searchQuery, map[string]any{
+		"language":      query.Language,
+		"text":          query.Text,
+		"answer_weight": repo.AnswerRankWeight,
+		"limit":         query.Limit,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]entity.SearchHit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, entity.SearchHit{
+			Question: entity.Question{
+				Id:        row.Id,
+				Text:      row.Text,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+			},
+			Rank: row.Rank,
+		})
+	}
+	return hits, nil
+}
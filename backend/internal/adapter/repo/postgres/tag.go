@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var _ repo.TagRepo = (*TagRepo)(nil)
+
+// tagCountQuery считает вопросы тега без учета корзины
+const tagCountQuery = "(SELECT COUNT(*) FROM question_tags " +
+	"JOIN questions ON questions.id = question_tags.question_id " +
+	"WHERE question_tags.tag_id = tags.id AND questions.deleted_at IS NULL) AS question_count"
+
+type TagRepo struct {
+	db *gorm.DB
+}
+
+func NewTagRepo(db *gorm.DB) *TagRepo {
+	return &TagRepo{
+		db: db,
+	}
+}
+
+// tagRow - строка тега вместе с подсчитанным числом вопросов
+type tagRow struct {
+	entity.Tag
+	QuestionCount int `gorm:"column:question_count"`
+}
+
+func (t *TagRepo) GetTags(ctx context.Context) ([]entity.TagInfo, error) {
+	var rows []tagRow
+	err := t.db.WithContext(ctx).Model(&entity.Tag{}).
+		Select("tags.*, " + tagCountQuery).
+		Order("name ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var synonyms []entity.TagSynonym
+	if err := t.db.WithContext(ctx).Order("name ASC").Find(&synonyms).Error; err != nil {
+		return nil, err
+	}
+	byTag := make(map[int][]string)
+	for _, synonym := range synonyms {
+		byTag[synonym.TagId] = append(byTag[synonym.TagId], synonym.Name)
+	}
+
+	tags := make([]entity.TagInfo, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, tagInfo(row, byTag[row.Id]))
+	}
+	return tags, nil
+}
+
+func (t *TagRepo) GetTag(ctx context.Context, tagId int) (*entity.TagInfo, error) {
+	var row tagRow
+	err := t.db.WithContext(ctx).Model(&entity.Tag{}).
+		Select("tags.*, "+tagCountQuery).
+		Where("id = ?", tagId).
+		Take(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("tag %d not found", tagId)
+		}
+		return nil, err
+	}
+
+	var synonyms []string
+	err = t.db.WithContext(ctx).Model(&entity.TagSynonym{}).
+		Where("tag_id = ?", tagId).
+		Order("name ASC").
+		Pluck("name", &synonyms).Error
+	if err != nil {
+		return nil, err
+	}
+	info := tagInfo(row, synonyms)
+	return &info, nil
+}
+
+func tagInfo(row tagRow, synonyms []string) entity.TagInfo {
+	if synonyms == nil {
+		synonyms = []string{}
+	}
+	return entity.TagInfo{Tag: row.Tag, QuestionCount: row.QuestionCount, Synonyms: synonyms}
+}
+
+func (t *TagRepo) CreateTag(ctx context.Context, tag *entity.Tag) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkTagName(tx, tag.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(tag).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errs.Conflict("tag %q already exists", tag.Name)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (t *TagRepo) RenameTag(ctx context.Context, tagId int, name string) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkTagName(tx, name, tagId); err != nil {
+			return err
+		}
+		err := tx.Where("name = ? AND tag_id = ?", name, tagId).Delete(&entity.TagSynonym{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.Tag{}).Where("id = ?", tagId).UpdateColumn("name", name)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return errs.Conflict("tag %q already exists", name)
+			}
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errs.NotFound("tag %d not found", tagId)
+		}
+		return nil
+	})
+}
+
+func (t *TagRepo) AddTagSynonym(ctx context.Context, tagId int, name string) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkTagName(tx, name, 0); err != nil {
+			return err
+		}
+		err := tx.Create(&entity.TagSynonym{Name: name, TagId: tagId}).Error
+		switch {
+		case errors.Is(err, gorm.ErrForeignKeyViolated):
+			return errs.NotFound("tag %d not found", tagId)
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return errs.Conflict("tag %q is already a synonym", name)
+		}
+		return err
+	})
+}
+
+// checkTagName блокирует изменения имен тегов до конца транзакции и проверяет, что имя свободно.
+// Синоним тега ownerId с этим именем не считается конфликтом, так тег можно переименовать в свой синоним.
+func checkTagName(tx *gorm.DB, name string, ownerId int) error {
+	// Уникальность имени среди тегов и синонимов вместе не выразить ограничением,
+	// поэтому изменения имен выполняются последовательно
+	if err := tx.Exec("LOCK TABLE tag_synonyms IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return err
+	}
+
+	var tags int64
+	if err := tx.Model(&entity.Tag{}).Where("name = ? AND id <> ?", name, ownerId).Count(&tags).Error; err != nil {
+		return err
+	}
+	if tags > 0 {
+		return errs.Conflict("tag %q already exists", name)
+	}
+
+	var synonyms int64
+	err := tx.Model(&entity.TagSynonym{}).Where("name = ? AND tag_id <> ?", name, ownerId).Count(&synonyms).Error
+	if err != nil {
+		return err
+	}
+	if synonyms > 0 {
+		return errs.Conflict("tag %q is already a synonym", name)
+	}
+	return nil
+}
+
+func (t *TagRepo) ResolveTags(ctx context.Context, names []string) (map[string]entity.Tag, error) {
+	resolved := make(map[string]entity.Tag, len(names))
+	if len(names) == 0 {
+		return resolved, nil
+	}
+
+	var tags []entity.Tag
+	if err := t.db.WithContext(ctx).Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		resolved[tag.Name] = tag
+	}
+
+	var synonyms []struct {
+		Synonym string
+		entity.Tag
+	}
+	err := t.db.WithContext(ctx).Table("tag_synonyms").
+		Select("tag_synonyms.name AS synonym, tags.*").
+		Joins("JOIN tags ON tags.id = tag_synonyms.tag_id").
+		Where("tag_synonyms.name IN ?", names).
+		Scan(&synonyms).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, synonym := range synonyms {
+		resolved[synonym.Synonym] = synonym.Tag
+	}
+	return resolved, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var _ repo.TrashRepo = (*TrashRepo)(nil)
+
+type TrashRepo struct {
+	db *gorm.DB
+}
+
+func NewTrashRepo(db *gorm.DB) *TrashRepo {
+	return &TrashRepo{
+		db: db,
+	}
+}
+
+func (t *TrashRepo) GetTrash(ctx context.Context, limit int) (*entity.Trash, error) {
+	trash := &entity.Trash{Questions: []entity.Question{}, Answers: []entity.Answer{}}
+	err := t.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id DESC").
+		Limit(limit).
+		Find(&trash.Questions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Ответы удаленных вопросов восстанавливаются только вместе с вопросом
+	err = t.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where("question_id IN (SELECT id FROM questions WHERE deleted_at IS NULL)").
+		Order("deleted_at DESC, id DESC").
+		Limit(limit).
+		Find(&trash.Answers).Error
+	if err != nil {
+		return nil, err
+	}
+	return trash, nil
+}
+
+func (t *TrashRepo) PurgeTrash(ctx context.Context, before time.Time) (entity.PurgeResult, error) {
+	var result entity.PurgeResult
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Сначала вопросы: их ответы удаляются каскадно и не попадают в счетчик ответов
+		questions := tx.Unscoped().Where("deleted_at < ?", before).Delete(&entity.Question{})
+		if questions.Error != nil {
+			return questions.Error
+		}
+		answers := tx.Unscoped().Where("deleted_at < ?", before).Delete(&entity.Answer{})
+		if answers.Error != nil {
+			return answers.Error
+		}
+		result.Questions = int(questions.RowsAffected)
+		result.Answers = int(answers.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return entity.PurgeResult{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ repo.UserRepo = (*UserRepo)(nil)
+
+type UserRepo struct {
+	db *gorm.DB
+}
+
+func NewUserRepo(db *gorm.DB) *UserRepo {
+	return &UserRepo{
+		db: db,
+	}
+}
+
+func (u *UserRepo) EnsureUser(ctx context.Context, user *entity.User) error {
+	// Параллельные первые запросы одного пользователя не должны падать на уникальности
+	err := u.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(user).Error
+	if err != nil {
+		return err
+	}
+	return u.db.WithContext(ctx).First(user, "id = ?", user.Id).Error
+}
+
+func (u *UserRepo) GetUser(ctx context.Context, userId string) (*entity.User, error) {
+	var user entity.User
+	if err := u.db.WithContext(ctx).First(&user, "id = ?", userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("user %q not found", userId)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *UserRepo) UpdateUser(ctx context.Context, user *entity.User) error {
+	result := u.db.WithContext(ctx).Model(user).Select("display_name", "bio").Updates(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound("user %q not found", user.Id)
+	}
+	return nil
+}
+
+func (u *UserRepo) GetUserAnswers(ctx context.Context, userId string, filter repo.UserListFilter) ([]entity.Answer, error) {
+	if _, err := u.GetUser(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	answers := []entity.Answer{}
+	query := u.db.WithContext(ctx).
+		Where("user_id = ? AND id > ?", userId, filter.AfterId).
+		Order("id ASC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&answers).Error; err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+func (u *UserRepo) GetUserQuestions(ctx context.Context, userId string, filter repo.UserListFilter) ([]entity.Question, error) {
+	if _, err := u.GetUser(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	questions := []entity.Question{}
+	query := u.db.WithContext(ctx).
+		Preload("Tags", orderTags).
+		Where("author_id = ? AND id > ?", userId, filter.AfterId).
+		Order("id ASC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
@@ -0,0 +1,1375 @@
+// Package repotest содержит общий набор контрактных тестов для реализаций
+// repo.QuestionRepo, repo.AnswerRepo, repo.SearchRepo, repo.TagRepo, repo.CommentRepo, repo.UserRepo, repo.ReputationRepo
+// и repo.IdempotencyRepo. Любой адаптер подключает его из своего
+// _test.go, чтобы поведение memory и postgres не расходилось.
+package repotest
+
+import (
+	"HiTalent_TestTask/backend/internal/cases"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Repos - набор репозиториев одной реализации, работающих с общим хранилищем
+type Repos struct {
+	Questions   repo.QuestionRepo
+	Answers     repo.AnswerRepo
+	Search      repo.SearchRepo // необязательный, без него тесты поиска пропускаются
+	Trash       repo.TrashRepo
+	Tags        repo.TagRepo
+	Comments    repo.CommentRepo
+	Users       repo.UserRepo
+	Reputation  repo.ReputationRepo
+	Idempotency repo.IdempotencyRepo
+}
+
+// Factory возвращает репозитории с пустым хранилищем для отдельного теста
+type Factory func(t *testing.T) Repos
+
+// Run запускает весь контрактный набор против реализации
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("QuestionListOrdering", func(t *testing.T) { testQuestionListOrdering(t, newRepos(t)) })
+	t.Run("QuestionListPaging", func(t *testing.T) { testQuestionListPaging(t, newRepos(t)) })
+	t.Run("QuestionNotFound", func(t *testing.T) { testQuestionNotFound(t, newRepos(t)) })
+	t.Run("AnswerNotFound", func(t *testing.T) { testAnswerNotFound(t, newRepos(t)) })
+	t.Run("AnswerForMissingQuestion", func(t *testing.T) { testAnswerForMissingQuestion(t, newRepos(t)) })
+	t.Run("AnswersOrdering", func(t *testing.T) { testAnswersOrdering(t, newRepos(t)) })
+	t.Run("DeleteQuestionCascade", func(t *testing.T) { testDeleteQuestionCascade(t, newRepos(t)) })
+	t.Run("Timestamps", func(t *testing.T) { testTimestamps(t, newRepos(t)) })
+	t.Run("ConcurrentCreate", func(t *testing.T) { testConcurrentCreate(t, newRepos(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepos(t)) })
+	t.Run("Votes", func(t *testing.T) { testVotes(t, newRepos(t)) })
+	t.Run("ConcurrentVotes", func(t *testing.T) { testConcurrentVotes(t, newRepos(t)) })
+	t.Run("AcceptedAnswer", func(t *testing.T) { testAcceptedAnswer(t, newRepos(t)) })
+	t.Run("ConcurrentAccept", func(t *testing.T) { testConcurrentAccept(t, newRepos(t)) })
+	t.Run("SoftDeleteQuestion", func(t *testing.T) { testSoftDeleteQuestion(t, newRepos(t)) })
+	t.Run("RestoreAnswer", func(t *testing.T) { testRestoreAnswer(t, newRepos(t)) })
+	t.Run("PurgeQuestion", func(t *testing.T) { testPurgeQuestion(t, newRepos(t)) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newRepos(t)) })
+	t.Run("Revisions", func(t *testing.T) { testRevisions(t, newRepos(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newRepos(t)) })
+	t.Run("QuestionListByTags", func(t *testing.T) { testQuestionListByTags(t, newRepos(t)) })
+	t.Run("Comments", func(t *testing.T) { testComments(t, newRepos(t)) })
+	t.Run("CommentsCascade", func(t *testing.T) { testCommentsCascade(t, newRepos(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepos(t)) })
+	t.Run("ReputationLedger", func(t *testing.T) { testReputationLedger(t, newRepos(t)) })
+	t.Run("ReputationSources", func(t *testing.T) { testReputationSources(t, newRepos(t)) })
+	t.Run("ReputationRebuildAfterDelete", func(t *testing.T) { testReputationRebuildAfterDelete(t, newRepos(t)) })
+	t.Run("IdempotencyKeys", func(t *testing.T) { testIdempotencyKeys(t, newRepos(t)) })
+	t.Run("QuestionVersions", func(t *testing.T) { testQuestionVersions(t, newRepos(t)) })
+}
+
+// CreateQuestion создает вопрос и проваливает тест при ошибке
+func CreateQuestion(t *testing.T, r Repos, text string) *entity.Question {
+	t.Helper()
+	question := &entity.Question{Text: text}
+	require.NoError(t, r.Questions.CreateQuestion(context.Background(), question))
+	require.NotZero(t, question.Id)
+	return question
+}
+
+// CreateUser заводит пользователя, без которого в postgres нельзя сохранить его ответы и комментарии
+func CreateUser(t *testing.T, r Repos, userId string) *entity.User {
+	t.Helper()
+	user := &entity.User{Id: userId, DisplayName: userId}
+	require.NoError(t, r.Users.EnsureUser(context.Background(), user))
+	return user
+}
+
+// CreateTag создает тег и проваливает тест при ошибке
+func CreateTag(t *testing.T, r Repos, name string) entity.Tag {
+	t.Helper()
+	tag := &entity.Tag{Name: name}
+	require.NoError(t, r.Tags.CreateTag(context.Background(), tag))
+	require.NotZero(t, tag.Id)
+	return *tag
+}
+
+// CreateComment создает комментарий к ответу и проваливает тест при ошибке
+func CreateComment(t *testing.T, r Repos, answerId int, parent *entity.Comment, text string) *entity.Comment {
+	t.Helper()
+	CreateUser(t, r, "user-1")
+	comment := &entity.Comment{AnswerId: answerId, UserId: "user-1", Text: text}
+	if parent != nil {
+		comment.ParentId = &parent.Id
+		comment.Depth = parent.Depth + 1
+	}
+	require.NoError(t, r.Comments.CreateComment(context.Background(), comment))
+	require.NotZero(t, comment.Id)
+	return comment
+}
+
+// CreateAnswer создает ответ и проваливает тест при ошибке
+func CreateAnswer(t *testing.T, r Repos, questionId int, userId string, text string) *entity.Answer {
+	t.Helper()
+	CreateUser(t, r, userId)
+	answer := &entity.Answer{QuestionId: questionId, UserId: userId, Text: text}
+	require.NoError(t, r.Answers.CreateAnswer(context.Background(), answer, 0))
+	require.NotZero(t, answer.ID)
+	return answer
+}
+
+func questionIds(questions []entity.Question) []int {
+	ids := make([]int, 0, len(questions))
+	for _, question := range questions {
+		ids = append(ids, question.Id)
+	}
+	return ids
+}
+
+func testQuestionListOrdering(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	var want []int
+	for i := 0; i < 5; i++ {
+		want = append(want, CreateQuestion(t, r, "question").Id)
+	}
+
+	questions, err := r.Questions.GetQuestionList(ctx, repo.QuestionListFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, want, questionIds(*questions))
+}
+
+func testQuestionListPaging(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	var ids []int
+	for i := 0; i < 5; i++ {
+		ids = append(ids, CreateQuestion(t, r, "question").Id)
+	}
+
+	questions, err := r.Questions.GetQuestionList(ctx, repo.QuestionListFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, ids[:2], questionIds(*questions))
+
+	questions, err = r.Questions.GetQuestionList(ctx, repo.QuestionListFilter{AfterId: ids[1], Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, ids[2:4], questionIds(*questions))
+
+	questions, err = r.Questions.GetQuestionList(ctx, repo.QuestionListFilter{AfterId: ids[4], Limit: 2})
+	require.NoError(t, err)
+	assert.Empty(t, *questions)
+}
+
+func testQuestionNotFound(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	_, err := r.Questions.GetQuestion(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	err = r.Questions.UpdateQuestion(ctx, &entity.Question{Id: 999, Text: "text"})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = r.Questions.DeleteQuestion(ctx, 999, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testAnswerNotFound(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	_, err := r.Answers.GetAnswer(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	err = r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: 999, Text: "text"}, "editor")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = r.Answers.DeleteAnswer(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testAnswerForMissingQuestion(t *testing.T, r Repos) {
+	CreateUser(t, r, "user-1")
+	err := r.Answers.CreateAnswer(context.Background(), &entity.Answer{
+		QuestionId: 999,
+		UserId:     "user-1",
+		Text:       "answer",
+	}, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testAnswersOrdering(t *testing.T, r Repos) {
+	question := CreateQuestion(t, r, "question")
+
+	var want []int
+	for i := 0; i < 5; i++ {
+		want = append(want, CreateAnswer(t, r, question.Id, "user-1", "answer").ID)
+	}
+
+	loaded, err := r.Questions.GetQuestion(context.Background(), question.Id)
+	require.NoError(t, err)
+
+	var got []int
+	for _, answer := range loaded.Answers {
+		got = append(got, answer.ID)
+	}
+	assert.Equal(t, want, got)
+}
+
+func testDeleteQuestionCascade(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	other := CreateQuestion(t, r, "other question")
+	answer1 := CreateAnswer(t, r, question.Id, "user-1", "answer 1")
+	answer2 := CreateAnswer(t, r, question.Id, "user-2", "answer 2")
+	kept := CreateAnswer(t, r, other.Id, "user-1", "answer to other")
+
+	assert.Equal(t, []int{answer1.ID, answer2.ID}, deleteQuestion(t, r, question.Id, 0))
+
+	_, err := r.Questions.GetQuestion(ctx, question.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	for _, answerId := range []int{answer1.ID, answer2.ID} {
+		_, err := r.Answers.GetAnswer(ctx, answerId)
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	}
+
+	_, err = r.Answers.GetAnswer(ctx, kept.ID)
+	assert.NoError(t, err)
+}
+
+func testTimestamps(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "user-1", "answer")
+
+	loadedQuestion, err := r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.False(t, loadedQuestion.CreatedAt.IsZero())
+	assert.False(t, loadedQuestion.UpdatedAt.Before(loadedQuestion.CreatedAt))
+
+	loadedAnswer, err := r.Answers.GetAnswer(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.False(t, loadedAnswer.CreatedAt.IsZero())
+	assert.False(t, loadedAnswer.UpdatedAt.Before(loadedAnswer.CreatedAt))
+
+	time.Sleep(10 * time.Millisecond)
+
+	require.NoError(t, r.Questions.UpdateQuestion(ctx, &entity.Question{Id: question.Id, Text: "edited"}))
+	updatedQuestion, err := r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updatedQuestion.Text)
+	assert.True(t, updatedQuestion.UpdatedAt.After(loadedQuestion.UpdatedAt))
+	assert.True(t, updatedQuestion.CreatedAt.Equal(loadedQuestion.CreatedAt))
+
+	require.NoError(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "edited"}, "editor"))
+	updatedAnswer, err := r.Answers.GetAnswer(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", updatedAnswer.Text)
+	assert.Equal(t, "user-1", updatedAnswer.UserId)
+	assert.True(t, updatedAnswer.UpdatedAt.After(loadedAnswer.UpdatedAt))
+	assert.True(t, updatedAnswer.CreatedAt.Equal(loadedAnswer.CreatedAt))
+}
+
+func testConcurrentCreate(t *testing.T, r Repos) {
+	CreateUser(t, r, "user-1")
+	ctx := context.Background()
+	question := CreateQuestion(t, r, "question")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	questionIdsCh := make(chan int, workers)
+	answerIdsCh := make(chan int, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newQuestion := &entity.Question{Text: "concurrent"}
+			if assert.NoError(t, r.Questions.CreateQuestion(ctx, newQuestion)) {
+				questionIdsCh <- newQuestion.Id
+			}
+			answer := &entity.Answer{QuestionId: question.Id, UserId: "user-1", Text: "concurrent"}
+			if assert.NoError(t, r.Answers.CreateAnswer(ctx, answer, 0)) {
+				answerIdsCh <- answer.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(questionIdsCh)
+	close(answerIdsCh)
+
+	seen := make(map[int]bool)
+	for id := range questionIdsCh {
+		assert.False(t, seen[id], "duplicate question id %d", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, workers)
+
+	seen = make(map[int]bool)
+	for id := range answerIdsCh {
+		assert.False(t, seen[id], "duplicate answer id %d", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, workers)
+
+	loaded, err := r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Answers, workers)
+}
+
+func searchIds(t *testing.T, r Repos, text string) []int {
+	t.Helper()
+	hits, err := r.Search.Search(context.Background(), repo.SearchQuery{
+		Text:     text,
+		Language: "simple",
+		Limit:    10,
+	})
+	require.NoError(t, err)
+
+	ids := make([]int, 0, len(hits))
+	for _, hit := range hits {
+		assert.Positive(t, hit.Rank)
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
+
+func testSearch(t *testing.T, r Repos) {
+	if r.Search == nil {
+		t.Skip("search repo is not provided")
+	}
+	ctx := context.Background()
+
+	byQuestion := CreateQuestion(t, r, "How to install Go compiler")
+	byAnswer := CreateQuestion(t, r, "Postgres indexes")
+	unrelated := CreateQuestion(t, r, "Kubernetes networking")
+	answer := CreateAnswer(t, r, byAnswer.Id, "user-1", "Use the Go driver")
+	CreateAnswer(t, r, unrelated.Id, "user-1", "Check the CNI plugin")
+
+	// Совпадение в тексте вопроса весит больше, чем совпадение в ответе
+	assert.Equal(t, []int{byQuestion.Id, byAnswer.Id}, searchIds(t, r, "go"))
+	assert.Empty(t, searchIds(t, r, "rust"))
+
+	require.NoError(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "Use pgx"}, "editor"))
+	assert.Equal(t, []int{byQuestion.Id}, searchIds(t, r, "go"))
+
+	deleteQuestion(t, r, byQuestion.Id, 0)
+	assert.Empty(t, searchIds(t, r, "go"))
+
+	// Восстановленный вопрос снова находится, в том числе по ответам
+	require.NoError(t, r.Questions.RestoreQuestion(ctx, byQuestion.Id))
+	assert.Equal(t, []int{byQuestion.Id}, searchIds(t, r, "go"))
+	deleteQuestion(t, r, unrelated.Id, 0)
+	assert.Empty(t, searchIds(t, r, "cni"))
+	require.NoError(t, r.Questions.RestoreQuestion(ctx, unrelated.Id))
+	assert.Equal(t, []int{unrelated.Id}, searchIds(t, r, "cni"))
+}
+
+func vote(t *testing.T, r Repos, answerId int, userId string, value int) int {
+	t.Helper()
+	previous, err := r.Answers.VoteAnswer(context.Background(), &entity.AnswerVote{
+		AnswerId: answerId,
+		UserId:   userId,
+		Value:    value,
+	})
+	require.NoError(t, err)
+	return previous
+}
+
+func deleteQuestion(t *testing.T, r Repos, questionId int, version int) []int {
+	t.Helper()
+	answerIds, err := r.Questions.DeleteQuestion(context.Background(), questionId, version)
+	require.NoError(t, err)
+	return answerIds
+}
+
+func purgeQuestion(t *testing.T, r Repos, questionId int, version int) []int {
+	t.Helper()
+	answerIds, err := r.Questions.PurgeQuestion(context.Background(), questionId, version)
+	require.NoError(t, err)
+	return answerIds
+}
+
+func deleteAnswer(t *testing.T, r Repos, answerId int) *entity.AnswerUnaccepted {
+	t.Helper()
+	unaccepted, err := r.Answers.DeleteAnswer(context.Background(), answerId)
+	require.NoError(t, err)
+	return unaccepted
+}
+
+func answerScore(t *testing.T, r Repos, answerId int) int {
+	t.Helper()
+	answer, err := r.Answers.GetAnswer(context.Background(), answerId)
+	require.NoError(t, err)
+	return answer.Score
+}
+
+func testVotes(t *testing.T, r Repos) {
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "author", "answer")
+	assert.Equal(t, 0, answerScore(t, r, answer.ID))
+
+	assert.Equal(t, 0, vote(t, r, answer.ID, "user-1", 1))
+	assert.Equal(t, 0, vote(t, r, answer.ID, "user-2", 1))
+	assert.Equal(t, 2, answerScore(t, r, answer.ID))
+
+	// Повторный голос не добавляет очков, смена голоса пересчитывает счет
+	assert.Equal(t, 1, vote(t, r, answer.ID, "user-1", 1))
+	assert.Equal(t, 2, answerScore(t, r, answer.ID))
+	assert.Equal(t, 1, vote(t, r, answer.ID, "user-1", -1))
+	assert.Equal(t, 0, answerScore(t, r, answer.ID))
+
+	// Снятие голоса
+	assert.Equal(t, 1, vote(t, r, answer.ID, "user-2", 0))
+	assert.Equal(t, -1, answerScore(t, r, answer.ID))
+	assert.Equal(t, 0, vote(t, r, answer.ID, "user-2", 0))
+	assert.Equal(t, -1, answerScore(t, r, answer.ID))
+
+	loaded, err := r.Questions.GetQuestion(context.Background(), question.Id)
+	require.NoError(t, err)
+	require.Len(t, loaded.Answers, 1)
+	assert.Equal(t, -1, loaded.Answers[0].Score)
+
+	_, err = r.Answers.VoteAnswer(context.Background(), &entity.AnswerVote{AnswerId: 999, UserId: "user-1", Value: 1})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testConcurrentVotes(t *testing.T, r Repos) {
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "author", "answer")
+
+	const voters = 10
+	const repeats = 5
+	var wg sync.WaitGroup
+	for i := 0; i < voters; i++ {
+		for j := 0; j < repeats; j++ {
+			wg.Add(1)
+			go func(userId string) {
+				defer wg.Done()
+				_, err := r.Answers.VoteAnswer(context.Background(), &entity.AnswerVote{
+					AnswerId: answer.ID,
+					UserId:   userId,
+					Value:    1,
+				})
+				assert.NoError(t, err)
+			}(fmt.Sprintf("user-%d", i))
+		}
+	}
+	wg.Wait()
+
+	// Каждый пользователь учтен ровно один раз
+	assert.Equal(t, voters, answerScore(t, r, answer.ID))
+}
+
+func acceptedAnswerId(t *testing.T, r Repos, questionId int) *int {
+	t.Helper()
+	question, err := r.Questions.GetQuestion(context.Background(), questionId)
+	require.NoError(t, err)
+	return question.AcceptedAnswerId
+}
+
+func testAcceptedAnswer(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	other := CreateQuestion(t, r, "other question")
+	first := CreateAnswer(t, r, question.Id, "user-1", "first")
+	second := CreateAnswer(t, r, question.Id, "user-2", "second")
+	foreign := CreateAnswer(t, r, other.Id, "user-3", "foreign")
+
+	assert.Nil(t, acceptedAnswerId(t, r, question.Id))
+
+	assert.Nil(t, setAcceptedAnswer(t, r, question.Id, &first.ID))
+	if accepted := acceptedAnswerId(t, r, question.Id); assert.NotNil(t, accepted) {
+		assert.Equal(t, first.ID, *accepted)
+	}
+	// Возвращается отметка до изменения, в том числе при повторном выборе того же ответа
+	if previous := setAcceptedAnswer(t, r, question.Id, &first.ID); assert.NotNil(t, previous) {
+		assert.Equal(t, first.ID, *previous)
+	}
+
+	// Ответ другого вопроса принять нельзя, текущая отметка не меняется
+	_, err := r.Questions.SetAcceptedAnswer(ctx, question.Id, &foreign.ID, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	if accepted := acceptedAnswerId(t, r, question.Id); assert.NotNil(t, accepted) {
+		assert.Equal(t, first.ID, *accepted)
+	}
+
+	if previous := setAcceptedAnswer(t, r, question.Id, nil); assert.NotNil(t, previous) {
+		assert.Equal(t, first.ID, *previous)
+	}
+	assert.Nil(t, acceptedAnswerId(t, r, question.Id))
+	assert.Nil(t, setAcceptedAnswer(t, r, question.Id, nil))
+
+	// Удаление принятого ответа снимает отметку и возвращает ее
+	setAcceptedAnswer(t, r, question.Id, &second.ID)
+	assert.Nil(t, deleteAnswer(t, r, first.ID))
+	unaccepted := deleteAnswer(t, r, second.ID)
+	assert.Nil(t, acceptedAnswerId(t, r, question.Id))
+	if assert.NotNil(t, unaccepted) {
+		assert.Equal(t, question.Id, unaccepted.QuestionId)
+		assert.Equal(t, second.ID, unaccepted.AnswerId)
+		assert.Equal(t, "user-2", unaccepted.AnswerAuthorId)
+		assert.Empty(t, unaccepted.QuestionAuthorId)
+		assert.False(t, unaccepted.At.IsZero())
+	}
+
+	_, err = r.Questions.SetAcceptedAnswer(ctx, 999, nil, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testConcurrentAccept(t *testing.T, r Repos) {
+	question := CreateQuestion(t, r, "question")
+	const answers = 10
+	ids := make([]int, 0, answers)
+	for i := 0; i < answers; i++ {
+		ids = append(ids, CreateAnswer(t, r, question.Id, fmt.Sprintf("user-%d", i), "answer").ID)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var previous []*int
+	for _, id := range ids {
+		wg.Add(1)
+		go func(answerId int) {
+			defer wg.Done()
+			before, err := r.Questions.SetAcceptedAnswer(context.Background(), question.Id, &answerId, 0)
+			assert.NoError(t, err)
+			mu.Lock()
+			previous = append(previous, before)
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	// Изменения выстраиваются в цепочку: каждый ответ, кроме последнего принятого, был прежним ровно один раз,
+	// и только первое изменение не застало отметки
+	last := acceptedAnswerId(t, r, question.Id)
+	require.NotNil(t, last)
+	seen := make(map[int]int)
+	empty := 0
+	for _, before := range previous {
+		if before == nil {
+			empty++
+		} else {
+			seen[*before]++
+		}
+	}
+	assert.Equal(t, 1, empty)
+	for _, id := range ids {
+		if id == *last {
+			assert.Zero(t, seen[id], "answer %d", id)
+		} else {
+			assert.Equal(t, 1, seen[id], "answer %d", id)
+		}
+	}
+}
+
+// setAcceptedAnswer меняет принятый ответ и возвращает ранее принятый
+func setAcceptedAnswer(t *testing.T, r Repos, questionId int, answerId *int) *int {
+	t.Helper()
+	previous, err := r.Questions.SetAcceptedAnswer(context.Background(), questionId, answerId, 0)
+	require.NoError(t, err)
+	return previous
+}
+
+func answerIds(answers []entity.Answer) []int {
+	ids := make([]int, 0, len(answers))
+	for _, answer := range answers {
+		ids = append(ids, answer.ID)
+	}
+	return ids
+}
+
+func testSoftDeleteQuestion(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	other := CreateQuestion(t, r, "other question")
+	deletedEarlier := CreateAnswer(t, r, question.Id, "user-1", "deleted earlier")
+	answer1 := CreateAnswer(t, r, question.Id, "user-1", "answer 1")
+	answer2 := CreateAnswer(t, r, question.Id, "user-2", "answer 2")
+	setAcceptedAnswer(t, r, question.Id, &answer2.ID)
+	deleteAnswer(t, r, deletedEarlier.ID)
+
+	// Ответ, удаленный раньше, не входит в удаленные вместе с вопросом
+	assert.Equal(t, []int{answer1.ID, answer2.ID}, deleteQuestion(t, r, question.Id, 0))
+
+	// Удаленный вопрос не виден ни одной операции чтения или изменения
+	questions, err := r.Questions.GetQuestionList(ctx, repo.QuestionListFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{other.Id}, questionIds(*questions))
+	_, err = r.Questions.GetQuestion(ctx, question.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.ErrorIs(t, r.Questions.UpdateQuestion(ctx, &entity.Question{Id: question.Id, Text: "text"}), errs.ErrNotFound)
+	_, err = r.Questions.SetAcceptedAnswer(ctx, question.Id, nil, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Questions.DeleteQuestion(ctx, question.Id, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	err = r.Answers.CreateAnswer(ctx, &entity.Answer{QuestionId: question.Id, UserId: "user-1", Text: "late"}, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Answers.GetAnswer(ctx, answer1.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Answers.VoteAnswer(ctx, &entity.AnswerVote{AnswerId: answer1.ID, UserId: "user-3", Value: 1})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	// Восстанавливаются только ответы, удаленные вместе с вопросом
+	require.NoError(t, r.Questions.RestoreQuestion(ctx, question.Id))
+	restored, err := r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{answer1.ID, answer2.ID}, answerIds(restored.Answers))
+	if assert.NotNil(t, restored.AcceptedAnswerId) {
+		assert.Equal(t, answer2.ID, *restored.AcceptedAnswerId)
+	}
+	assert.False(t, restored.DeletedAt.Valid)
+	_, err = r.Answers.GetAnswer(ctx, deletedEarlier.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	assert.ErrorIs(t, r.Questions.RestoreQuestion(ctx, question.Id), errs.ErrNotFound)
+	assert.ErrorIs(t, r.Questions.RestoreQuestion(ctx, 999), errs.ErrNotFound)
+}
+
+func testRestoreAnswer(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "author", "answer")
+	vote(t, r, answer.ID, "user-1", 1)
+
+	deleteAnswer(t, r, answer.ID)
+	_, err := r.Answers.DeleteAnswer(ctx, answer.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.ErrorIs(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "text"}, "editor"), errs.ErrNotFound)
+	_, err = r.Questions.SetAcceptedAnswer(ctx, question.Id, &answer.ID, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	// Голоса переживают корзину
+	require.NoError(t, r.Answers.RestoreAnswer(ctx, answer.ID))
+	assert.Equal(t, 1, answerScore(t, r, answer.ID))
+	assert.ErrorIs(t, r.Answers.RestoreAnswer(ctx, answer.ID), errs.ErrNotFound)
+
+	// Ответ удаленного вопроса восстанавливается только вместе с вопросом
+	deleteAnswer(t, r, answer.ID)
+	deleteQuestion(t, r, question.Id, 0)
+	assert.ErrorIs(t, r.Answers.RestoreAnswer(ctx, answer.ID), errs.ErrConflict)
+	assert.ErrorIs(t, r.Answers.RestoreAnswer(ctx, 999), errs.ErrNotFound)
+}
+
+func testPurgeQuestion(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	live := CreateQuestion(t, r, "live")
+	liveAnswer := CreateAnswer(t, r, live.Id, "user-1", "answer")
+	trashed := CreateQuestion(t, r, "trashed")
+	trashedAnswer := CreateAnswer(t, r, trashed.Id, "user-1", "trashed answer")
+	deleteQuestion(t, r, trashed.Id, 0)
+
+	assert.Equal(t, []int{liveAnswer.ID}, purgeQuestion(t, r, live.Id, 0))
+	// Окончательное удаление затрагивает и ответы, уже лежащие в корзине
+	assert.Equal(t, []int{trashedAnswer.ID}, purgeQuestion(t, r, trashed.Id, 0))
+
+	_, err := r.Questions.GetQuestion(ctx, live.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.ErrorIs(t, r.Questions.RestoreQuestion(ctx, trashed.Id), errs.ErrNotFound)
+	assert.ErrorIs(t, r.Answers.RestoreAnswer(ctx, liveAnswer.ID), errs.ErrNotFound)
+	_, err = r.Questions.PurgeQuestion(ctx, live.Id, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testTrash(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	deleted := CreateQuestion(t, r, "deleted")
+	CreateAnswer(t, r, deleted.Id, "user-1", "answer of deleted")
+	live := CreateQuestion(t, r, "live")
+	kept := CreateAnswer(t, r, live.Id, "user-1", "kept")
+	trashedAnswer := CreateAnswer(t, r, live.Id, "user-2", "trashed")
+
+	deleteAnswer(t, r, trashedAnswer.ID)
+	deleteQuestion(t, r, deleted.Id, 0)
+
+	// Ответы удаленного вопроса входят в вопрос и отдельно не перечисляются
+	trash, err := r.Trash.GetTrash(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{deleted.Id}, questionIds(trash.Questions))
+	assert.Equal(t, []int{trashedAnswer.ID}, answerIds(trash.Answers))
+	for _, question := range trash.Questions {
+		assert.True(t, question.DeletedAt.Valid)
+	}
+
+	result, err := r.Trash.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, entity.PurgeResult{}, result)
+
+	result, err = r.Trash.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, entity.PurgeResult{Questions: 1, Answers: 1}, result)
+
+	trash, err = r.Trash.GetTrash(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, trash.Questions)
+	assert.Empty(t, trash.Answers)
+	assert.ErrorIs(t, r.Questions.RestoreQuestion(ctx, deleted.Id), errs.ErrNotFound)
+
+	loaded, err := r.Questions.GetQuestion(ctx, live.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{kept.ID}, answerIds(loaded.Answers))
+}
+
+func testRevisions(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "author", "first")
+	require.NoError(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "second"}, "moderator"))
+	require.NoError(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "third"}, "author"))
+
+	revisions, err := r.Answers.GetAnswerRevisions(ctx, answer.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	for i, expected := range []struct{ text, editor string }{
+		{"first", "author"}, {"second", "moderator"}, {"third", "author"},
+	} {
+		assert.Equal(t, i+1, revisions[i].Number)
+		assert.Equal(t, answer.ID, revisions[i].AnswerId)
+		assert.Equal(t, expected.text, revisions[i].Text)
+		assert.Equal(t, expected.editor, revisions[i].EditorId)
+		assert.False(t, revisions[i].CreatedAt.IsZero())
+	}
+
+	revision, err := r.Answers.GetAnswerRevision(ctx, answer.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "second", revision.Text)
+	_, err = r.Answers.GetAnswerRevision(ctx, answer.ID, 4)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Answers.GetAnswerRevisions(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	// История удаленного ответа скрыта вместе с ним и возвращается при восстановлении
+	deleteAnswer(t, r, answer.ID)
+	_, err = r.Answers.GetAnswerRevisions(ctx, answer.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	require.NoError(t, r.Answers.RestoreAnswer(ctx, answer.ID))
+	revisions, err = r.Answers.GetAnswerRevisions(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+}
+
+func testTags(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	golang := CreateTag(t, r, "go")
+	sql := CreateTag(t, r, "sql")
+	assert.ErrorIs(t, r.Tags.CreateTag(ctx, &entity.Tag{Name: "go"}), errs.ErrConflict)
+
+	require.NoError(t, r.Tags.AddTagSynonym(ctx, golang.Id, "golang"))
+	assert.ErrorIs(t, r.Tags.AddTagSynonym(ctx, sql.Id, "golang"), errs.ErrConflict)
+	assert.ErrorIs(t, r.Tags.AddTagSynonym(ctx, sql.Id, "go"), errs.ErrConflict)
+	assert.ErrorIs(t, r.Tags.CreateTag(ctx, &entity.Tag{Name: "golang"}), errs.ErrConflict)
+	assert.ErrorIs(t, r.Tags.AddTagSynonym(ctx, 999, "postgres"), errs.ErrNotFound)
+
+	resolved, err := r.Tags.ResolveTags(ctx, []string{"golang", "sql", "rust"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"golang": golang.Id, "sql": sql.Id}, map[string]int{
+		"golang": resolved["golang"].Id,
+		"sql":    resolved["sql"].Id,
+	})
+	assert.Len(t, resolved, 2)
+
+	// Учитываются только вопросы вне корзины
+	require.NoError(t, r.Questions.CreateQuestion(ctx, &entity.Question{Text: "q1", Tags: []entity.Tag{golang, sql}}))
+	deleted := &entity.Question{Text: "q2", Tags: []entity.Tag{golang}}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, deleted))
+	deleteQuestion(t, r, deleted.Id, 0)
+
+	tags, err := r.Tags.GetTags(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "go", tags[0].Name)
+	assert.Equal(t, 1, tags[0].QuestionCount)
+	assert.Equal(t, []string{"golang"}, tags[0].Synonyms)
+	assert.Equal(t, "sql", tags[1].Name)
+	assert.Empty(t, tags[1].Synonyms)
+
+	// Тег можно переименовать в собственный синоним, синоним при этом удаляется
+	require.NoError(t, r.Tags.RenameTag(ctx, golang.Id, "golang"))
+	info, err := r.Tags.GetTag(ctx, golang.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "golang", info.Name)
+	assert.Empty(t, info.Synonyms)
+	assert.Equal(t, 1, info.QuestionCount)
+
+	assert.ErrorIs(t, r.Tags.RenameTag(ctx, golang.Id, "sql"), errs.ErrConflict)
+	assert.ErrorIs(t, r.Tags.RenameTag(ctx, 999, "rust"), errs.ErrNotFound)
+	_, err = r.Tags.GetTag(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testQuestionListByTags(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	golang := CreateTag(t, r, "go")
+	sql := CreateTag(t, r, "sql")
+	both := &entity.Question{Text: "both", Tags: []entity.Tag{golang, sql}}
+	onlyGo := &entity.Question{Text: "only go", Tags: []entity.Tag{golang}}
+	untagged := &entity.Question{Text: "untagged"}
+	for _, question := range []*entity.Question{both, onlyGo, untagged} {
+		require.NoError(t, r.Questions.CreateQuestion(ctx, question))
+	}
+
+	list := func(filter repo.QuestionListFilter) []int {
+		questions, err := r.Questions.GetQuestionList(ctx, filter)
+		require.NoError(t, err)
+		return questionIds(*questions)
+	}
+	tagIds := []int{golang.Id, sql.Id}
+	assert.Equal(t, []int{both.Id}, list(repo.QuestionListFilter{TagIds: tagIds, TagMode: entity.TagModeAll}))
+	assert.Equal(t, []int{both.Id, onlyGo.Id}, list(repo.QuestionListFilter{TagIds: tagIds, TagMode: entity.TagModeAny}))
+	assert.Equal(t, []int{onlyGo.Id}, list(repo.QuestionListFilter{AfterId: both.Id, TagIds: []int{golang.Id}, TagMode: entity.TagModeAll}))
+	assert.Equal(t, []int{both.Id, onlyGo.Id, untagged.Id}, list(repo.QuestionListFilter{}))
+
+	// Теги отдаются вместе с вопросом по имени
+	loaded, err := r.Questions.GetQuestion(ctx, both.Id)
+	require.NoError(t, err)
+	names := make([]string, 0, len(loaded.Tags))
+	for _, tag := range loaded.Tags {
+		names = append(names, tag.Name)
+	}
+	assert.Equal(t, []string{"go", "sql"}, names)
+}
+
+func commentIds(comments []entity.Comment) []int {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	return ids
+}
+
+func testComments(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "user-1", "answer")
+	other := CreateAnswer(t, r, question.Id, "user-2", "other")
+
+	root := CreateComment(t, r, answer.ID, nil, "root")
+	reply := CreateComment(t, r, answer.ID, root, "reply")
+	nested := CreateComment(t, r, answer.ID, reply, "nested")
+	sibling := CreateComment(t, r, answer.ID, nil, "sibling")
+	otherComment := CreateComment(t, r, other.ID, nil, "other")
+
+	loaded, err := r.Comments.GetComment(ctx, nested.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loaded.Depth)
+	assert.Equal(t, reply.Id, *loaded.ParentId)
+	assert.Equal(t, "user-1", loaded.UserId)
+	assert.False(t, loaded.CreatedAt.IsZero())
+
+	comments, err := r.Comments.GetAnswerComments(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{root.Id, reply.Id, nested.Id, sibling.Id}, commentIds(comments))
+
+	comments, err = r.Comments.GetQuestionComments(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{root.Id, reply.Id, nested.Id, sibling.Id, otherComment.Id}, commentIds(comments))
+
+	// Родитель должен быть комментарием того же ответа
+	err = r.Comments.CreateComment(ctx, &entity.Comment{AnswerId: other.ID, ParentId: &root.Id, UserId: "user-1", Text: "x"})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	err = r.Comments.CreateComment(ctx, &entity.Comment{AnswerId: 999, UserId: "user-1", Text: "x"})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Comments.GetAnswerComments(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	// Удаление комментария удаляет ответы на него
+	require.NoError(t, r.Comments.DeleteComment(ctx, reply.Id))
+	comments, err = r.Comments.GetAnswerComments(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{root.Id, sibling.Id}, commentIds(comments))
+	_, err = r.Comments.GetComment(ctx, nested.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.ErrorIs(t, r.Comments.DeleteComment(ctx, reply.Id), errs.ErrNotFound)
+}
+
+func testCommentsCascade(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	question := CreateQuestion(t, r, "question")
+	answer := CreateAnswer(t, r, question.Id, "user-1", "answer")
+	comment := CreateComment(t, r, answer.ID, nil, "comment")
+
+	// Комментарии ответа в корзине скрыты и возвращаются вместе с ним
+	deleteAnswer(t, r, answer.ID)
+	_, err := r.Comments.GetComment(ctx, comment.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Comments.GetAnswerComments(ctx, answer.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	comments, err := r.Comments.GetQuestionComments(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+	err = r.Comments.CreateComment(ctx, &entity.Comment{AnswerId: answer.ID, UserId: "user-1", Text: "x"})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	require.NoError(t, r.Answers.RestoreAnswer(ctx, answer.ID))
+	comments, err = r.Comments.GetAnswerComments(ctx, answer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{comment.Id}, commentIds(comments))
+
+	// То же при удалении вопроса, а окончательное удаление уносит комментарии совсем
+	deleteQuestion(t, r, question.Id, 0)
+	_, err = r.Comments.GetComment(ctx, comment.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	require.NoError(t, r.Questions.RestoreQuestion(ctx, question.Id))
+	_, err = r.Comments.GetComment(ctx, comment.Id)
+	require.NoError(t, err)
+
+	purgeQuestion(t, r, question.Id, 0)
+	_, err = r.Comments.GetComment(ctx, comment.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testUsers(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	_, err := r.Users.GetUser(ctx, "user-1")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Users.GetUserAnswers(ctx, "user-1", repo.UserListFilter{})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Users.GetUserQuestions(ctx, "user-1", repo.UserListFilter{})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.ErrorIs(t, r.Users.UpdateUser(ctx, &entity.User{Id: "user-1", DisplayName: "x"}), errs.ErrNotFound)
+
+	// Повторная регистрация не перезаписывает профиль и возвращает сохраненный
+	user := &entity.User{Id: "user-1", DisplayName: "Alice"}
+	require.NoError(t, r.Users.EnsureUser(ctx, user))
+	assert.False(t, user.CreatedAt.IsZero())
+	again := &entity.User{Id: "user-1", DisplayName: "Mallory"}
+	require.NoError(t, r.Users.EnsureUser(ctx, again))
+	assert.Equal(t, "Alice", again.DisplayName)
+
+	require.NoError(t, r.Users.UpdateUser(ctx, &entity.User{Id: "user-1", DisplayName: "Alice B.", Bio: "Gopher"}))
+	stored, err := r.Users.GetUser(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice B.", stored.DisplayName)
+	assert.Equal(t, "Gopher", stored.Bio)
+	assert.WithinDuration(t, user.CreatedAt, stored.CreatedAt, time.Second)
+
+	// Вопросы пользователя - только его, вне корзины, с тегами
+	CreateUser(t, r, "user-2")
+	tag := CreateTag(t, r, "go")
+	authorId := "user-1"
+	first := &entity.Question{Text: "first", AuthorId: &authorId}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, first))
+	CreateQuestion(t, r, "anonymous")
+	otherId := "user-2"
+	require.NoError(t, r.Questions.CreateQuestion(ctx, &entity.Question{Text: "other", AuthorId: &otherId}))
+	second := &entity.Question{Text: "second", AuthorId: &authorId, Tags: []entity.Tag{tag}}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, second))
+	deleted := &entity.Question{Text: "deleted", AuthorId: &authorId}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, deleted))
+	CreateAnswer(t, r, deleted.Id, "user-1", "deleted with question")
+	deleteQuestion(t, r, deleted.Id, 0)
+
+	questions, err := r.Users.GetUserQuestions(ctx, "user-1", repo.UserListFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.Id, second.Id}, questionIds(questions))
+	require.Len(t, questions[1].Tags, 1)
+	assert.Equal(t, tag.Id, questions[1].Tags[0].Id)
+	questions, err = r.Users.GetUserQuestions(ctx, "user-1", repo.UserListFilter{AfterId: first.Id})
+	require.NoError(t, err)
+	assert.Equal(t, []int{second.Id}, questionIds(questions))
+	questions, err = r.Users.GetUserQuestions(ctx, "user-1", repo.UserListFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.Id}, questionIds(questions))
+
+	// Ответы пользователя, в том числе на чужие вопросы; ответы удаленного вопроса скрыты
+	answer1 := CreateAnswer(t, r, first.Id, "user-1", "own")
+	CreateAnswer(t, r, first.Id, "user-2", "foreign")
+	answer2 := CreateAnswer(t, r, second.Id, "user-1", "second")
+	answers, err := r.Users.GetUserAnswers(ctx, "user-1", repo.UserListFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{answer1.ID, answer2.ID}, answerIds(answers))
+	answers, err = r.Users.GetUserAnswers(ctx, "user-1", repo.UserListFilter{AfterId: answer1.ID, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{answer2.ID}, answerIds(answers))
+}
+
+func reputationOf(t *testing.T, r Repos, userId string) int {
+	t.Helper()
+	user, err := r.Users.GetUser(context.Background(), userId)
+	require.NoError(t, err)
+	return user.Reputation
+}
+
+func testReputationLedger(t *testing.T, r Repos) {
+	ctx := context.Background()
+	CreateUser(t, r, "user-1")
+	CreateUser(t, r, "user-2")
+	CreateUser(t, r, "user-3")
+
+	now := time.Now()
+	old := now.AddDate(0, -2, 0)
+	require.NoError(t, r.Reputation.AddReputation(ctx, []entity.ReputationEntry{
+		{UserId: "user-1", Points: 10, Reason: entity.ReputationReasonVote, AnswerId: 1, CreatedAt: old},
+		{UserId: "user-1", Points: 15, Reason: entity.ReputationReasonAccepted, AnswerId: 1, CreatedAt: old},
+		{UserId: "user-2", Points: 10, Reason: entity.ReputationReasonVote, AnswerId: 2, CreatedAt: now},
+		{UserId: "user-3", Points: -2, Reason: entity.ReputationReasonVote, AnswerId: 3, CreatedAt: now},
+	}))
+	require.NoError(t, r.Reputation.AddReputation(ctx, nil))
+	require.NoError(t, r.Reputation.AddReputation(ctx, []entity.ReputationEntry{
+		{UserId: "user-2", Points: 10, Reason: entity.ReputationReasonVote, AnswerId: 4, CreatedAt: now},
+		{UserId: "user-2", Points: -10, Reason: entity.ReputationReasonVote, AnswerId: 4, CreatedAt: now},
+	}))
+	assert.Equal(t, 25, reputationOf(t, r, "user-1"))
+	assert.Equal(t, 10, reputationOf(t, r, "user-2"))
+	assert.Equal(t, -2, reputationOf(t, r, "user-3"))
+
+	// Суммы по ответу, пользователю и причине; нулевые суммы не возвращаются
+	points, err := r.Reputation.GetAnswerReputation(ctx, []int{1, 3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []entity.ReputationEntry{
+		{UserId: "user-1", Points: 15, Reason: entity.ReputationReasonAccepted, AnswerId: 1},
+		{UserId: "user-1", Points: 10, Reason: entity.ReputationReasonVote, AnswerId: 1},
+		{UserId: "user-3", Points: -2, Reason: entity.ReputationReasonVote, AnswerId: 3},
+	}, points)
+
+	// Пользователи без положительной суммы в таблицу не попадают
+	leaders, err := r.Reputation.GetLeaderboard(ctx, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.LeaderboardEntry{
+		{UserId: "user-1", DisplayName: "user-1", Reputation: 25},
+		{UserId: "user-2", DisplayName: "user-2", Reputation: 10},
+	}, leaders)
+	leaders, err = r.Reputation.GetLeaderboard(ctx, time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Len(t, leaders, 1)
+	leaders, err = r.Reputation.GetLeaderboard(ctx, now.AddDate(0, 0, -7), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.LeaderboardEntry{{UserId: "user-2", DisplayName: "user-2", Reputation: 10}}, leaders)
+
+	// Пересчет заменяет журнал целиком, в том числе обнуляет репутацию пользователей без записей
+	require.NoError(t, r.Reputation.ReplaceReputation(ctx, []entity.ReputationEntry{
+		{UserId: "user-3", Points: 2, Reason: entity.ReputationReasonAccept, AnswerId: 3, CreatedAt: now},
+	}))
+	assert.Equal(t, 0, reputationOf(t, r, "user-1"))
+	assert.Equal(t, 0, reputationOf(t, r, "user-2"))
+	assert.Equal(t, 2, reputationOf(t, r, "user-3"))
+	leaders, err = r.Reputation.GetLeaderboard(ctx, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.LeaderboardEntry{{UserId: "user-3", DisplayName: "user-3", Reputation: 2}}, leaders)
+}
+
+func testReputationSources(t *testing.T, r Repos) {
+	ctx := context.Background()
+	CreateUser(t, r, "asker")
+	askerId := "asker"
+	question := &entity.Question{Text: "question", AuthorId: &askerId}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, question))
+	anonymous := CreateQuestion(t, r, "anonymous")
+
+	answer := CreateAnswer(t, r, question.Id, "user-1", "answer")
+	deleted := CreateAnswer(t, r, question.Id, "user-2", "deleted")
+	other := CreateAnswer(t, r, anonymous.Id, "user-2", "other")
+	vote(t, r, answer.ID, "user-3", 1)
+	vote(t, r, answer.ID, "user-2", -1)
+	vote(t, r, deleted.ID, "user-3", 1)
+	deleteAnswer(t, r, deleted.ID)
+
+	// Голоса за ответы в корзине в пересчет не попадают
+	votes, err := r.Reputation.GetVotes(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, votes, 2)
+	assert.Equal(t, answer.ID, votes[0].AnswerId)
+	assert.Equal(t, question.Id, votes[0].QuestionId)
+	assert.Equal(t, "user-1", votes[0].AuthorId)
+	assert.Equal(t, "user-2", votes[0].VoterId)
+	assert.Equal(t, -1, votes[0].Value)
+	assert.Equal(t, "user-3", votes[1].VoterId)
+	assert.Equal(t, 1, votes[1].Value)
+	assert.False(t, votes[1].At.IsZero())
+	votes, err = r.Reputation.GetVotes(ctx, []int{deleted.ID, other.ID})
+	require.NoError(t, err)
+	assert.Empty(t, votes)
+
+	setAcceptedAnswer(t, r, question.Id, &answer.ID)
+	setAcceptedAnswer(t, r, anonymous.Id, &other.ID)
+	accepted, err := r.Reputation.GetAcceptedAnswers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, accepted, 2)
+	assert.Equal(t, answer.ID, accepted[0].AnswerId)
+	assert.Equal(t, "user-1", accepted[0].AnswerAuthorId)
+	assert.Equal(t, "asker", accepted[0].QuestionAuthorId)
+	assert.WithinDuration(t, time.Now(), accepted[0].At, time.Minute)
+	assert.Equal(t, other.ID, accepted[1].AnswerId)
+	assert.Empty(t, accepted[1].QuestionAuthorId)
+	accepted, err = r.Reputation.GetAcceptedAnswers(ctx, []int{other.ID})
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, anonymous.Id, accepted[0].QuestionId)
+
+	// Снятая отметка и вопрос в корзине убирают принятый ответ из пересчета
+	setAcceptedAnswer(t, r, question.Id, nil)
+	deleteQuestion(t, r, anonymous.Id, 0)
+	accepted, err = r.Reputation.GetAcceptedAnswers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, accepted)
+}
+
+// reputationSnapshot возвращает репутацию пользователей userIds
+func reputationSnapshot(t *testing.T, r Repos, userIds ...string) map[string]int {
+	t.Helper()
+	snapshot := make(map[string]int, len(userIds))
+	for _, userId := range userIds {
+		snapshot[userId] = reputationOf(t, r, userId)
+	}
+	return snapshot
+}
+
+// testReputationRebuildAfterDelete проверяет, что журнал, накопленный по событиям удаления
+// и восстановления, совпадает с полным пересчетом
+func testReputationRebuildAfterDelete(t *testing.T, r Repos) {
+	ctx := context.Background()
+	reputation := cases.NewReputationCase(r.Reputation, entity.ReputationWeights{
+		Upvote:          10,
+		DownvotePenalty: 2,
+		Accepted:        15,
+		Accept:          2,
+	}, zap.NewNop())
+	users := []string{"asker", "user-1", "user-2", "user-3"}
+	for _, userId := range users {
+		CreateUser(t, r, userId)
+	}
+	askerId := "asker"
+	question := &entity.Question{Text: "question", AuthorId: &askerId}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, question))
+	answer := CreateAnswer(t, r, question.Id, "user-1", "answer")
+	kept := CreateAnswer(t, r, question.Id, "user-2", "kept")
+
+	castVote := func(answer *entity.Answer, voterId string, value int) {
+		previous := vote(t, r, answer.ID, voterId, value)
+		reputation.HandleEvent(ctx, entity.AnswerVoted{
+			AnswerId:   answer.ID,
+			QuestionId: answer.QuestionId,
+			AuthorId:   answer.UserId,
+			VoterId:    voterId,
+			Previous:   previous,
+			Value:      value,
+			At:         time.Now(),
+		})
+	}
+	accept := func(answer *entity.Answer) {
+		setAcceptedAnswer(t, r, question.Id, &answer.ID)
+		reputation.HandleEvent(ctx, entity.AnswerAccepted{
+			QuestionId:       question.Id,
+			AnswerId:         answer.ID,
+			AnswerAuthorId:   answer.UserId,
+			QuestionAuthorId: askerId,
+			At:               time.Now(),
+		})
+	}
+	rebuilt := func() {
+		t.Helper()
+		ledger := reputationSnapshot(t, r, users...)
+		require.NoError(t, reputation.RebuildReputation(ctx))
+		assert.Equal(t, ledger, reputationSnapshot(t, r, users...))
+	}
+
+	castVote(answer, "user-2", 1)
+	castVote(answer, "user-3", -1)
+	castVote(kept, "user-3", 1)
+	accept(answer)
+	assert.Equal(t, 10-2+15, reputationOf(t, r, "user-1"))
+
+	// Удаление проголосованного принятого ответа снимает и голоса, и отметку
+	unaccepted := deleteAnswer(t, r, answer.ID)
+	require.NotNil(t, unaccepted)
+	reputation.HandleEvent(ctx, *unaccepted)
+	reputation.HandleEvent(ctx, entity.AnswerDeleted{AnswerId: answer.ID, QuestionId: question.Id, At: time.Now()})
+	assert.Equal(t, 0, reputationOf(t, r, "user-1"))
+	assert.Equal(t, 0, reputationOf(t, r, "asker"))
+	rebuilt()
+
+	// Восстановленный ответ возвращает голоса, но не отметку
+	require.NoError(t, r.Answers.RestoreAnswer(ctx, answer.ID))
+	reputation.HandleEvent(ctx, entity.AnswerRestored{AnswerId: answer.ID, QuestionId: question.Id, At: time.Now()})
+	assert.Equal(t, 10-2, reputationOf(t, r, "user-1"))
+	rebuilt()
+
+	// Вопрос в корзине не дает очков ни за голоса, ни за принятый ответ; восстановление возвращает их
+	accept(kept)
+	answerIds := deleteQuestion(t, r, question.Id, 0)
+	reputation.HandleEvent(ctx, entity.QuestionDeleted{QuestionId: question.Id, AnswerIds: answerIds, At: time.Now()})
+	assert.Equal(t, map[string]int{"asker": 0, "user-1": 0, "user-2": 0, "user-3": 0}, reputationSnapshot(t, r, users...))
+	rebuilt()
+
+	require.NoError(t, r.Questions.RestoreQuestion(ctx, question.Id))
+	reputation.HandleEvent(ctx, entity.QuestionRestored{QuestionId: question.Id, AnswerIds: answerIds, At: time.Now()})
+	assert.Equal(t, 10+15, reputationOf(t, r, "user-2"))
+	assert.Equal(t, 2, reputationOf(t, r, "asker"))
+	rebuilt()
+
+	// Повторное событие ничего не меняет, окончательное удаление снимает все очки
+	reputation.HandleEvent(ctx, entity.QuestionRestored{QuestionId: question.Id, AnswerIds: answerIds, At: time.Now()})
+	rebuilt()
+	answerIds = purgeQuestion(t, r, question.Id, 0)
+	reputation.HandleEvent(ctx, entity.QuestionDeleted{QuestionId: question.Id, AnswerIds: answerIds, Hard: true, At: time.Now()})
+	assert.Equal(t, map[string]int{"asker": 0, "user-1": 0, "user-2": 0, "user-3": 0}, reputationSnapshot(t, r, users...))
+	rebuilt()
+}
+
+func testIdempotencyKeys(t *testing.T, r Repos) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	record := func(userId string, fingerprint string, createdAt time.Time) *entity.IdempotencyRecord {
+		return &entity.IdempotencyRecord{
+			UserId:      userId,
+			Key:         "key-1",
+			Fingerprint: fingerprint,
+			CreatedAt:   createdAt,
+			ExpiresAt:   createdAt.Add(time.Hour),
+		}
+	}
+
+	stored, err := r.Idempotency.ReserveKey(ctx, record("user-1", "first", now))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Повтор, пока запрос выполняется, видит незавершенную запись
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-1", "second", now.Add(time.Minute)))
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "first", stored.Fingerprint)
+	assert.False(t, stored.Completed())
+
+	// Ключи разных пользователей независимы
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-2", "other", now))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	require.NoError(t, r.Idempotency.CompleteKey(ctx, &entity.IdempotencyRecord{
+		UserId: "user-1", Key: "key-1", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`),
+	}))
+	assert.ErrorIs(t, r.Idempotency.CompleteKey(ctx, &entity.IdempotencyRecord{UserId: "user-3", Key: "key-1", StatusCode: 201}), errs.ErrNotFound)
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-1", "first", now.Add(time.Minute)))
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 201, stored.StatusCode)
+	assert.Equal(t, "application/json", stored.ContentType)
+	assert.Equal(t, `{"id":1}`, string(stored.Body))
+
+	// Освобождается только ключ незавершенного запроса
+	require.NoError(t, r.Idempotency.ReleaseKey(ctx, "user-1", "key-1"))
+	require.NoError(t, r.Idempotency.ReleaseKey(ctx, "user-2", "key-1"))
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-1", "first", now.Add(time.Minute)))
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-2", "again", now.Add(time.Minute)))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// Истекший ключ резервируется заново, ответ прежнего запроса забывается
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-1", "late", now.Add(2*time.Hour)))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-1", "later", now.Add(2*time.Hour+time.Minute)))
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "late", stored.Fingerprint)
+	assert.False(t, stored.Completed())
+
+	// Очистка удаляет ключи, истекшие к заданному времени
+	purged, err := r.Idempotency.PurgeKeys(ctx, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	stored, err = r.Idempotency.ReserveKey(ctx, record("user-2", "new", now.Add(90*time.Minute)))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func testQuestionVersions(t *testing.T, r Repos) {
+	ctx := context.Background()
+	tag := CreateTag(t, r, "go")
+	question := &entity.Question{Text: "question", Tags: []entity.Tag{tag}}
+	require.NoError(t, r.Questions.CreateQuestion(ctx, question))
+	other := CreateQuestion(t, r, "other")
+
+	version, err := r.Questions.GetQuestionVersion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Positive(t, version.Version)
+	assert.False(t, version.ModifiedAt.IsZero())
+	loaded, err := r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, version.Version, loaded.Version)
+	otherVersion, err := r.Questions.GetQuestionVersion(ctx, other.Id)
+	require.NoError(t, err)
+
+	// Любое изменение представления вопроса поднимает его версию и не трогает другие вопросы
+	bumped := func(name string, change func()) {
+		t.Helper()
+		change()
+		current, err := r.Questions.GetQuestionVersion(ctx, question.Id)
+		require.NoError(t, err, name)
+		assert.Greater(t, current.Version, version.Version, name)
+		assert.False(t, current.ModifiedAt.Before(version.ModifiedAt), name)
+		version = current
+	}
+	var answer *entity.Answer
+	var comment *entity.Comment
+	bumped("create answer", func() { answer = CreateAnswer(t, r, question.Id, "user-1", "answer") })
+	bumped("update answer", func() {
+		require.NoError(t, r.Answers.UpdateAnswer(ctx, &entity.Answer{ID: answer.ID, Text: "edited"}, "user-1"))
+	})
+	bumped("vote", func() {
+		CreateUser(t, r, "voter")
+		_, err := r.Answers.VoteAnswer(ctx, &entity.AnswerVote{AnswerId: answer.ID, UserId: "voter", Value: 1})
+		require.NoError(t, err)
+	})
+	bumped("accept", func() { setAcceptedAnswer(t, r, question.Id, &answer.ID) })
+	bumped("comment", func() { comment = CreateComment(t, r, answer.ID, nil, "comment") })
+	bumped("delete comment", func() { require.NoError(t, r.Comments.DeleteComment(ctx, comment.Id)) })
+	bumped("rename tag", func() { require.NoError(t, r.Tags.RenameTag(ctx, tag.Id, "golang")) })
+	bumped("delete answer", func() { deleteAnswer(t, r, answer.ID) })
+	bumped("restore answer", func() { require.NoError(t, r.Answers.RestoreAnswer(ctx, answer.ID)) })
+	bumped("update question", func() {
+		require.NoError(t, r.Questions.UpdateQuestion(ctx, &entity.Question{Id: question.Id, Text: "edited", Version: version.Version}))
+	})
+	bumped("accept with version", func() {
+		_, err := r.Questions.SetAcceptedAnswer(ctx, question.Id, &answer.ID, version.Version)
+		require.NoError(t, err)
+	})
+
+	current, err := r.Questions.GetQuestionVersion(ctx, other.Id)
+	require.NoError(t, err)
+	assert.Equal(t, otherVersion.Version, current.Version)
+
+	// Изменение с устаревшей версией отклоняется и ничего не меняет
+	stale := version.Version - 1
+	err = r.Questions.UpdateQuestion(ctx, &entity.Question{Id: question.Id, Text: "lost update", Version: stale})
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	loaded, err = r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", loaded.Text)
+	assert.Equal(t, version.Version, loaded.Version)
+	_, err = r.Questions.DeleteQuestion(ctx, question.Id, stale)
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	_, err = r.Questions.SetAcceptedAnswer(ctx, question.Id, nil, stale)
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	err = r.Answers.CreateAnswer(ctx, &entity.Answer{QuestionId: question.Id, UserId: "user-1", Text: "lost answer"}, stale)
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	loaded, err = r.Questions.GetQuestion(ctx, question.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &answer.ID, loaded.AcceptedAnswerId)
+	assert.Len(t, loaded.Answers, 1)
+	assert.Equal(t, version.Version, loaded.Version)
+	assert.ErrorIs(t, r.Questions.UpdateQuestion(ctx, &entity.Question{Id: 999, Text: "text", Version: 1}), errs.ErrNotFound)
+
+	deleteQuestion(t, r, question.Id, version.Version)
+	_, err = r.Questions.GetQuestionVersion(ctx, question.Id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = r.Questions.PurgeQuestion(ctx, question.Id, version.Version)
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	purgeQuestion(t, r, other.Id, otherVersion.Version)
+	_, err = r.Questions.GetQuestionVersion(ctx, 999)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
@@ -3,33 +3,309 @@ package app
 import (
 	"HiTalent_TestTask/backend/config"
 	"HiTalent_TestTask/backend/internal/adapter/repo/postgres"
+	"HiTalent_TestTask/backend/internal/auth"
 	"HiTalent_TestTask/backend/internal/cases"
+	"HiTalent_TestTask/backend/internal/event"
 	"HiTalent_TestTask/backend/internal/input/http/server"
+	"HiTalent_TestTask/backend/internal/metrics"
+	"HiTalent_TestTask/backend/internal/policy"
+	"HiTalent_TestTask/backend/internal/ratelimit"
+	"HiTalent_TestTask/backend/internal/tracing"
+	migrations "HiTalent_TestTask/backend/pkg/migration/postgres"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// healthCheckTimeout ограничивает каждую проверку готовности
+const healthCheckTimeout = 2 * time.Second
+
+// App - собранное приложение: HTTP-серверы, фоновая очистка корзины и ключей идемпотентности и ресурсы, которые нужно освободить при остановке
+type App struct {
+	cfg             config.Config
+	logger          *zap.Logger
+	db              *gorm.DB
+	httpServer      *http.Server
+	metricsServer   *http.Server // nil - метрики отдаются основным сервером
+	trashCase       *cases.TrashCase
+	idempotencyCase *cases.IdempotencyCase
+	health          *Health
+	shutdownTracing func(context.Context) error
+}
+
+// Start собирает приложение и работает до SIGINT/SIGTERM, после чего корректно останавливается
 func Start(cfg config.Config, logger *zap.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	application, err := New(cfg, logger)
+	if err != nil {
+		return err
+	}
+	return application.Run(ctx)
+}
+
+// New подключается к БД и собирает зависимости; при ошибке уже открытые ресурсы закрываются
+func New(cfg config.Config, logger *zap.Logger) (_ *App, err error) {
+	a := &App{cfg: cfg, logger: logger}
+	defer func() {
+		if err != nil {
+			a.release()
+		}
+	}()
+
+	// Трассировка настраивается до подключения к БД, чтобы плагин GORM писал спаны в выбранный экспортер
+	if a.shutdownTracing, err = tracing.Setup(context.Background(), tracing.Exporter(cfg.TracingExporter), cfg.ServiceName); err != nil {
+		return nil, err
+	}
+
 	// Создаем подключение к БД через GORM
-	db, err := postgres.NewGormDB(cfg.PgConnStr)
+	if a.db, err = postgres.NewGormDB(cfg.PgConnStr); err != nil {
+		return nil, fmt.Errorf("failed to create gorm db: %w", err)
+	}
+	db := a.db
+	sqlDB, err := db.DB()
 	if err != nil {
-		logger.Fatal("Failed to connect to postgres", zap.Error(err))
-		return fmt.Errorf("failed to create gorm db: %w", err)
+		return nil, fmt.Errorf("failed to get sql db: %w", err)
 	}
 
+	// Готовность: БД отвечает и схема на последней миграции
+	a.health = NewHealth(healthCheckTimeout)
+	a.health.Register("postgres", sqlDB.PingContext)
+	a.health.Register("migrations", func(ctx context.Context) error {
+		return migrations.CheckVersion(ctx, sqlDB)
+	})
+
 	// Создаем репозитории
 	questionRepo := postgres.NewQuestionRepo(db)
 	answerRepo := postgres.NewAnswerRepo(db)
+	searchRepo := postgres.NewSearchRepo(db)
+	trashRepo := postgres.NewTrashRepo(db)
+	tagRepo := postgres.NewTagRepo(db)
+	commentRepo := postgres.NewCommentRepo(db)
+	userRepo := postgres.NewUserRepo(db)
+	reputationRepo := postgres.NewReputationRepo(db)
+	idempotencyRepo := postgres.NewIdempotencyRepo(db)
+
+	accessPolicy, err := newPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Репутация начисляется по событиям голосования и выбора ответа
+	bus := event.NewBus()
+	reputationCase := cases.NewReputationCase(reputationRepo, cfg.ReputationWeights, logger)
+	bus.Subscribe(reputationCase.HandleEvent)
+
+	// Доменные счетчики метрик тоже считаются по событиям
+	appMetrics := metrics.New()
+	bus.Subscribe(appMetrics.HandleEvent)
+
+	// Добавленные и удаленные ответы уходят в потоки событий вопросов
+	eventBroker := event.NewBroker(cfg.SSEReplaySize, cfg.SSEClientBuffer)
+	bus.Subscribe(eventBroker.HandleEvent)
+	if err := appMetrics.RegisterDB(sqlDB, "postgres"); err != nil {
+		return nil, fmt.Errorf("failed to register db metrics: %w", err)
+	}
 
 	// Создаем cases (бизнес-логика)
-	questionCase := cases.NewQuestionCase(questionRepo, logger)
-	answerCase := cases.NewAnswerCase(answerRepo, logger)
+	questionCase := cases.NewQuestionCase(questionRepo, tagRepo, commentRepo, accessPolicy, bus, logger)
+	answerCase := cases.NewAnswerCase(answerRepo, accessPolicy, bus, logger)
+	searchCase := cases.NewSearchCase(searchRepo, cfg.SearchLanguage, logger)
+	a.trashCase = cases.NewTrashCase(trashRepo, accessPolicy, cfg.TrashRetention, logger)
+	tagCase := cases.NewTagCase(tagRepo, accessPolicy, logger)
+	commentCase := cases.NewCommentCase(commentRepo, accessPolicy, bus, cfg.CommentMaxDepth, logger)
+	userCase := cases.NewUserCase(userRepo, accessPolicy, logger)
+	a.idempotencyCase = cases.NewIdempotencyCase(idempotencyRepo, cfg.IdempotencyKeyTTL, logger)
+
+	verifier, err := newVerifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitRules, err := ratelimit.ParseRules(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Rate limits", zap.Stringers("rules", rateLimitRules))
+	// Пустая AUTH_RATE_LIMIT оставляет нулевой лимит, и проверка токенов не ограничивается
+	var authRateLimit ratelimit.Limit
+	if cfg.AuthRateLimit != "" {
+		if authRateLimit, err = ratelimit.ParseLimit(cfg.AuthRateLimit); err != nil {
+			return nil, fmt.Errorf("invalid AUTH_RATE_LIMIT: %w", err)
+		}
+		logger.Info("Authentication rate limit", zap.Stringer("limit", authRateLimit))
+	}
+	trustedProxies, err := server.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if !verifier.HasKeys() {
+		logger.Warn("No JWT keys configured, all requests are anonymous")
+	}
 
 	// Создаем HTTP сервер
-	srv := server.NewServer(questionCase, answerCase, logger)
+	srv := server.NewServer(questionCase, answerCase, logger,
+		server.WithSearchCase(searchCase),
+		server.WithTrashCase(a.trashCase),
+		server.WithTagCase(tagCase),
+		server.WithCommentCase(commentCase),
+		server.WithUserCase(userCase),
+		server.WithReputationCase(reputationCase),
+		server.WithIdempotencyCase(a.idempotencyCase),
+		server.WithAuthenticator(verifier),
+		server.WithMetrics(appMetrics, cfg.MetricsAddr == ""),
+		server.WithRateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rateLimitRules)),
+		server.WithAuthRateLimit(authRateLimit),
+		server.WithTrustedProxies(trustedProxies),
+		server.WithEventBroker(eventBroker, cfg.SSEHeartbeatInterval),
+	)
+	// Пробы обслуживаются до Server, без аутентификации, метрик и трассировки
+	mux := a.healthMux()
+	mux.Handle("/", srv)
+	a.httpServer = a.newHTTPServer(cfg.HTTPPort, mux)
+	// Потоки событий не завершаются сами: при остановке подписки закрываются, чтобы Shutdown не ждал их до таймаута
+	a.httpServer.RegisterOnShutdown(eventBroker.Close)
+
+	if cfg.MetricsAddr != "" {
+		adminMux := a.healthMux()
+		adminMux.Handle("/metrics", appMetrics.Handler())
+		a.metricsServer = a.newHTTPServer(cfg.MetricsAddr, adminMux)
+	}
+	return a, nil
+}
+
+// healthMux создает маршрутизатор с /healthz и /readyz
+func (a *App) healthMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", a.health.LivenessHandler())
+	mux.Handle("GET /readyz", a.health.ReadinessHandler())
+	return mux
+}
+
+// newHTTPServer создает сервер с таймаутами из конфигурации
+func (a *App) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: a.cfg.HTTPReadTimeout,
+		ReadTimeout:       a.cfg.HTTPReadTimeout,
+		WriteTimeout:      a.cfg.HTTPWriteTimeout,
+		IdleTimeout:       a.cfg.HTTPIdleTimeout,
+		ErrorLog:          zap.NewStdLog(a.logger),
+	}
+}
+
+// Run обслуживает запросы, пока не отменен ctx или не упал один из серверов, затем дожидается
+// текущих запросов (не дольше ShutdownTimeout), останавливает фоновую очистку и закрывает пул соединений БД
+func (a *App) Run(ctx context.Context) error {
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	defer stopPurge()
+	// Фоновая очистка корзины от записей старше срока хранения и истекших ключей идемпотентности
+	var purges sync.WaitGroup
+	purges.Go(func() { a.trashCase.RunPurge(purgeCtx, a.cfg.TrashPurgeInterval) })
+	purges.Go(func() { a.idempotencyCase.RunPurge(purgeCtx, a.cfg.IdempotencyPurgeInterval) })
+
+	servers := []*http.Server{a.httpServer}
+	if a.metricsServer != nil {
+		servers = append(servers, a.metricsServer)
+	}
+	serveErr := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			a.logger.Info("Starting server", zap.String("addr", srv.Addr))
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("server %s failed: %w", srv.Addr, err)
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Shutting down")
+	case runErr = <-serveErr:
+		a.logger.Error("Server failed, shutting down", zap.Error(runErr))
+	}
+
+	// Сначала /readyz начинает отвечать 503, чтобы балансировщик успел снять трафик, и только потом закрываются листенеры
+	a.health.SetShuttingDown()
+	if runErr == nil && a.cfg.ShutdownDelay > 0 {
+		time.Sleep(a.cfg.ShutdownDelay)
+	}
 
-	logger.Info("Starting server", zap.String("port", cfg.HTTPPort))
-	return http.ListenAndServe(cfg.HTTPPort, srv)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.cfg.ShutdownTimeout)
+	defer cancel()
+	var shutdownErrs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// Запросы не успели завершиться за отведенное время - обрываем соединения
+			a.logger.Warn("Graceful shutdown timed out, closing connections", zap.String("addr", srv.Addr), zap.Error(err))
+			shutdownErrs = append(shutdownErrs, fmt.Errorf("shutdown server %s: %w", srv.Addr, err), srv.Close())
+		}
+	}
+	stopPurge()
+	purges.Wait()
+
+	shutdownErrs = append(shutdownErrs, a.release())
+	if err := errors.Join(append([]error{runErr}, shutdownErrs...)...); err != nil {
+		return err
+	}
+	a.logger.Info("Stopped successfully")
+	return nil
+}
+
+// release закрывает пул соединений БД и отправляет оставшиеся спаны
+func (a *App) release() error {
+	var errs []error
+	if a.db != nil {
+		if sqlDB, err := a.db.DB(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get sql db: %w", err))
+		} else if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database: %w", err))
+		}
+	}
+	if a.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+		defer cancel()
+		if err := a.shutdownTracing(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush traces: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newVerifier собирает проверку JWT из всех ключей, заданных в конфигурации
+func newVerifier(cfg config.Config) (*auth.Verifier, error) {
+	verifier := auth.NewVerifier(cfg.JWTIssuer, cfg.JWTAudience)
+	if cfg.JWTSecret != "" {
+		verifier.AddHMACKey("", []byte(cfg.JWTSecret))
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		if err := verifier.LoadRSAPublicKeyFile(cfg.JWTPublicKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.JWKSFile != "" {
+		if err := verifier.LoadJWKSFile(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	return verifier, nil
+}
+
+// newPolicy загружает политику доступа из файла или берет встроенную
+func newPolicy(cfg config.Config) (*policy.Policy, error) {
+	if cfg.PolicyFile == "" {
+		return policy.Default()
+	}
+	return policy.LoadFile(cfg.PolicyFile)
 }
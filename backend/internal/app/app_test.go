@@ -0,0 +1,228 @@
+package app
+
+import (
+	"HiTalent_TestTask/backend/config"
+	"HiTalent_TestTask/backend/internal/adapter/repo/memory"
+	"HiTalent_TestTask/backend/internal/cases"
+	"HiTalent_TestTask/backend/internal/policy"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestApp собирает App без БД вокруг заданного обработчика на свободном локальном порту
+func newTestApp(t *testing.T, handler http.Handler, cfg config.Config) (*App, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	accessPolicy, err := policy.Default()
+	require.NoError(t, err)
+	logger := zap.NewNop()
+	a := &App{
+		cfg:             cfg,
+		logger:          logger,
+		trashCase:       cases.NewTrashCase(memory.NewTrashRepo(memory.NewQuestionRepo()), accessPolicy, time.Hour, logger),
+		idempotencyCase: cases.NewIdempotencyCase(memory.NewIdempotencyRepo(), time.Hour, logger),
+		health:          NewHealth(time.Second),
+	}
+	mux := a.healthMux()
+	mux.Handle("/", handler)
+	a.httpServer = a.newHTTPServer(addr, mux)
+	return a, "http://" + addr
+}
+
+// testConfig - таймауты сервера для тестов с заданным временем на остановку
+func testConfig(shutdownTimeout time.Duration) config.Config {
+	return config.Config{
+		HTTPReadTimeout:          time.Second,
+		HTTPWriteTimeout:         time.Second,
+		HTTPIdleTimeout:          time.Second,
+		ShutdownTimeout:          shutdownTimeout,
+		TrashPurgeInterval:       time.Hour,
+		IdempotencyPurgeInterval: time.Hour,
+	}
+}
+
+// waitForServer ждет, пока сервер начнет принимать соединения
+func waitForServer(t *testing.T, url string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRunDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	a, url := newTestApp(t, mux, testConfig(5*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- a.Run(ctx) }()
+	waitForServer(t, url)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	// Запрос, начатый до остановки, завершается, а новые соединения уже не принимаются
+	assert.Equal(t, http.StatusOK, <-status)
+	require.NoError(t, <-runErr)
+	_, err := http.Get(url + "/healthz")
+	assert.Error(t, err)
+}
+
+func TestRunForcesCloseAfterShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	a, url := newTestApp(t, mux, testConfig(50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- a.Run(ctx) }()
+	waitForServer(t, url)
+
+	go func() {
+		if resp, err := http.Get(url + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after shutdown timeout")
+	}
+}
+
+func TestRunReturnsListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	a, _ := newTestApp(t, http.NewServeMux(), testConfig(time.Second))
+	// Порт уже занят, сервер падает сразу - Run возвращает ошибку вместо завершения процесса
+	a.httpServer.Addr = listener.Addr().String()
+	assert.Error(t, a.Run(context.Background()))
+}
+
+// getReadiness запрашивает /readyz и разбирает ответ
+func getReadiness(t *testing.T, handler http.Handler) (int, HealthReport) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	health := NewHealth(50 * time.Millisecond)
+	health.Register("postgres", func(ctx context.Context) error { return nil })
+	health.Register("migrations", func(ctx context.Context) error {
+		return errors.New("database schema is at version 10, expected 11")
+	})
+	// Зависшая проверка прерывается по таймауту
+	health.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	status, report := getReadiness(t, health.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "fail", report.Status)
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, "ok", report.Checks["postgres"].Status)
+	assert.Equal(t, "fail", report.Checks["migrations"].Status)
+	assert.Equal(t, "database schema is at version 10, expected 11", report.Checks["migrations"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	assert.GreaterOrEqual(t, report.Checks["slow"].LatencyMs, float64(50))
+
+	// Повторная регистрация заменяет проверку
+	health.Register("migrations", func(ctx context.Context) error { return nil })
+	health.Register("slow", func(ctx context.Context) error { return nil })
+	status, report = getReadiness(t, health.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", report.Status)
+
+	health.SetShuttingDown()
+	status, report = getReadiness(t, health.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "shutting_down", report.Status)
+
+	// Liveness не зависит от проверок и остановки
+	w := httptest.NewRecorder()
+	health.LivenessHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+}
+
+func TestRunReportsNotReadyDuringShutdownDelay(t *testing.T) {
+	cfg := testConfig(time.Second)
+	cfg.ShutdownDelay = 300 * time.Millisecond
+	a, url := newTestApp(t, http.NewServeMux(), cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- a.Run(ctx) }()
+	waitForServer(t, url)
+
+	// Без keep-alive опрос не оставляет соединений, которых Shutdown ждал бы дольше ShutdownTimeout
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(url + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	// Листенер еще открыт, но трафик на экземпляр больше не направляется
+	assert.Eventually(t, func() bool {
+		resp, err := client.Get(url + "/readyz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, cfg.ShutdownDelay, 10*time.Millisecond)
+	require.NoError(t, <-runErr)
+}
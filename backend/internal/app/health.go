@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck проверяет одну зависимость; ошибка делает приложение неготовым принимать трафик
+type HealthCheck func(ctx context.Context) error
+
+// Health - реестр проверок готовности. /healthz отвечает, пока процесс жив, /readyz выполняет
+// все зарегистрированные проверки и перестает отвечать 200 с началом остановки.
+type Health struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       map[string]HealthCheck
+	shuttingDown atomic.Bool
+}
+
+// NewHealth создает реестр, в котором каждая проверка ограничена timeout
+func NewHealth(timeout time.Duration) *Health {
+	return &Health{
+		timeout: timeout,
+		checks:  make(map[string]HealthCheck),
+	}
+}
+
+// Register добавляет проверку; повторная регистрация имени заменяет прежнюю проверку
+func (h *Health) Register(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetShuttingDown переводит /readyz в состояние неготовности до конца работы процесса
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+const (
+	healthStatusOK           = "ok"
+	healthStatusFail         = "fail"
+	healthStatusShuttingDown = "shutting_down"
+)
+
+// HealthReport - тело ответа /readyz
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Check выполняет все проверки параллельно
+func (h *Health) Check(ctx context.Context) HealthReport {
+	if h.shuttingDown.Load() {
+		return HealthReport{Status: healthStatusShuttingDown}
+	}
+
+	h.mu.RLock()
+	checks := make(map[string]HealthCheck, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	report := HealthReport{Status: healthStatusOK, Checks: make(map[string]HealthCheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Go(func() {
+			result := h.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != healthStatusOK {
+				report.Status = healthStatusFail
+			}
+		})
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	result := HealthCheckResult{
+		Status:    healthStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = healthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivenessHandler отвечает на GET /healthz: процесс запущен и обслуживает запросы
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, HealthReport{Status: healthStatusOK})
+	})
+}
+
+// ReadinessHandler отвечает на GET /readyz: 200, если все проверки прошли, иначе 503
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Check(r.Context())
+		status := http.StatusOK
+		if report.Status != healthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealth(w, status, report)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, status int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
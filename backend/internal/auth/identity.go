@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Identity - аутентифицированный пользователь запроса
+type Identity struct {
+	Subject string   // id пользователя из claim sub
+	Name    string   // отображаемое имя из claim name, может быть пустым
+	Roles   []string // роли из claim roles, права ролей задает политика доступа
+}
+
+func (i Identity) HasRole(role string) bool {
+	return slices.Contains(i.Roles, role)
+}
+
+type identityKey struct{}
+
+// WithIdentity кладет пользователя в контекст запроса
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext возвращает пользователя запроса, ok=false для анонимного запроса
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
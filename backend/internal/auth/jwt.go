@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken - токен не прошел проверку; причина добавляется через обертку
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+// clockSkew - допустимое расхождение часов при проверке exp и nbf
+const clockSkew = 30 * time.Second
+
+// Verifier проверяет JWT, подписанные HS256 или RS256, и извлекает из них Identity.
+// Алгоритм берется из заголовка токена, но подпись проверяется только ключом
+// соответствующего типа, поэтому подмена RS256 на HS256 с публичным ключом невозможна.
+type Verifier struct {
+	hmacKeys map[string][]byte
+	rsaKeys  map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewVerifier создает проверку без ключей. Пустые issuer и audience не проверяются.
+func NewVerifier(issuer string, audience string) *Verifier {
+	return &Verifier{
+		hmacKeys: make(map[string][]byte),
+		rsaKeys:  make(map[string]*rsa.PublicKey),
+		issuer:   issuer,
+		audience: audience,
+		now:      time.Now,
+	}
+}
+
+// AddHMACKey добавляет секрет HS256. kid может быть пустым.
+func (v *Verifier) AddHMACKey(kid string, secret []byte) {
+	v.hmacKeys[kid] = secret
+}
+
+// AddRSAKey добавляет публичный ключ RS256. kid может быть пустым.
+func (v *Verifier) AddRSAKey(kid string, key *rsa.PublicKey) {
+	v.rsaKeys[kid] = key
+}
+
+// HasKeys сообщает, настроен ли хотя бы один ключ
+func (v *Verifier) HasKeys() bool {
+	return len(v.hmacKeys) > 0 || len(v.rsaKeys) > 0
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Name      string          `json:"name"`
+	Roles     []string        `json:"roles"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// Verify проверяет подпись и стандартные claims, возвращает пользователя из токена
+func (v *Verifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return Identity{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{
+		Subject: claims.Subject,
+		Name:    claims.Name,
+		Roles:   claims.Roles,
+	}, nil
+}
+
+func decodeSegment(segment string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (v *Verifier) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	switch header.Alg {
+	case algHS256:
+		for _, secret := range candidates(v.hmacKeys, header.Kid) {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(signingInput))
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return nil
+			}
+		}
+	case algRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		for _, key := range candidates(v.rsaKeys, header.Kid) {
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+}
+
+// candidates возвращает ключ с указанным kid, а без kid - все ключи этого типа
+func candidates[K any](keys map[string]K, kid string) []K {
+	if kid != "" {
+		if key, ok := keys[kid]; ok {
+			return []K{key}
+		}
+		return nil
+	}
+	result := make([]K, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key)
+	}
+	return result
+}
+
+func (v *Verifier) validateClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(clockSkew)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(numericDate(*claims.NotBefore)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !hasAudience(claims.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// hasAudience проверяет claim aud, который может быть строкой или массивом строк
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
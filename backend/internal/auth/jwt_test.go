@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, header map[string]any, claims map[string]any, secret []byte) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, header map[string]any, claims map[string]any, key *rsa.PrivateKey) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "user-1",
+		"name":  "User",
+		"roles": []string{"admin"},
+		"iss":   "issuer",
+		"aud":   []string{"other", "api"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	secret := []byte("secret")
+	verifier := NewVerifier("issuer", "api")
+	verifier.AddHMACKey("", secret)
+
+	identity, err := verifier.Verify(signHS256(t, map[string]any{"alg": "HS256"}, validClaims(), secret))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, "User", identity.Name)
+	assert.True(t, identity.HasRole("admin"))
+}
+
+func TestVerifyRejectsInvalidClaims(t *testing.T) {
+	secret := []byte("secret")
+	verifier := NewVerifier("issuer", "api")
+	verifier.AddHMACKey("", secret)
+
+	tests := map[string]func(claims map[string]any){
+		"expired":        func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"missing exp":    func(c map[string]any) { delete(c, "exp") },
+		"missing sub":    func(c map[string]any) { delete(c, "sub") },
+		"not yet valid":  func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong issuer":   func(c map[string]any) { c["iss"] = "someone" },
+		"wrong audience": func(c map[string]any) { c["aud"] = "other" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			mutate(claims)
+			_, err := verifier.Verify(signHS256(t, map[string]any{"alg": "HS256"}, claims, secret))
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err := verifier.Verify(signHS256(t, map[string]any{"alg": "HS256"}, validClaims(), []byte("other")))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	unsigned := encodeSegment(t, map[string]any{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + "."
+	_, err = verifier.Verify(unsigned)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := map[string]any{"keys": []map[string]any{{
+		"kty": "RSA",
+		"kid": "key-1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	verifier := NewVerifier("", "")
+	require.NoError(t, verifier.LoadJWKSFile(path))
+
+	identity, err := verifier.Verify(signRS256(t, map[string]any{"alg": "RS256", "kid": "key-1"}, validClaims(), key))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", identity.Subject)
+
+	_, err = verifier.Verify(signRS256(t, map[string]any{"alg": "RS256", "kid": "unknown"}, validClaims(), key))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	path := filepath.Join(t.TempDir(), "public.pem")
+	require.NoError(t, os.WriteFile(path, publicPEM, 0o600))
+
+	verifier := NewVerifier("", "")
+	require.NoError(t, verifier.LoadRSAPublicKeyFile(path))
+
+	// Токен HS256, подписанный публичным ключом как секретом, не должен проходить
+	_, err = verifier.Verify(signHS256(t, map[string]any{"alg": "HS256"}, validClaims(), publicPEM))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = verifier.Verify(signRS256(t, map[string]any{"alg": "RS256"}, validClaims(), key))
+	assert.NoError(t, err)
+}
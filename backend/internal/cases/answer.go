@@ -1,55 +1,287 @@
 package cases
 
 import (
+	"HiTalent_TestTask/backend/internal/auth"
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"HiTalent_TestTask/backend/internal/tracing"
+	"HiTalent_TestTask/backend/pkg/diff"
 	"context"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type AnswerCase struct {
 	answerRepo repo.AnswerRepo
+	authorizer service.Authorizer
+	publisher  service.EventPublisher
 	logger     *zap.Logger
 }
 
-func NewAnswerCase(answerRepo repo.AnswerRepo, logger *zap.Logger) *AnswerCase {
+func NewAnswerCase(answerRepo repo.AnswerRepo, authorizer service.Authorizer, publisher service.EventPublisher, logger *zap.Logger) *AnswerCase {
 	return &AnswerCase{
 		answerRepo: answerRepo,
+		authorizer: authorizer,
+		publisher:  publisher,
 		logger:     logger,
 	}
 }
 
-func (a *AnswerCase) CreateAnswer(ctx context.Context, answer *entity.Answer) error {
-	a.logger.Info("Creating answer",
+// CreateAnswer создает ответ от имени пользователя из контекста, user_id из тела игнорируется.
+// Ненулевой version - версия вопроса, которую видел клиент: если вопрос с тех пор изменился,
+// ответ не создается и возвращается errs.ErrPreconditionFailed.
+func (a *AnswerCase) CreateAnswer(ctx context.Context, answer *entity.Answer, version int) error {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.CreateAnswer")
+	defer span.End()
+	if err := a.authorizer.Authorize(ctx, entity.PermissionAnswerCreate, ""); err != nil {
+		return err
+	}
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return errs.Unauthorized("authentication required")
+	}
+	answer.UserId = identity.Subject
+
+	logger.Info("Creating answer",
 		zap.Int("question_id", answer.QuestionId),
 		zap.String("user_id", answer.UserId))
+	if answer.Text == "" {
+		return errs.Validation("text is required")
+	}
+	// Счет набирается только голосами
+	answer.Score = 0
 
-	if err := a.answerRepo.CreateAnswer(ctx, answer); err != nil {
-		a.logger.Error("Failed to create answer", zap.Error(err))
+	if err := a.answerRepo.CreateAnswer(ctx, answer, version); err != nil {
+		logger.Error("Failed to create answer", zap.Error(err))
 		return err
 	}
 
-	a.logger.Info("Answer created successfully", zap.Int("id", answer.ID))
+	a.publisher.Publish(ctx, entity.AnswerCreated{
+		AnswerId:   answer.ID,
+		QuestionId: answer.QuestionId,
+		AuthorId:   answer.UserId,
+		At:         answer.CreatedAt,
+	})
+	logger.Info("Answer created successfully", zap.Int("id", answer.ID))
 	return nil
 }
 
 func (a *AnswerCase) GetAnswer(ctx context.Context, answerId int) (*entity.Answer, error) {
-	a.logger.Info("Getting answer", zap.Int("id", answerId))
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.GetAnswer")
+	defer span.End()
+	logger.Info("Getting answer", zap.Int("id", answerId))
 	answer, err := a.answerRepo.GetAnswer(ctx, answerId)
 	if err != nil {
-		a.logger.Error("Failed to get answer", zap.Int("id", answerId), zap.Error(err))
+		logger.Error("Failed to get answer", zap.Int("id", answerId), zap.Error(err))
 		return nil, err
 	}
 	return answer, nil
 }
 
+func (a *AnswerCase) UpdateAnswer(ctx context.Context, answerId int, text string) (*entity.Answer, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.UpdateAnswer")
+	defer span.End()
+	logger.Info("Updating answer", zap.Int("id", answerId))
+	if _, err := a.authorizeAnswer(ctx, entity.PermissionAnswerUpdate, answerId); err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, errs.Validation("text is required")
+	}
+	return a.saveAnswerText(ctx, answerId, text)
+}
+
+// saveAnswerText сохраняет новый текст ответа следующей версией от имени пользователя из контекста
+func (a *AnswerCase) saveAnswerText(ctx context.Context, answerId int, text string) (*entity.Answer, error) {
+	identity, _ := auth.FromContext(ctx)
+	answer := &entity.Answer{ID: answerId, Text: text}
+	if err := a.answerRepo.UpdateAnswer(ctx, answer, identity.Subject); err != nil {
+		tracing.Logger(ctx, a.logger).Error("Failed to update answer", zap.Int("id", answerId), zap.Error(err))
+		return nil, err
+	}
+	tracing.Logger(ctx, a.logger).Info("Answer updated successfully", zap.Int("id", answerId))
+	return a.answerRepo.GetAnswer(ctx, answerId)
+}
+
+// GetAnswerRevisions возвращает историю правок ответа от первой версии к последней
+func (a *AnswerCase) GetAnswerRevisions(ctx context.Context, answerId int) ([]entity.AnswerRevision, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.GetAnswerRevisions")
+	defer span.End()
+	logger.Info("Getting answer revisions", zap.Int("id", answerId))
+	revisions, err := a.answerRepo.GetAnswerRevisions(ctx, answerId)
+	if err != nil {
+		logger.Error("Failed to get answer revisions", zap.Int("id", answerId), zap.Error(err))
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// DiffAnswerRevision сравнивает версию number с версией from; from 0 - с предыдущей версией.
+// Первая версия сравнивается с пустым текстом.
+func (a *AnswerCase) DiffAnswerRevision(ctx context.Context, answerId int, number int, from int, format entity.DiffFormat) (*entity.RevisionDiff, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.DiffAnswerRevision")
+	defer span.End()
+	logger.Info("Diffing answer revision",
+		zap.Int("id", answerId),
+		zap.Int("number", number),
+		zap.Int("from", from),
+		zap.String("format", string(format)))
+	if format == "" {
+		format = entity.DiffFormatUnified
+	}
+	if format != entity.DiffFormatUnified && format != entity.DiffFormatWord {
+		return nil, errs.Validation("unsupported diff format %q", format)
+	}
+	if from == 0 {
+		from = number - 1
+	}
+	if from < 0 {
+		return nil, errs.Validation("from must be a revision number")
+	}
+
+	to, err := a.answerRepo.GetAnswerRevision(ctx, answerId, number)
+	if err != nil {
+		logger.Error("Failed to get answer revision", zap.Int("id", answerId), zap.Int("number", number), zap.Error(err))
+		return nil, err
+	}
+	base := &entity.AnswerRevision{}
+	if from > 0 {
+		if base, err = a.answerRepo.GetAnswerRevision(ctx, answerId, from); err != nil {
+			logger.Error("Failed to get answer revision", zap.Int("id", answerId), zap.Int("number", from), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	result := &entity.RevisionDiff{AnswerId: answerId, From: from, To: number, Format: format}
+	if format == entity.DiffFormatWord {
+		result.Diff = diff.Words(base.Text, to.Text)
+	} else {
+		result.Diff = diff.Unified(base.Text, to.Text, fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", number), 3)
+	}
+	return result, nil
+}
+
+// RollbackAnswer возвращает ответу текст версии number. Откат - обычная правка и создает новую версию.
+func (a *AnswerCase) RollbackAnswer(ctx context.Context, answerId int, number int) (*entity.Answer, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.RollbackAnswer")
+	defer span.End()
+	logger.Info("Rolling back answer", zap.Int("id", answerId), zap.Int("number", number))
+	if _, err := a.authorizeAnswer(ctx, entity.PermissionAnswerUpdate, answerId); err != nil {
+		return nil, err
+	}
+	revision, err := a.answerRepo.GetAnswerRevision(ctx, answerId, number)
+	if err != nil {
+		logger.Error("Failed to get answer revision", zap.Int("id", answerId), zap.Int("number", number), zap.Error(err))
+		return nil, err
+	}
+	return a.saveAnswerText(ctx, answerId, revision.Text)
+}
+
 func (a *AnswerCase) DeleteAnswer(ctx context.Context, answerId int) error {
-	a.logger.Info("Deleting answer", zap.Int("id", answerId))
-	if err := a.answerRepo.DeleteAnswer(ctx, answerId); err != nil {
-		a.logger.Error("Failed to delete answer", zap.Int("id", answerId), zap.Error(err))
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.DeleteAnswer")
+	defer span.End()
+	logger.Info("Deleting answer", zap.Int("id", answerId))
+	answer, err := a.authorizeAnswer(ctx, entity.PermissionAnswerDelete, answerId)
+	if err != nil {
 		return err
 	}
-	a.logger.Info("Answer deleted successfully", zap.Int("id", answerId))
+	unaccepted, err := a.answerRepo.DeleteAnswer(ctx, answerId)
+	if err != nil {
+		logger.Error("Failed to delete answer", zap.Int("id", answerId), zap.Error(err))
+		return err
+	}
+	// Снятие отметки публикуется первым, чтобы подписчики видели его до удаления ответа
+	if unaccepted != nil {
+		a.publisher.Publish(ctx, *unaccepted)
+	}
+	a.publisher.Publish(ctx, entity.AnswerDeleted{AnswerId: answerId, QuestionId: answer.QuestionId, At: time.Now()})
+	logger.Info("Answer deleted successfully", zap.Int("id", answerId))
 	return nil
 }
+
+// RestoreAnswer возвращает ответ из корзины
+func (a *AnswerCase) RestoreAnswer(ctx context.Context, answerId int) (*entity.Answer, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.RestoreAnswer")
+	defer span.End()
+	logger.Info("Restoring answer", zap.Int("id", answerId))
+	if err := a.authorizer.Authorize(ctx, entity.PermissionAnswerRestore, ""); err != nil {
+		return nil, err
+	}
+	if err := a.answerRepo.RestoreAnswer(ctx, answerId); err != nil {
+		logger.Error("Failed to restore answer", zap.Int("id", answerId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Answer restored successfully", zap.Int("id", answerId))
+	answer, err := a.answerRepo.GetAnswer(ctx, answerId)
+	if err != nil {
+		return nil, err
+	}
+	a.publisher.Publish(ctx, entity.AnswerRestored{AnswerId: answerId, QuestionId: answer.QuestionId, At: time.Now()})
+	return answer, nil
+}
+
+// VoteAnswer ставит, меняет (value 1 или -1) или снимает (value 0) голос пользователя из контекста
+func (a *AnswerCase) VoteAnswer(ctx context.Context, answerId int, value int) (*entity.Answer, error) {
+	ctx, span, logger := startSpan(ctx, a.logger, "AnswerCase.VoteAnswer")
+	defer span.End()
+	if err := a.authorizer.Authorize(ctx, entity.PermissionAnswerVote, ""); err != nil {
+		return nil, err
+	}
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, errs.Unauthorized("authentication required")
+	}
+	userId := identity.Subject
+
+	logger.Info("Voting for answer",
+		zap.Int("id", answerId),
+		zap.String("user_id", userId),
+		zap.Int("value", value))
+	if value < -1 || value > 1 {
+		return nil, errs.Validation("vote value must be -1, 0 or 1")
+	}
+
+	vote := &entity.AnswerVote{AnswerId: answerId, UserId: userId, Value: value}
+	previous, err := a.answerRepo.VoteAnswer(ctx, vote)
+	if err != nil {
+		logger.Error("Failed to vote for answer", zap.Int("id", answerId), zap.Error(err))
+		return nil, err
+	}
+	answer, err := a.answerRepo.GetAnswer(ctx, answerId)
+	if err != nil {
+		return nil, err
+	}
+	if previous != value {
+		a.publisher.Publish(ctx, entity.AnswerVoted{
+			AnswerId:   answerId,
+			QuestionId: answer.QuestionId,
+			AuthorId:   answer.UserId,
+			VoterId:    userId,
+			Previous:   previous,
+			Value:      value,
+			At:         time.Now(),
+		})
+	}
+	return answer, nil
+}
+
+// authorizeAnswer проверяет право на действие над ответом с учетом его автора и возвращает ответ
+func (a *AnswerCase) authorizeAnswer(ctx context.Context, permission entity.Permission, answerId int) (*entity.Answer, error) {
+	answer, err := a.answerRepo.GetAnswer(ctx, answerId)
+	if err != nil {
+		tracing.Logger(ctx, a.logger).Error("Failed to get answer", zap.Int("id", answerId), zap.Error(err))
+		return nil, err
+	}
+	if err := a.authorizer.Authorize(ctx, permission, answer.UserId); err != nil {
+		tracing.Logger(ctx, a.logger).Info("Answer action denied",
+			zap.Int("id", answerId),
+			zap.String("permission", string(permission)),
+			zap.Error(err))
+		return nil, err
+	}
+	return answer, nil
+}
@@ -0,0 +1,139 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type CommentCase struct {
+	commentRepo repo.CommentRepo
+	authorizer  service.Authorizer
+	publisher   service.EventPublisher
+	maxDepth    int
+	logger      *zap.Logger
+}
+
+// NewCommentCase создает комментарии, в которых на комментарий можно отвечать до глубины maxDepth
+func NewCommentCase(commentRepo repo.CommentRepo, authorizer service.Authorizer, publisher service.EventPublisher, maxDepth int, logger *zap.Logger) *CommentCase {
+	return &CommentCase{
+		commentRepo: commentRepo,
+		authorizer:  authorizer,
+		publisher:   publisher,
+		maxDepth:    maxDepth,
+		logger:      logger,
+	}
+}
+
+// CreateComment добавляет комментарий к ответу от имени пользователя из контекста; parentId - ответ на комментарий
+func (c *CommentCase) CreateComment(ctx context.Context, answerId int, parentId *int, text string) (*entity.Comment, error) {
+	ctx, span, logger := startSpan(ctx, c.logger, "CommentCase.CreateComment")
+	defer span.End()
+	if err := c.authorizer.Authorize(ctx, entity.PermissionCommentCreate, ""); err != nil {
+		return nil, err
+	}
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, errs.Unauthorized("authentication required")
+	}
+
+	logger.Info("Creating comment",
+		zap.Int("answer_id", answerId),
+		zap.String("user_id", identity.Subject))
+	if text == "" {
+		return nil, errs.Validation("text is required")
+	}
+
+	comment := &entity.Comment{AnswerId: answerId, ParentId: parentId, UserId: identity.Subject, Text: text}
+	if parentId != nil {
+		parent, err := c.commentRepo.GetComment(ctx, *parentId)
+		if err != nil {
+			logger.Error("Failed to get parent comment", zap.Int("id", *parentId), zap.Error(err))
+			return nil, err
+		}
+		if parent.AnswerId != answerId {
+			return nil, errs.Validation("comment %d does not belong to answer %d", *parentId, answerId)
+		}
+		if parent.Depth >= c.maxDepth {
+			return nil, errs.Validation("comment thread is limited to depth %d", c.maxDepth)
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := c.commentRepo.CreateComment(ctx, comment); err != nil {
+		logger.Error("Failed to create comment", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Comment created successfully", zap.Int("id", comment.Id))
+	return comment, nil
+}
+
+// GetComments возвращает комментарии ответа ветками
+func (c *CommentCase) GetComments(ctx context.Context, answerId int) ([]entity.Comment, error) {
+	ctx, span, logger := startSpan(ctx, c.logger, "CommentCase.GetComments")
+	defer span.End()
+	logger.Info("Getting comments", zap.Int("answer_id", answerId))
+	comments, err := c.commentRepo.GetAnswerComments(ctx, answerId)
+	if err != nil {
+		logger.Error("Failed to get comments", zap.Int("answer_id", answerId), zap.Error(err))
+		return nil, err
+	}
+	return buildThreads(comments), nil
+}
+
+// DeleteComment удаляет комментарий ответа вместе с ответами на него
+func (c *CommentCase) DeleteComment(ctx context.Context, answerId int, commentId int) error {
+	ctx, span, logger := startSpan(ctx, c.logger, "CommentCase.DeleteComment")
+	defer span.End()
+	logger.Info("Deleting comment", zap.Int("answer_id", answerId), zap.Int("id", commentId))
+	comment, err := c.commentRepo.GetComment(ctx, commentId)
+	if err != nil {
+		logger.Error("Failed to get comment", zap.Int("id", commentId), zap.Error(err))
+		return err
+	}
+	if comment.AnswerId != answerId {
+		return errs.NotFound("comment %d not found in answer %d", commentId, answerId)
+	}
+	if err := c.authorizer.Authorize(ctx, entity.PermissionCommentDelete, comment.UserId); err != nil {
+		return err
+	}
+
+	if err := c.commentRepo.DeleteComment(ctx, commentId); err != nil {
+		logger.Error("Failed to delete comment", zap.Int("id", commentId), zap.Error(err))
+		return err
+	}
+	c.publisher.Publish(ctx, entity.CommentDeleted{CommentId: commentId, AnswerId: answerId, At: time.Now()})
+	logger.Info("Comment deleted successfully", zap.Int("id", commentId))
+	return nil
+}
+
+// buildThreads собирает плоский список комментариев по возрастанию id в деревья
+func buildThreads(comments []entity.Comment) []entity.Comment {
+	children := make(map[int][]entity.Comment)
+	roots := []entity.Comment{}
+	for _, comment := range comments {
+		if comment.ParentId == nil {
+			roots = append(roots, comment)
+		} else {
+			children[*comment.ParentId] = append(children[*comment.ParentId], comment)
+		}
+	}
+
+	var attach func(comment entity.Comment) entity.Comment
+	attach = func(comment entity.Comment) entity.Comment {
+		for _, reply := range children[comment.Id] {
+			comment.Replies = append(comment.Replies, attach(reply))
+		}
+		return comment
+	}
+	for i := range roots {
+		roots[i] = attach(roots[i])
+	}
+	return roots
+}
@@ -0,0 +1,50 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/errs"
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// pageCursor - содержимое непрозрачного курсора пагинации
+type pageCursor struct {
+	LastId int `json:"last_id"`
+}
+
+// encodeCursor кодирует позицию последнего элемента страницы в непрозрачный токен
+func encodeCursor(lastId int) string {
+	data, _ := json.Marshal(pageCursor{LastId: lastId})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает токен, пустой курсор означает первую страницу
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errs.Validation("invalid cursor")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.LastId <= 0 {
+		return 0, errs.Validation("invalid cursor")
+	}
+	return c.LastId, nil
+}
+
+// normalizeLimit подставляет лимит по умолчанию и ограничивает максимальный
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
@@ -0,0 +1,132 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyCase делает повторы запросов создания безопасными: запрос с ключом Idempotency-Key
+// выполняется один раз, повторы получают сохраненный ответ. Ключи принадлежат пользователю токена,
+// анонимные запросы делят одно пространство ключей.
+type IdempotencyCase struct {
+	idempotencyRepo repo.IdempotencyRepo
+	ttl             time.Duration
+	logger          *zap.Logger
+}
+
+// NewIdempotencyCase создает хранилище ответов, в котором ключ действует ttl с первого запроса
+func NewIdempotencyCase(idempotencyRepo repo.IdempotencyRepo, ttl time.Duration, logger *zap.Logger) *IdempotencyCase {
+	return &IdempotencyCase{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		logger:          logger,
+	}
+}
+
+// idempotencyOwner возвращает владельца ключей запроса
+func idempotencyOwner(ctx context.Context) string {
+	identity, _ := auth.FromContext(ctx)
+	return identity.Subject
+}
+
+// Begin резервирует ключ перед выполнением запроса с отпечатком fingerprint. Если запрос с этим ключом
+// уже выполнен, возвращает запись с его ответом, и запрос выполнять не нужно. Тот же ключ с другим
+// запросом или повтор, пока первый запрос еще выполняется, - конфликт.
+func (i *IdempotencyCase) Begin(ctx context.Context, key string, fingerprint string) (*entity.IdempotencyRecord, error) {
+	ctx, span, logger := startSpan(ctx, i.logger, "IdempotencyCase.Begin")
+	defer span.End()
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, errs.Validation("idempotency key must be at most %d bytes", maxIdempotencyKeyLength)
+	}
+
+	now := time.Now()
+	record := &entity.IdempotencyRecord{
+		UserId:      idempotencyOwner(ctx),
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.ttl),
+	}
+	stored, err := i.idempotencyRepo.ReserveKey(ctx, record)
+	if err != nil {
+		logger.Error("Failed to reserve idempotency key", zap.Error(err))
+		return nil, err
+	}
+	if stored == nil {
+		return nil, nil
+	}
+
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return nil, errs.Conflict("idempotency key %q was already used with a different request", key)
+	case !stored.Completed():
+		return nil, errs.Conflict("request with idempotency key %q is still in progress", key)
+	}
+	logger.Info("Replaying idempotent response", zap.String("key", key), zap.Int("status", stored.StatusCode))
+	return stored, nil
+}
+
+// Complete сохраняет ответ на запрос, ключ которого зарезервировал Begin
+func (i *IdempotencyCase) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	ctx, span, logger := startSpan(ctx, i.logger, "IdempotencyCase.Complete")
+	defer span.End()
+	err := i.idempotencyRepo.CompleteKey(ctx, &entity.IdempotencyRecord{
+		UserId:      idempotencyOwner(ctx),
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+	})
+	if err != nil {
+		logger.Error("Failed to save idempotent response", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Release освобождает ключ запроса, который не удалось выполнить, чтобы клиент мог его повторить
+func (i *IdempotencyCase) Release(ctx context.Context, key string) error {
+	ctx, span, logger := startSpan(ctx, i.logger, "IdempotencyCase.Release")
+	defer span.End()
+	if err := i.idempotencyRepo.ReleaseKey(ctx, idempotencyOwner(ctx), key); err != nil {
+		logger.Error("Failed to release idempotency key", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Purge удаляет истекшие ключи
+func (i *IdempotencyCase) Purge(ctx context.Context) (int, error) {
+	ctx, span, logger := startSpan(ctx, i.logger, "IdempotencyCase.Purge")
+	defer span.End()
+	purged, err := i.idempotencyRepo.PurgeKeys(ctx, time.Now())
+	if err != nil {
+		logger.Error("Failed to purge idempotency keys", zap.Error(err))
+		return 0, err
+	}
+	logger.Info("Idempotency keys purged successfully", zap.Int("keys", purged))
+	return purged, nil
+}
+
+// RunPurge удаляет истекшие ключи с периодом interval, пока не отменен ctx
+func (i *IdempotencyCase) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Ошибка уже залогирована, следующая попытка будет на следующем тике
+		_, _ = i.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,61 +1,471 @@
 package cases
 
 import (
+	"HiTalent_TestTask/backend/internal/auth"
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
 	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"HiTalent_TestTask/backend/internal/tracing"
 	"context"
+	"sort"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type QuestionCase struct {
 	questionRepo repo.QuestionRepo
+	tagRepo      repo.TagRepo
+	commentRepo  repo.CommentRepo
+	authorizer   service.Authorizer
+	publisher    service.EventPublisher
 	logger       *zap.Logger
 }
 
-func NewQuestionCase(questionRepo repo.QuestionRepo, logger *zap.Logger) *QuestionCase {
+func NewQuestionCase(questionRepo repo.QuestionRepo, tagRepo repo.TagRepo, commentRepo repo.CommentRepo, authorizer service.Authorizer, publisher service.EventPublisher, logger *zap.Logger) *QuestionCase {
 	return &QuestionCase{
 		questionRepo: questionRepo,
+		tagRepo:      tagRepo,
+		commentRepo:  commentRepo,
+		authorizer:   authorizer,
+		publisher:    publisher,
 		logger:       logger,
 	}
 }
 
-func (q *QuestionCase) GetQuestionList(ctx context.Context) (*[]entity.Question, error) {
-	q.logger.Info("Getting question list")
-	questions, err := q.questionRepo.GetQuestionList(ctx)
+// GetQuestionList возвращает страницу вопросов; непустой tags оставляет вопросы со всеми
+// (tagMode all, по умолчанию) или хотя бы одним (any) из тегов. Теги можно указывать синонимами.
+func (q *QuestionCase) GetQuestionList(ctx context.Context, limit int, cursor string, tags []string, tagMode entity.TagMode) (*entity.Page[entity.Question], error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.GetQuestionList")
+	defer span.End()
+	logger.Info("Getting question list",
+		zap.Int("limit", limit),
+		zap.String("cursor", cursor),
+		zap.Strings("tags", tags),
+		zap.String("tag_mode", string(tagMode)))
+	afterId, err := decodeCursor(cursor)
 	if err != nil {
-		q.logger.Error("Failed to get question list", zap.Error(err))
 		return nil, err
 	}
-	return questions, nil
+	limit = normalizeLimit(limit)
+
+	tagIds, matchable, err := q.tagFilter(ctx, tags, tagMode)
+	if err != nil {
+		return nil, err
+	}
+	if !matchable {
+		return &entity.Page[entity.Question]{Items: []entity.Question{}}, nil
+	}
+	if tagMode == "" {
+		tagMode = entity.TagModeAll
+	}
+
+	// Запрашиваем на один элемент больше, чтобы понять, есть ли следующая страница
+	questions, err := q.questionRepo.GetQuestionList(ctx, repo.QuestionListFilter{
+		AfterId: afterId,
+		Limit:   limit + 1,
+		TagIds:  tagIds,
+		TagMode: tagMode,
+	})
+	if err != nil {
+		logger.Error("Failed to get question list", zap.Error(err))
+		return nil, err
+	}
+
+	page := &entity.Page[entity.Question]{Items: *questions}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = encodeCursor(page.Items[limit-1].Id)
+	}
+	return page, nil
+}
+
+// tagFilter переводит имена тегов фильтра в id. matchable false значит, что фильтру
+// заведомо не соответствует ни один вопрос: неизвестный тег в режиме all или только неизвестные в режиме any.
+func (q *QuestionCase) tagFilter(ctx context.Context, tags []string, tagMode entity.TagMode) ([]int, bool, error) {
+	if tagMode != "" && tagMode != entity.TagModeAll && tagMode != entity.TagModeAny {
+		return nil, false, errs.Validation("unsupported tag mode %q", tagMode)
+	}
+	names, err := normalizeTagNames(tags)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(names) == 0 {
+		return nil, true, nil
+	}
+
+	resolved, err := q.tagRepo.ResolveTags(ctx, names)
+	if err != nil {
+		tracing.Logger(ctx, q.logger).Error("Failed to resolve tags", zap.Error(err))
+		return nil, false, err
+	}
+	var tagIds []int
+	seen := make(map[int]bool, len(names))
+	for _, name := range names {
+		tag, ok := resolved[name]
+		if !ok {
+			if tagMode != entity.TagModeAny {
+				return nil, false, nil
+			}
+			continue
+		}
+		// Тег и его синоним - один и тот же тег
+		if !seen[tag.Id] {
+			seen[tag.Id] = true
+			tagIds = append(tagIds, tag.Id)
+		}
+	}
+	return tagIds, len(tagIds) > 0, nil
+}
+
+// resolveQuestionTags заменяет имена тегов вопроса существующими тегами; неизвестный тег - ошибка валидации
+func (q *QuestionCase) resolveQuestionTags(ctx context.Context, tags []entity.Tag) ([]entity.Tag, error) {
+	raw := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		raw = append(raw, tag.Name)
+	}
+	names, err := normalizeTagNames(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > maxQuestionTags {
+		return nil, errs.Validation("question can have at most %d tags", maxQuestionTags)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	resolved, err := q.tagRepo.ResolveTags(ctx, names)
+	if err != nil {
+		tracing.Logger(ctx, q.logger).Error("Failed to resolve tags", zap.Error(err))
+		return nil, err
+	}
+	result := make([]entity.Tag, 0, len(names))
+	seen := make(map[int]bool, len(names))
+	for _, name := range names {
+		tag, ok := resolved[name]
+		if !ok {
+			return nil, errs.Validation("unknown tag %q", name)
+		}
+		if !seen[tag.Id] {
+			seen[tag.Id] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
 }
 
 func (q *QuestionCase) CreateQuestion(ctx context.Context, question *entity.Question) error {
-	q.logger.Info("Creating question", zap.String("text", question.Text))
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.CreateQuestion")
+	defer span.End()
+	logger.Info("Creating question", zap.String("text", question.Text))
+	if err := q.authorizer.Authorize(ctx, entity.PermissionQuestionCreate, ""); err != nil {
+		return err
+	}
+	if question.Text == "" {
+		return errs.Validation("text is required")
+	}
+	// Принятый ответ выбирается только через AcceptAnswer
+	question.AcceptedAnswerId = nil
+	// Автор - пользователь запроса, анонимный вопрос остается без автора
+	question.AuthorId = nil
+	if identity, ok := auth.FromContext(ctx); ok {
+		question.AuthorId = &identity.Subject
+	}
+	tags, err := q.resolveQuestionTags(ctx, question.Tags)
+	if err != nil {
+		return err
+	}
+	question.Tags = tags
 	if err := q.questionRepo.CreateQuestion(ctx, question); err != nil {
-		q.logger.Error("Failed to create question", zap.Error(err))
+		logger.Error("Failed to create question", zap.Error(err))
 		return err
 	}
-	q.logger.Info("Question created successfully", zap.Int("id", question.Id))
+	q.publisher.Publish(ctx, entity.QuestionCreated{
+		QuestionId: question.Id,
+		AuthorId:   questionAuthor(question),
+		At:         question.CreatedAt,
+	})
+	logger.Info("Question created successfully", zap.Int("id", question.Id))
 	return nil
 }
 
-func (q *QuestionCase) GetQuestion(ctx context.Context, questionId int) (*entity.Question, error) {
-	q.logger.Info("Getting question", zap.Int("id", questionId))
+// GetQuestion возвращает вопрос с ответами в заданном порядке, пустой порядок - от старых к новым.
+// withComments добавляет к ответам ветки комментариев.
+func (q *QuestionCase) GetQuestion(ctx context.Context, questionId int, answerSort entity.AnswerSort, withComments bool) (*entity.Question, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.GetQuestion")
+	defer span.End()
+	logger.Info("Getting question",
+		zap.Int("id", questionId),
+		zap.String("sort", string(answerSort)),
+		zap.Bool("comments", withComments))
+	less, err := answerLess(answerSort)
+	if err != nil {
+		return nil, err
+	}
+
 	question, err := q.questionRepo.GetQuestion(ctx, questionId)
 	if err != nil {
-		q.logger.Error("Failed to get question", zap.Int("id", questionId), zap.Error(err))
+		logger.Error("Failed to get question", zap.Int("id", questionId), zap.Error(err))
 		return nil, err
 	}
+	sort.SliceStable(question.Answers, func(i, j int) bool {
+		return less(&question.Answers[i], &question.Answers[j])
+	})
+
+	if withComments {
+		comments, err := q.commentRepo.GetQuestionComments(ctx, questionId)
+		if err != nil {
+			logger.Error("Failed to get comments", zap.Int("id", questionId), zap.Error(err))
+			return nil, err
+		}
+		byAnswer := make(map[int][]entity.Comment)
+		for _, comment := range comments {
+			byAnswer[comment.AnswerId] = append(byAnswer[comment.AnswerId], comment)
+		}
+		for i := range question.Answers {
+			question.Answers[i].Comments = buildThreads(byAnswer[question.Answers[i].ID])
+		}
+	}
 	return question, nil
 }
 
-func (q *QuestionCase) DeleteQuestion(ctx context.Context, questionId int) error {
-	q.logger.Info("Deleting question", zap.Int("id", questionId))
-	if err := q.questionRepo.DeleteQuestion(ctx, questionId); err != nil {
-		q.logger.Error("Failed to delete question", zap.Int("id", questionId), zap.Error(err))
+// answerLess возвращает функцию сравнения ответов для порядка сортировки
+func answerLess(answerSort entity.AnswerSort) (func(a, b *entity.Answer) bool, error) {
+	oldest := func(a, b *entity.Answer) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+
+	switch answerSort {
+	case "", entity.AnswerSortOldest:
+		return oldest, nil
+	case entity.AnswerSortNewest:
+		return func(a, b *entity.Answer) bool {
+			return oldest(b, a)
+		}, nil
+	case entity.AnswerSortScore:
+		return func(a, b *entity.Answer) bool {
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			return oldest(a, b)
+		}, nil
+	default:
+		return nil, errs.Validation("unsupported answer sort %q", answerSort)
+	}
+}
+
+// GetQuestionVersion возвращает версию вопроса, не загружая ответы: по ней проверяются
+// условные запросы, пока вопрос не изменился
+func (q *QuestionCase) GetQuestionVersion(ctx context.Context, questionId int) (entity.QuestionVersion, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.GetQuestionVersion")
+	defer span.End()
+	version, err := q.questionRepo.GetQuestionVersion(ctx, questionId)
+	if err != nil {
+		logger.Error("Failed to get question version", zap.Int("id", questionId), zap.Error(err))
+		return entity.QuestionVersion{}, err
+	}
+	return version, nil
+}
+
+// UpdateQuestion меняет текст вопроса. Ненулевой version - версия, которую видел клиент:
+// если вопрос с тех пор изменился, правка отклоняется с errs.ErrPreconditionFailed.
+func (q *QuestionCase) UpdateQuestion(ctx context.Context, questionId int, text string, version int) (*entity.Question, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.UpdateQuestion")
+	defer span.End()
+	logger.Info("Updating question", zap.Int("id", questionId), zap.Int("version", version))
+	if _, err := q.authorizeQuestion(ctx, entity.PermissionQuestionUpdate, questionId); err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, errs.Validation("text is required")
+	}
+	question := &entity.Question{Id: questionId, Text: text, Version: version}
+	if err := q.questionRepo.UpdateQuestion(ctx, question); err != nil {
+		logger.Error("Failed to update question", zap.Int("id", questionId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Question updated successfully", zap.Int("id", questionId))
+	return q.questionRepo.GetQuestion(ctx, questionId)
+}
+
+// AcceptAnswer отмечает ответ как принятое решение вопроса.
+// Ответ должен принадлежать вопросу; ранее принятый ответ заменяется, так что принятым всегда остается один.
+// version проверяется как в UpdateQuestion.
+func (q *QuestionCase) AcceptAnswer(ctx context.Context, questionId int, answerId int, version int) (*entity.Question, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.AcceptAnswer")
+	defer span.End()
+	logger.Info("Accepting answer", zap.Int("question_id", questionId), zap.Int("answer_id", answerId), zap.Int("version", version))
+	question, err := q.authorizeQuestion(ctx, entity.PermissionQuestionAccept, questionId)
+	if err != nil {
+		return nil, err
+	}
+
+	authorId, belongs := answerAuthor(question, answerId)
+	if !belongs {
+		return nil, errs.Validation("answer %d does not belong to question %d", answerId, questionId)
+	}
+
+	previous, err := q.questionRepo.SetAcceptedAnswer(ctx, questionId, &answerId, version)
+	if err != nil {
+		logger.Error("Failed to accept answer", zap.Int("question_id", questionId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Answer accepted successfully", zap.Int("question_id", questionId), zap.Int("answer_id", answerId))
+
+	// События только при смене отметки: прежний ответ берется из того же изменения,
+	// поэтому параллельные вызовы не начислят очки дважды
+	if previous == nil || *previous != answerId {
+		now := time.Now()
+		q.publishUnaccepted(ctx, question, previous, now)
+		q.publisher.Publish(ctx, entity.AnswerAccepted{
+			QuestionId:       questionId,
+			AnswerId:         answerId,
+			AnswerAuthorId:   authorId,
+			QuestionAuthorId: questionAuthor(question),
+			At:               now,
+		})
+	}
+	return q.questionRepo.GetQuestion(ctx, questionId)
+}
+
+// UnacceptAnswer снимает отметку о принятом ответе; version проверяется как в UpdateQuestion
+func (q *QuestionCase) UnacceptAnswer(ctx context.Context, questionId int, version int) (*entity.Question, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.UnacceptAnswer")
+	defer span.End()
+	logger.Info("Unaccepting answer", zap.Int("question_id", questionId), zap.Int("version", version))
+	question, err := q.authorizeQuestion(ctx, entity.PermissionQuestionAccept, questionId)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := q.questionRepo.SetAcceptedAnswer(ctx, questionId, nil, version)
+	if err != nil {
+		logger.Error("Failed to unaccept answer", zap.Int("question_id", questionId), zap.Error(err))
+		return nil, err
+	}
+	q.publishUnaccepted(ctx, question, previous, time.Now())
+	return q.questionRepo.GetQuestion(ctx, questionId)
+}
+
+// DeleteQuestion переносит вопрос с ответами в корзину; version проверяется как в UpdateQuestion
+func (q *QuestionCase) DeleteQuestion(ctx context.Context, questionId int, version int) error {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.DeleteQuestion")
+	defer span.End()
+	logger.Info("Deleting question", zap.Int("id", questionId), zap.Int("version", version))
+	if _, err := q.authorizeQuestion(ctx, entity.PermissionQuestionDelete, questionId); err != nil {
+		return err
+	}
+	answerIds, err := q.questionRepo.DeleteQuestion(ctx, questionId, version)
+	if err != nil {
+		logger.Error("Failed to delete question", zap.Int("id", questionId), zap.Error(err))
+		return err
+	}
+	q.publisher.Publish(ctx, entity.QuestionDeleted{QuestionId: questionId, AnswerIds: answerIds, At: time.Now()})
+	logger.Info("Question deleted successfully", zap.Int("id", questionId))
+	return nil
+}
+
+// publishUnaccepted сообщает о снятии отметки с ответа previous, который был принят в question; nil - отметки не было
+func (q *QuestionCase) publishUnaccepted(ctx context.Context, question *entity.Question, previous *int, at time.Time) {
+	if previous == nil {
+		return
+	}
+	authorId, ok := answerAuthor(question, *previous)
+	if !ok {
+		// Ответ добавили и приняли параллельно, после того как вопрос был прочитан
+		current, err := q.questionRepo.GetQuestion(ctx, question.Id)
+		if err != nil {
+			tracing.Logger(ctx, q.logger).Error("Failed to get question", zap.Int("id", question.Id), zap.Error(err))
+			return
+		}
+		if authorId, ok = answerAuthor(current, *previous); !ok {
+			return
+		}
+	}
+	q.publisher.Publish(ctx, entity.AnswerUnaccepted{
+		QuestionId:       question.Id,
+		AnswerId:         *previous,
+		AnswerAuthorId:   authorId,
+		QuestionAuthorId: questionAuthor(question),
+		At:               at,
+	})
+}
+
+// answerAuthor находит автора ответа среди ответов вопроса
+func answerAuthor(question *entity.Question, answerId int) (string, bool) {
+	for _, answer := range question.Answers {
+		if answer.ID == answerId {
+			return answer.UserId, true
+		}
+	}
+	return "", false
+}
+
+func questionAuthor(question *entity.Question) string {
+	if question.AuthorId == nil {
+		return ""
+	}
+	return *question.AuthorId
+}
+
+// authorizeQuestion проверяет право на действие с вопросом с учетом его автора и возвращает вопрос
+func (q *QuestionCase) authorizeQuestion(ctx context.Context, permission entity.Permission, questionId int) (*entity.Question, error) {
+	question, err := q.questionRepo.GetQuestion(ctx, questionId)
+	if err != nil {
+		tracing.Logger(ctx, q.logger).Error("Failed to get question", zap.Int("id", questionId), zap.Error(err))
+		return nil, err
+	}
+	if err := q.authorizer.Authorize(ctx, permission, questionAuthor(question)); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// RestoreQuestion возвращает вопрос из корзины вместе с ответами, удаленными вместе с ним
+func (q *QuestionCase) RestoreQuestion(ctx context.Context, questionId int) (*entity.Question, error) {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.RestoreQuestion")
+	defer span.End()
+	logger.Info("Restoring question", zap.Int("id", questionId))
+	if err := q.authorizer.Authorize(ctx, entity.PermissionQuestionRestore, ""); err != nil {
+		return nil, err
+	}
+	if err := q.questionRepo.RestoreQuestion(ctx, questionId); err != nil {
+		logger.Error("Failed to restore question", zap.Int("id", questionId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Question restored successfully", zap.Int("id", questionId))
+	question, err := q.questionRepo.GetQuestion(ctx, questionId)
+	if err != nil {
+		return nil, err
+	}
+	// Пока вопрос в корзине, ответы к нему не добавляются и не восстанавливаются,
+	// поэтому все его ответы - восстановленные вместе с ним
+	answerIds := make([]int, 0, len(question.Answers))
+	for _, answer := range question.Answers {
+		answerIds = append(answerIds, answer.ID)
+	}
+	q.publisher.Publish(ctx, entity.QuestionRestored{QuestionId: questionId, AnswerIds: answerIds, At: time.Now()})
+	return question, nil
+}
+
+// PurgeQuestion окончательно удаляет вопрос с ответами, минуя корзину; version проверяется как в UpdateQuestion
+func (q *QuestionCase) PurgeQuestion(ctx context.Context, questionId int, version int) error {
+	ctx, span, logger := startSpan(ctx, q.logger, "QuestionCase.PurgeQuestion")
+	defer span.End()
+	logger.Info("Purging question", zap.Int("id", questionId), zap.Int("version", version))
+	if err := q.authorizer.Authorize(ctx, entity.PermissionQuestionHardDelete, ""); err != nil {
+		return err
+	}
+	answerIds, err := q.questionRepo.PurgeQuestion(ctx, questionId, version)
+	if err != nil {
+		logger.Error("Failed to purge question", zap.Int("id", questionId), zap.Error(err))
 		return err
 	}
-	q.logger.Info("Question deleted successfully", zap.Int("id", questionId))
+	q.publisher.Publish(ctx, entity.QuestionDeleted{QuestionId: questionId, AnswerIds: answerIds, Hard: true, At: time.Now()})
+	logger.Info("Question purged successfully", zap.Int("id", questionId))
 	return nil
 }
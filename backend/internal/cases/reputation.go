@@ -0,0 +1,220 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/tracing"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReputationCase начисляет репутацию по доменным событиям голосования и выбора принятого ответа.
+// Одни и те же правила применяются к событиям по мере их появления и при полном пересчете,
+// поэтому пересчет дает ту же сумму, что и накопленный журнал. Удаление и восстановление ответов
+// и вопросов не меняют голоса, поэтому по ним очки ответов сверяются с текущим состоянием.
+type ReputationCase struct {
+	reputationRepo repo.ReputationRepo
+	weights        entity.ReputationWeights
+	logger         *zap.Logger
+}
+
+func NewReputationCase(reputationRepo repo.ReputationRepo, weights entity.ReputationWeights, logger *zap.Logger) *ReputationCase {
+	return &ReputationCase{
+		reputationRepo: reputationRepo,
+		weights:        weights,
+		logger:         logger,
+	}
+}
+
+// HandleEvent - подписчик шины событий. Ошибка сохранения только логируется:
+// действие пользователя уже выполнено, а расхождение исправит пересчет.
+func (r *ReputationCase) HandleEvent(ctx context.Context, event entity.Event) {
+	// Начисление не должно прерываться, если клиент уже закрыл соединение
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	switch e := event.(type) {
+	case entity.AnswerDeleted:
+		err = r.syncAnswers(ctx, []int{e.AnswerId}, e.At)
+	case entity.AnswerRestored:
+		err = r.syncAnswers(ctx, []int{e.AnswerId}, e.At)
+	case entity.QuestionDeleted:
+		err = r.syncAnswers(ctx, e.AnswerIds, e.At)
+	case entity.QuestionRestored:
+		err = r.syncAnswers(ctx, e.AnswerIds, e.At)
+	default:
+		if entries := r.entries(event); len(entries) > 0 {
+			err = r.reputationRepo.AddReputation(ctx, entries)
+		}
+	}
+	if err != nil {
+		tracing.Logger(ctx, r.logger).Error("Failed to add reputation", zap.String("event", event.EventName()), zap.Error(err))
+	}
+}
+
+// syncAnswers дописывает в журнал разницу между очками за ответы answerIds, которые дали бы
+// их текущие голоса и отметка о принятом ответе, и уже записанными. Ответы в корзине и удаленные
+// очков не дают, как при пересчете. Повторная сверка тех же ответов ничего не меняет.
+func (r *ReputationCase) syncAnswers(ctx context.Context, answerIds []int, at time.Time) error {
+	if len(answerIds) == 0 {
+		return nil
+	}
+	expected, err := r.currentEntries(ctx, answerIds)
+	if err != nil {
+		return err
+	}
+	recorded, err := r.reputationRepo.GetAnswerReputation(ctx, answerIds)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		userId   string
+		reason   entity.ReputationReason
+		answerId int
+	}
+	var keys []key
+	delta := make(map[key]int)
+	apply := func(entry entity.ReputationEntry, sign int) {
+		k := key{entry.UserId, entry.Reason, entry.AnswerId}
+		if _, ok := delta[k]; !ok {
+			keys = append(keys, k)
+		}
+		delta[k] += sign * entry.Points
+	}
+	for _, entry := range expected {
+		apply(entry, 1)
+	}
+	for _, entry := range recorded {
+		apply(entry, -1)
+	}
+
+	var entries []entity.ReputationEntry
+	for _, k := range keys {
+		if points := delta[k]; points != 0 {
+			entries = append(entries, entity.ReputationEntry{
+				UserId:    k.userId,
+				Points:    points,
+				Reason:    k.reason,
+				AnswerId:  k.answerId,
+				CreatedAt: at,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.reputationRepo.AddReputation(ctx, entries)
+}
+
+// currentEntries переводит текущие голоса и принятые ответы в записи журнала; пустой answerIds - по всем ответам
+func (r *ReputationCase) currentEntries(ctx context.Context, answerIds []int) ([]entity.ReputationEntry, error) {
+	votes, err := r.reputationRepo.GetVotes(ctx, answerIds)
+	if err != nil {
+		tracing.Logger(ctx, r.logger).Error("Failed to get votes", zap.Error(err))
+		return nil, err
+	}
+	accepted, err := r.reputationRepo.GetAcceptedAnswers(ctx, answerIds)
+	if err != nil {
+		tracing.Logger(ctx, r.logger).Error("Failed to get accepted answers", zap.Error(err))
+		return nil, err
+	}
+
+	var entries []entity.ReputationEntry
+	for _, vote := range votes {
+		entries = append(entries, r.entries(vote)...)
+	}
+	for _, answer := range accepted {
+		entries = append(entries, r.entries(answer)...)
+	}
+	return entries, nil
+}
+
+// entries переводит событие в записи журнала; действия со своими ответами очков не дают
+func (r *ReputationCase) entries(event entity.Event) []entity.ReputationEntry {
+	var entries []entity.ReputationEntry
+	add := func(userId string, points int, reason entity.ReputationReason, answerId int, at time.Time) {
+		if userId != "" && points != 0 {
+			entries = append(entries, entity.ReputationEntry{
+				UserId:    userId,
+				Points:    points,
+				Reason:    reason,
+				AnswerId:  answerId,
+				CreatedAt: at,
+			})
+		}
+	}
+
+	switch e := event.(type) {
+	case entity.AnswerVoted:
+		if e.VoterId != e.AuthorId {
+			add(e.AuthorId, r.votePoints(e.Value)-r.votePoints(e.Previous), entity.ReputationReasonVote, e.AnswerId, e.At)
+		}
+	case entity.AnswerAccepted:
+		if e.AnswerAuthorId != e.QuestionAuthorId {
+			add(e.AnswerAuthorId, r.weights.Accepted, entity.ReputationReasonAccepted, e.AnswerId, e.At)
+			add(e.QuestionAuthorId, r.weights.Accept, entity.ReputationReasonAccept, e.AnswerId, e.At)
+		}
+	case entity.AnswerUnaccepted:
+		if e.AnswerAuthorId != e.QuestionAuthorId {
+			add(e.AnswerAuthorId, -r.weights.Accepted, entity.ReputationReasonAccepted, e.AnswerId, e.At)
+			add(e.QuestionAuthorId, -r.weights.Accept, entity.ReputationReasonAccept, e.AnswerId, e.At)
+		}
+	}
+	return entries
+}
+
+func (r *ReputationCase) votePoints(value int) int {
+	switch value {
+	case 1:
+		return r.weights.Upvote
+	case -1:
+		return -r.weights.DownvotePenalty
+	default:
+		return 0
+	}
+}
+
+// RebuildReputation пересчитывает журнал и репутацию всех пользователей по текущим голосам
+// и принятым ответам. Удаленные в корзину ответы в пересчет не попадают.
+func (r *ReputationCase) RebuildReputation(ctx context.Context) error {
+	ctx, span, logger := startSpan(ctx, r.logger, "ReputationCase.RebuildReputation")
+	defer span.End()
+	logger.Info("Rebuilding reputation")
+	entries, err := r.currentEntries(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.reputationRepo.ReplaceReputation(ctx, entries); err != nil {
+		logger.Error("Failed to replace reputation", zap.Error(err))
+		return err
+	}
+	logger.Info("Reputation rebuilt successfully", zap.Int("entries", len(entries)))
+	return nil
+}
+
+// GetLeaderboard возвращает пользователей с наибольшей суммой очков за период, пустой период - за все время
+func (r *ReputationCase) GetLeaderboard(ctx context.Context, window entity.LeaderboardWindow, limit int) ([]entity.LeaderboardEntry, error) {
+	ctx, span, logger := startSpan(ctx, r.logger, "ReputationCase.GetLeaderboard")
+	defer span.End()
+	logger.Info("Getting leaderboard", zap.String("window", string(window)), zap.Int("limit", limit))
+	var since time.Time
+	switch window {
+	case "", entity.LeaderboardWindowAll:
+	case entity.LeaderboardWindowWeek:
+		since = time.Now().AddDate(0, 0, -7)
+	case entity.LeaderboardWindowMonth:
+		since = time.Now().AddDate(0, -1, 0)
+	default:
+		return nil, errs.Validation("unsupported leaderboard window %q", window)
+	}
+
+	leaders, err := r.reputationRepo.GetLeaderboard(ctx, since, normalizeLimit(limit))
+	if err != nil {
+		logger.Error("Failed to get leaderboard", zap.Error(err))
+		return nil, err
+	}
+	return leaders, nil
+}
@@ -0,0 +1,55 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"context"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// SearchLanguages - поддерживаемые конфигурации текстового поиска
+var SearchLanguages = []string{"simple", "english", "russian"}
+
+type SearchCase struct {
+	searchRepo      repo.SearchRepo
+	defaultLanguage string
+	logger          *zap.Logger
+}
+
+func NewSearchCase(searchRepo repo.SearchRepo, defaultLanguage string, logger *zap.Logger) *SearchCase {
+	return &SearchCase{
+		searchRepo:      searchRepo,
+		defaultLanguage: defaultLanguage,
+		logger:          logger,
+	}
+}
+
+// Search ищет вопросы по тексту вопросов и ответов. Пустой language означает язык по умолчанию.
+func (s *SearchCase) Search(ctx context.Context, text string, language string, limit int) ([]entity.SearchHit, error) {
+	ctx, span, logger := startSpan(ctx, s.logger, "SearchCase.Search")
+	defer span.End()
+	logger.Info("Searching questions", zap.String("query", text), zap.String("language", language))
+	if text == "" {
+		return nil, errs.Validation("query is required")
+	}
+	if language == "" {
+		language = s.defaultLanguage
+	}
+	if !slices.Contains(SearchLanguages, language) {
+		return nil, errs.Validation("unsupported search language %q", language)
+	}
+
+	hits, err := s.searchRepo.Search(ctx, repo.SearchQuery{
+		Text:     text,
+		Language: language,
+		Limit:    normalizeLimit(limit),
+	})
+	if err != nil {
+		logger.Error("Failed to search questions", zap.Error(err))
+		return nil, err
+	}
+	return hits, nil
+}
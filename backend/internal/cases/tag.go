@@ -0,0 +1,155 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxTagLength    = 35
+	maxQuestionTags = 5
+)
+
+type TagCase struct {
+	tagRepo    repo.TagRepo
+	authorizer service.Authorizer
+	logger     *zap.Logger
+}
+
+func NewTagCase(tagRepo repo.TagRepo, authorizer service.Authorizer, logger *zap.Logger) *TagCase {
+	return &TagCase{
+		tagRepo:    tagRepo,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+func (t *TagCase) GetTags(ctx context.Context) ([]entity.TagInfo, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TagCase.GetTags")
+	defer span.End()
+	logger.Info("Getting tags")
+	tags, err := t.tagRepo.GetTags(ctx)
+	if err != nil {
+		logger.Error("Failed to get tags", zap.Error(err))
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (t *TagCase) GetTag(ctx context.Context, tagId int) (*entity.TagInfo, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TagCase.GetTag")
+	defer span.End()
+	logger.Info("Getting tag", zap.Int("id", tagId))
+	tag, err := t.tagRepo.GetTag(ctx, tagId)
+	if err != nil {
+		logger.Error("Failed to get tag", zap.Int("id", tagId), zap.Error(err))
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (t *TagCase) CreateTag(ctx context.Context, name string) (*entity.TagInfo, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TagCase.CreateTag")
+	defer span.End()
+	logger.Info("Creating tag", zap.String("name", name))
+	if err := t.authorizer.Authorize(ctx, entity.PermissionTagCreate, ""); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &entity.Tag{Name: name}
+	if err := t.tagRepo.CreateTag(ctx, tag); err != nil {
+		logger.Error("Failed to create tag", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Tag created successfully", zap.Int("id", tag.Id))
+	return t.tagRepo.GetTag(ctx, tag.Id)
+}
+
+// RenameTag меняет имя тега; вопросы остаются отмечены им под новым именем
+func (t *TagCase) RenameTag(ctx context.Context, tagId int, name string) (*entity.TagInfo, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TagCase.RenameTag")
+	defer span.End()
+	logger.Info("Renaming tag", zap.Int("id", tagId), zap.String("name", name))
+	if err := t.authorizer.Authorize(ctx, entity.PermissionTagUpdate, ""); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.tagRepo.RenameTag(ctx, tagId, name); err != nil {
+		logger.Error("Failed to rename tag", zap.Int("id", tagId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Tag renamed successfully", zap.Int("id", tagId))
+	return t.tagRepo.GetTag(ctx, tagId)
+}
+
+// AddTagSynonym добавляет тегу другое имя, под которым его можно указывать в вопросах и фильтрах
+func (t *TagCase) AddTagSynonym(ctx context.Context, tagId int, name string) (*entity.TagInfo, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TagCase.AddTagSynonym")
+	defer span.End()
+	logger.Info("Adding tag synonym", zap.Int("id", tagId), zap.String("name", name))
+	if err := t.authorizer.Authorize(ctx, entity.PermissionTagUpdate, ""); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.tagRepo.AddTagSynonym(ctx, tagId, name); err != nil {
+		logger.Error("Failed to add tag synonym", zap.Int("id", tagId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("Tag synonym added successfully", zap.Int("id", tagId))
+	return t.tagRepo.GetTag(ctx, tagId)
+}
+
+// normalizeTagName приводит имя к нижнему регистру и заменяет пробелы дефисом: " Go  Modules" -> "go-modules".
+// Допустимы буквы, цифры и символы + # . -
+func normalizeTagName(name string) (string, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(name), "-"))
+	if normalized == "" {
+		return "", errs.Validation("tag name is required")
+	}
+	if utf8.RuneCountInString(normalized) > maxTagLength {
+		return "", errs.Validation("tag %q is longer than %d characters", normalized, maxTagLength)
+	}
+	for _, r := range normalized {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+#.-", r) {
+			return "", errs.Validation("tag %q contains invalid character %q", normalized, r)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeTagNames нормализует имена и убирает повторы, сохраняя порядок
+func normalizeTagNames(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		normalized, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
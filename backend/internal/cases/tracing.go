@@ -0,0 +1,15 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// startSpan открывает спан метода case и возвращает логгер с trace id этого спана
+func startSpan(ctx context.Context, logger *zap.Logger, name string) (context.Context, trace.Span, *zap.Logger) {
+	ctx, span := tracing.Start(ctx, name)
+	return ctx, span, tracing.Logger(ctx, logger)
+}
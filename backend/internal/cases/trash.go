@@ -0,0 +1,77 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TrashCase struct {
+	trashRepo  repo.TrashRepo
+	authorizer service.Authorizer
+	retention  time.Duration
+	logger     *zap.Logger
+}
+
+// NewTrashCase создает корзину, записи в которой хранятся retention до окончательного удаления
+func NewTrashCase(trashRepo repo.TrashRepo, authorizer service.Authorizer, retention time.Duration, logger *zap.Logger) *TrashCase {
+	return &TrashCase{
+		trashRepo:  trashRepo,
+		authorizer: authorizer,
+		retention:  retention,
+		logger:     logger,
+	}
+}
+
+// GetTrash возвращает последние удаленные вопросы и ответы
+func (t *TrashCase) GetTrash(ctx context.Context, limit int) (*entity.Trash, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TrashCase.GetTrash")
+	defer span.End()
+	logger.Info("Getting trash", zap.Int("limit", limit))
+	if err := t.authorizer.Authorize(ctx, entity.PermissionTrashRead, ""); err != nil {
+		return nil, err
+	}
+	trash, err := t.trashRepo.GetTrash(ctx, normalizeLimit(limit))
+	if err != nil {
+		logger.Error("Failed to get trash", zap.Error(err))
+		return nil, err
+	}
+	return trash, nil
+}
+
+// Purge окончательно удаляет записи, пролежавшие в корзине дольше срока хранения.
+// Это системная операция, права пользователя не проверяются.
+func (t *TrashCase) Purge(ctx context.Context) (entity.PurgeResult, error) {
+	ctx, span, logger := startSpan(ctx, t.logger, "TrashCase.Purge")
+	defer span.End()
+	before := time.Now().Add(-t.retention)
+	logger.Info("Purging trash", zap.Time("before", before))
+	result, err := t.trashRepo.PurgeTrash(ctx, before)
+	if err != nil {
+		logger.Error("Failed to purge trash", zap.Error(err))
+		return entity.PurgeResult{}, err
+	}
+	logger.Info("Trash purged successfully",
+		zap.Int("questions", result.Questions),
+		zap.Int("answers", result.Answers))
+	return result, nil
+}
+
+// RunPurge очищает корзину с периодом interval, пока не отменен ctx
+func (t *TrashCase) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Ошибка уже залогирована, следующая попытка будет на следующем тике
+		_, _ = t.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cases
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/port/repo"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"HiTalent_TestTask/backend/internal/tracing"
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxDisplayNameLength = 255
+	maxBioLength         = 2000
+
+	// maxKnownUsers - сколько последних пользователей помнит RegisterUser; вытесненные просто
+	// снова пройдут через идемпотентный EnsureUser
+	maxKnownUsers = 10000
+)
+
+type UserCase struct {
+	userRepo   repo.UserRepo
+	authorizer service.Authorizer
+	known      *knownUsers // id уже заведенных пользователей, чтобы не писать в базу на каждый запрос
+	logger     *zap.Logger
+}
+
+func NewUserCase(userRepo repo.UserRepo, authorizer service.Authorizer, logger *zap.Logger) *UserCase {
+	return &UserCase{
+		userRepo:   userRepo,
+		authorizer: authorizer,
+		known:      newKnownUsers(maxKnownUsers),
+		logger:     logger,
+	}
+}
+
+// RegisterUser заводит профиль пользователя токена при первом запросе.
+// Отображаемое имя берется из claim name, без него - из id.
+func (u *UserCase) RegisterUser(ctx context.Context, identity auth.Identity) error {
+	if u.known.Contains(identity.Subject) {
+		return nil
+	}
+
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name = identity.Subject
+	}
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		name = string([]rune(name)[:maxDisplayNameLength])
+	}
+	user := &entity.User{Id: identity.Subject, DisplayName: name}
+	if err := u.userRepo.EnsureUser(ctx, user); err != nil {
+		tracing.Logger(ctx, u.logger).Error("Failed to register user", zap.String("id", identity.Subject), zap.Error(err))
+		return err
+	}
+	u.known.Add(identity.Subject)
+	return nil
+}
+
+func (u *UserCase) GetUser(ctx context.Context, userId string) (*entity.User, error) {
+	ctx, span, logger := startSpan(ctx, u.logger, "UserCase.GetUser")
+	defer span.End()
+	logger.Info("Getting user", zap.String("id", userId))
+	user, err := u.userRepo.GetUser(ctx, userId)
+	if err != nil {
+		logger.Error("Failed to get user", zap.String("id", userId), zap.Error(err))
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser меняет имя и описание профиля; nil оставляет поле как есть
+func (u *UserCase) UpdateUser(ctx context.Context, userId string, displayName *string, bio *string) (*entity.User, error) {
+	ctx, span, logger := startSpan(ctx, u.logger, "UserCase.UpdateUser")
+	defer span.End()
+	logger.Info("Updating user", zap.String("id", userId))
+	if err := u.authorizer.Authorize(ctx, entity.PermissionUserUpdate, userId); err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.GetUser(ctx, userId)
+	if err != nil {
+		logger.Error("Failed to get user", zap.String("id", userId), zap.Error(err))
+		return nil, err
+	}
+
+	if displayName != nil {
+		name := strings.TrimSpace(*displayName)
+		if name == "" {
+			return nil, errs.Validation("display_name must not be empty")
+		}
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return nil, errs.Validation("display_name is longer than %d characters", maxDisplayNameLength)
+		}
+		user.DisplayName = name
+	}
+	if bio != nil {
+		if utf8.RuneCountInString(*bio) > maxBioLength {
+			return nil, errs.Validation("bio is longer than %d characters", maxBioLength)
+		}
+		user.Bio = *bio
+	}
+
+	if err := u.userRepo.UpdateUser(ctx, user); err != nil {
+		logger.Error("Failed to update user", zap.String("id", userId), zap.Error(err))
+		return nil, err
+	}
+	logger.Info("User updated successfully", zap.String("id", userId))
+	return user, nil
+}
+
+// GetUserAnswers возвращает страницу ответов пользователя по возрастанию id
+func (u *UserCase) GetUserAnswers(ctx context.Context, userId string, limit int, cursor string) (*entity.Page[entity.Answer], error) {
+	ctx, span, logger := startSpan(ctx, u.logger, "UserCase.GetUserAnswers")
+	defer span.End()
+	logger.Info("Getting user answers",
+		zap.String("id", userId),
+		zap.Int("limit", limit),
+		zap.String("cursor", cursor))
+	afterId, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+
+	answers, err := u.userRepo.GetUserAnswers(ctx, userId, repo.UserListFilter{AfterId: afterId, Limit: limit + 1})
+	if err != nil {
+		logger.Error("Failed to get user answers", zap.String("id", userId), zap.Error(err))
+		return nil, err
+	}
+	page := &entity.Page[entity.Answer]{Items: answers}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = encodeCursor(page.Items[limit-1].ID)
+	}
+	return page, nil
+}
+
+// GetUserQuestions возвращает страницу вопросов пользователя по возрастанию id
+func (u *UserCase) GetUserQuestions(ctx context.Context, userId string, limit int, cursor string) (*entity.Page[entity.Question], error) {
+	ctx, span, logger := startSpan(ctx, u.logger, "UserCase.GetUserQuestions")
+	defer span.End()
+	logger.Info("Getting user questions",
+		zap.String("id", userId),
+		zap.Int("limit", limit),
+		zap.String("cursor", cursor))
+	afterId, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+
+	questions, err := u.userRepo.GetUserQuestions(ctx, userId, repo.UserListFilter{AfterId: afterId, Limit: limit + 1})
+	if err != nil {
+		logger.Error("Failed to get user questions", zap.String("id", userId), zap.Error(err))
+		return nil, err
+	}
+	page := &entity.Page[entity.Question]{Items: questions}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = encodeCursor(page.Items[limit-1].Id)
+	}
+	return page, nil
+}
+
+// knownUsers - LRU-множество id с ограниченной емкостью: память не растет с числом всех
+// когда-либо приходивших пользователей
+type knownUsers struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // от недавних к давним
+	elements map[string]*list.Element
+}
+
+func newKnownUsers(capacity int) *knownUsers {
+	return &knownUsers{
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// Contains проверяет id и отмечает его как недавно использованный
+func (k *knownUsers) Contains(id string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	element, ok := k.elements[id]
+	if ok {
+		k.order.MoveToFront(element)
+	}
+	return ok
+}
+
+// Add запоминает id, вытесняя самый давний при переполнении
+func (k *knownUsers) Add(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if element, ok := k.elements[id]; ok {
+		k.order.MoveToFront(element)
+		return
+	}
+	k.elements[id] = k.order.PushFront(id)
+	if k.order.Len() > k.capacity {
+		oldest := k.order.Back()
+		k.order.Remove(oldest)
+		delete(k.elements, oldest.Value.(string))
+	}
+}
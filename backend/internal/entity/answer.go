@@ -1,14 +1,22 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Answer struct {
-	ID         int       `gorm:"primaryKey;column:id" json:"id"`
-	QuestionId int       `gorm:"column:question_id;not null;index" json:"question_id"`
-	UserId     string    `gorm:"column:user_id;not null;index" json:"user_id"` //uuid
-	Text       string    `gorm:"column:text;not null" json:"text"`
-	CreatedAt  time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
-	Question   Question  `gorm:"foreignKey:QuestionId" json:"question,omitempty"`
+	ID         int            `gorm:"primaryKey;column:id" json:"id"`
+	QuestionId int            `gorm:"column:question_id;not null;index" json:"question_id"`
+	UserId     string         `gorm:"column:user_id;not null;index" json:"user_id"` //uuid
+	Text       string         `gorm:"column:text;not null" json:"text"`
+	Score      int            `gorm:"column:score;not null;default:0" json:"score"` // сумма голосов
+	CreatedAt  time.Time      `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at,omitzero"` // время удаления в корзину
+	Question   Question       `gorm:"foreignKey:QuestionId" json:"question,omitempty"`
+	Comments   []Comment      `gorm:"-" json:"comments,omitempty"` // ветки комментариев, загружаются по запросу
 }
 
 func (Answer) TableName() string {
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Comment - комментарий к ответу. Комментарий без ParentId открывает ветку, ответы на него
+// вложены на один уровень глубже. Видимость и удаление комментариев следуют за ответом.
+type Comment struct {
+	Id        int       `gorm:"primaryKey;column:id" json:"id"`
+	AnswerId  int       `gorm:"column:answer_id;not null;index" json:"answer_id"`
+	ParentId  *int      `gorm:"column:parent_id;index" json:"parent_id"`
+	UserId    string    `gorm:"column:user_id;not null" json:"user_id"`
+	Text      string    `gorm:"column:text;not null" json:"text"`
+	Depth     int       `gorm:"column:depth;not null;default:0" json:"depth"` // 0 - комментарий к самому ответу
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	Replies   []Comment `gorm:"-" json:"replies,omitempty"`
+}
+
+func (Comment) TableName() string {
+	return "comments"
+}
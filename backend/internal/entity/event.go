@@ -0,0 +1,130 @@
+package entity
+
+import "time"
+
+// Event - доменное событие, которое cases публикуют после успешного изменения
+type Event interface {
+	EventName() string
+}
+
+// AnswerVoted - пользователь проголосовал за ответ или отменил голос (Value 0).
+// Previous - голос этого пользователя до изменения.
+type AnswerVoted struct {
+	AnswerId   int
+	QuestionId int
+	AuthorId   string // автор ответа
+	VoterId    string
+	Previous   int
+	Value      int
+	At         time.Time
+}
+
+func (AnswerVoted) EventName() string {
+	return "answer.voted"
+}
+
+// AnswerAccepted - ответ отмечен принятым решением вопроса
+type AnswerAccepted struct {
+	QuestionId       int
+	AnswerId         int
+	AnswerAuthorId   string
+	QuestionAuthorId string // пустой для анонимного вопроса
+	At               time.Time
+}
+
+func (AnswerAccepted) EventName() string {
+	return "answer.accepted"
+}
+
+// AnswerUnaccepted - с ответа снята отметка о принятом решении, в том числе при выборе другого ответа
+type AnswerUnaccepted struct {
+	QuestionId       int
+	AnswerId         int
+	AnswerAuthorId   string
+	QuestionAuthorId string
+	At               time.Time
+}
+
+func (AnswerUnaccepted) EventName() string {
+	return "answer.unaccepted"
+}
+
+// QuestionCreated - создан новый вопрос
+type QuestionCreated struct {
+	QuestionId int
+	AuthorId   string // пустой для анонимного вопроса
+	At         time.Time
+}
+
+func (QuestionCreated) EventName() string {
+	return "question.created"
+}
+
+// QuestionDeleted - вопрос перемещен в корзину или, при Hard, удален окончательно.
+// AnswerIds - ответы, удаленные вместе с ним.
+type QuestionDeleted struct {
+	QuestionId int
+	AnswerIds  []int
+	Hard       bool
+	At         time.Time
+}
+
+func (QuestionDeleted) EventName() string {
+	return "question.deleted"
+}
+
+// QuestionRestored - вопрос возвращен из корзины вместе с ответами AnswerIds
+type QuestionRestored struct {
+	QuestionId int
+	AnswerIds  []int
+	At         time.Time
+}
+
+func (QuestionRestored) EventName() string {
+	return "question.restored"
+}
+
+// AnswerCreated - к вопросу добавлен ответ
+type AnswerCreated struct {
+	AnswerId   int
+	QuestionId int
+	AuthorId   string
+	At         time.Time
+}
+
+func (AnswerCreated) EventName() string {
+	return "answer.created"
+}
+
+// AnswerDeleted - ответ перемещен в корзину
+type AnswerDeleted struct {
+	AnswerId   int
+	QuestionId int
+	At         time.Time
+}
+
+func (AnswerDeleted) EventName() string {
+	return "answer.deleted"
+}
+
+// AnswerRestored - ответ возвращен из корзины
+type AnswerRestored struct {
+	AnswerId   int
+	QuestionId int
+	At         time.Time
+}
+
+func (AnswerRestored) EventName() string {
+	return "answer.restored"
+}
+
+// CommentDeleted - комментарий удален вместе с ветками ответов на него
+type CommentDeleted struct {
+	CommentId int
+	AnswerId  int
+	At        time.Time
+}
+
+func (CommentDeleted) EventName() string {
+	return "comment.deleted"
+}
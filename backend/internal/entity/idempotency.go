@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// IdempotencyRecord - ответ на запрос с заголовком Idempotency-Key. Запись создается до выполнения
+// запроса с нулевым StatusCode и заполняется ответом после; повтор с тем же ключом получает сохраненный ответ.
+type IdempotencyRecord struct {
+	UserId      string    `gorm:"primaryKey;column:user_id"` // владелец ключа, пустой - анонимные запросы
+	Key         string    `gorm:"primaryKey;column:idempotency_key"`
+	Fingerprint string    `gorm:"column:fingerprint;not null"` // хеш метода, пути и тела запроса
+	StatusCode  int       `gorm:"column:status_code;not null;default:0"`
+	ContentType string    `gorm:"column:content_type;not null;default:''"`
+	Body        []byte    `gorm:"column:body"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;not null;index"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed сообщает, сохранен ли уже ответ на запрос
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,7 @@
+package entity
+
+// Page - страница списка с курсором на следующую страницу
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // пустой, если страница последняя
+}
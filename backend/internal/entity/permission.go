@@ -0,0 +1,45 @@
+package entity
+
+// Permission - действие, право на которое выдается ролям в политике доступа
+type Permission string
+
+const (
+	PermissionQuestionCreate     Permission = "question.create"
+	PermissionQuestionUpdate     Permission = "question.update"
+	PermissionQuestionDelete     Permission = "question.delete"
+	PermissionQuestionHardDelete Permission = "question.hard_delete"
+	PermissionQuestionRestore    Permission = "question.restore"
+	PermissionQuestionAccept     Permission = "question.accept"
+	PermissionAnswerCreate       Permission = "answer.create"
+	PermissionAnswerUpdate       Permission = "answer.update"
+	PermissionAnswerDelete       Permission = "answer.delete"
+	PermissionAnswerVote         Permission = "answer.vote"
+	PermissionAnswerRestore      Permission = "answer.restore"
+	PermissionTrashRead          Permission = "trash.read"
+	PermissionCommentCreate      Permission = "comment.create"
+	PermissionCommentDelete      Permission = "comment.delete"
+	PermissionTagCreate          Permission = "tag.create"
+	PermissionTagUpdate          Permission = "tag.update"  // переименование и синонимы
+	PermissionUserUpdate         Permission = "user.update" // имя и описание профиля
+)
+
+// Permissions - все действия; права с другими именами политика доступа не принимает
+var Permissions = []Permission{
+	PermissionQuestionCreate,
+	PermissionQuestionUpdate,
+	PermissionQuestionDelete,
+	PermissionQuestionHardDelete,
+	PermissionQuestionRestore,
+	PermissionQuestionAccept,
+	PermissionAnswerCreate,
+	PermissionAnswerUpdate,
+	PermissionAnswerDelete,
+	PermissionAnswerVote,
+	PermissionAnswerRestore,
+	PermissionTrashRead,
+	PermissionCommentCreate,
+	PermissionCommentDelete,
+	PermissionTagCreate,
+	PermissionTagUpdate,
+	PermissionUserUpdate,
+}
@@ -1,15 +1,35 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Question - вопрос
 type Question struct {
-	Id        int       `gorm:"primaryKey;column:id" json:"id"`
-	Text      string    `gorm:"column:text;not null" json:"text"` //(текст вопроса)
-	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
-	Answers   []Answer  `gorm:"foreignKey:QuestionId;constraint:OnDelete:CASCADE" json:"answers,omitempty"`
+	Id               int            `gorm:"primaryKey;column:id" json:"id"`
+	Text             string         `gorm:"column:text;not null" json:"text"`                    //(текст вопроса)
+	AuthorId         *string        `gorm:"column:author_id;index" json:"author_id"`             // автор, nil для анонимного вопроса
+	AcceptedAnswerId *int           `gorm:"column:accepted_answer_id" json:"accepted_answer_id"` // принятый ответ, nil если не выбран
+	AcceptedAt       *time.Time     `gorm:"column:accepted_at" json:"accepted_at,omitempty"`     // когда выбран принятый ответ
+	CreatedAt        time.Time      `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at,omitzero"` // время удаления в корзину
+	Version          int            `gorm:"column:version;->" json:"-"`                         // версия, см. QuestionVersion
+	ModifiedAt       time.Time      `gorm:"column:modified_at;->" json:"-"`                     // время последнего изменения версии
+	Answers          []Answer       `gorm:"foreignKey:QuestionId;constraint:OnDelete:CASCADE" json:"answers,omitempty"`
+	Tags             []Tag          `gorm:"many2many:question_tags;constraint:OnDelete:CASCADE" json:"tags,omitempty"`
 }
 
 func (Question) TableName() string {
 	return "questions"
 }
+
+// QuestionVersion - версия вопроса. Она растет при любом изменении представления вопроса: текста,
+// принятого ответа, его ответов, их голосов и комментариев, имен тегов. Ее ведет хранилище,
+// а HTTP-слой строит по ней ETag и Last-Modified.
+type QuestionVersion struct {
+	Version    int
+	ModifiedAt time.Time
+}
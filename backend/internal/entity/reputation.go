@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// ReputationReason - за что начислены очки репутации
+type ReputationReason string
+
+const (
+	ReputationReasonVote     ReputationReason = "vote"     // голос за ответ пользователя
+	ReputationReasonAccepted ReputationReason = "accepted" // ответ пользователя принят
+	ReputationReasonAccept   ReputationReason = "accept"   // пользователь принял ответ на свой вопрос
+)
+
+// ReputationEntry - запись журнала репутации. Отмена действия записывается
+// отдельной записью с противоположным знаком, поэтому сумма очков за период видна по журналу.
+type ReputationEntry struct {
+	Id        int              `gorm:"primaryKey;column:id" json:"id"`
+	UserId    string           `gorm:"column:user_id;not null;index" json:"user_id"`
+	Points    int              `gorm:"column:points;not null" json:"points"`
+	Reason    ReputationReason `gorm:"column:reason;not null" json:"reason"`
+	AnswerId  int              `gorm:"column:answer_id;not null" json:"answer_id"`
+	CreatedAt time.Time        `gorm:"column:created_at;default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
+
+func (ReputationEntry) TableName() string {
+	return "reputation_ledger"
+}
+
+// ReputationWeights - число очков за каждое действие
+type ReputationWeights struct {
+	Upvote          int // автору ответа за голос "за"
+	DownvotePenalty int // снимается с автора ответа за голос "против"
+	Accepted        int // автору принятого ответа
+	Accept          int // автору вопроса за выбор принятого ответа
+}
+
+// LeaderboardWindow - период, за который считается таблица лидеров
+type LeaderboardWindow string
+
+const (
+	LeaderboardWindowWeek  LeaderboardWindow = "week"
+	LeaderboardWindowMonth LeaderboardWindow = "month"
+	LeaderboardWindowAll   LeaderboardWindow = "all"
+)
+
+// LeaderboardEntry - пользователь и сумма его очков за период
+type LeaderboardEntry struct {
+	UserId      string `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Reputation  int    `json:"reputation"`
+}
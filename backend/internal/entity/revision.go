@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// AnswerRevision - версия текста ответа. Версия 1 - текст при создании, каждая правка добавляет следующую.
+type AnswerRevision struct {
+	AnswerId  int       `gorm:"primaryKey;column:answer_id;autoIncrement:false" json:"answer_id"`
+	Number    int       `gorm:"primaryKey;column:number;autoIncrement:false" json:"number"`
+	Text      string    `gorm:"column:text;not null" json:"text"`
+	EditorId  string    `gorm:"column:editor_id;not null" json:"editor_id"` // автор правки
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (AnswerRevision) TableName() string {
+	return "answer_revisions"
+}
+
+// DiffFormat - формат разницы между версиями
+type DiffFormat string
+
+const (
+	DiffFormatUnified DiffFormat = "unified"
+	DiffFormatWord    DiffFormat = "word"
+)
+
+// RevisionDiff - разница между версиями ответа From и To; From 0 - пустой текст
+type RevisionDiff struct {
+	AnswerId int        `json:"answer_id"`
+	From     int        `json:"from"`
+	To       int        `json:"to"`
+	Format   DiffFormat `json:"format"`
+	Diff     string     `json:"diff"`
+}
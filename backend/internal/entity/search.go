@@ -0,0 +1,7 @@
+package entity
+
+// SearchHit - найденный вопрос и его релевантность запросу
+type SearchHit struct {
+	Question
+	Rank float64 `json:"rank"`
+}
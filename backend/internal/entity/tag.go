@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// Tag - метка вопроса, имя хранится в нормализованном виде
+type Tag struct {
+	Id        int       `gorm:"primaryKey;column:id" json:"id"`
+	Name      string    `gorm:"column:name;not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// TagSynonym - другое имя тега: вопросы с синонимом получают основной тег, фильтр по синониму ищет по нему
+type TagSynonym struct {
+	Name      string    `gorm:"primaryKey;column:name" json:"name"`
+	TagId     int       `gorm:"column:tag_id;not null;index" json:"tag_id"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (TagSynonym) TableName() string {
+	return "tag_synonyms"
+}
+
+// TagInfo - тег с числом вопросов (без учета удаленных в корзину) и синонимами
+type TagInfo struct {
+	Tag
+	QuestionCount int      `json:"question_count"`
+	Synonyms      []string `json:"synonyms"`
+}
+
+// TagMode - как фильтр по нескольким тегам сочетает их
+type TagMode string
+
+const (
+	TagModeAll TagMode = "all" // вопрос отмечен всеми тегами
+	TagModeAny TagMode = "any" // вопрос отмечен хотя бы одним тегом
+)
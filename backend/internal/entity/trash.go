@@ -0,0 +1,13 @@
+package entity
+
+// Trash - содержимое корзины. Ответы удаленных вопросов входят в вопрос и отдельно не перечисляются.
+type Trash struct {
+	Questions []Question `json:"questions"`
+	Answers   []Answer   `json:"answers"`
+}
+
+// PurgeResult - число окончательно удаленных записей
+type PurgeResult struct {
+	Questions int `json:"questions"`
+	Answers   int `json:"answers"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// User - профиль пользователя. Id совпадает с claim sub токена: пользователь заводится
+// при первом аутентифицированном запросе, отдельной регистрации нет.
+type User struct {
+	Id          string    `gorm:"primaryKey;column:id" json:"id"`
+	DisplayName string    `gorm:"column:display_name;not null" json:"display_name"`
+	Bio         string    `gorm:"column:bio;not null;default:''" json:"bio"`
+	Reputation  int       `gorm:"column:reputation;not null;default:0" json:"reputation"` // сумма журнала репутации
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
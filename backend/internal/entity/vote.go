@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// AnswerVote - голос пользователя за ответ, не больше одного на пользователя
+type AnswerVote struct {
+	AnswerId  int       `gorm:"primaryKey;column:answer_id;autoIncrement:false" json:"answer_id"`
+	UserId    string    `gorm:"primaryKey;column:user_id" json:"user_id"`
+	Value     int       `gorm:"column:value;not null" json:"value"` // 1 - за, -1 - против
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (AnswerVote) TableName() string {
+	return "answer_votes"
+}
+
+// AnswerSort - порядок ответов внутри вопроса
+type AnswerSort string
+
+const (
+	AnswerSortOldest AnswerSort = "oldest"
+	AnswerSortNewest AnswerSort = "newest"
+	AnswerSortScore  AnswerSort = "score"
+)
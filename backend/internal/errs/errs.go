@@ -0,0 +1,69 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Виды доменных ошибок. Адаптеры и cases оборачивают их,
+// а HTTP-слой по виду выбирает статус ответа.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrPreconditionFailed - запись изменилась с версии, которую ожидал клиент
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error - доменная ошибка с сообщением, которое можно показать клиенту
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) error {
+	return newError(ErrNotFound, format, args...)
+}
+
+func Conflict(format string, args ...any) error {
+	return newError(ErrConflict, format, args...)
+}
+
+func Validation(format string, args ...any) error {
+	return newError(ErrValidation, format, args...)
+}
+
+func Unauthorized(format string, args ...any) error {
+	return newError(ErrUnauthorized, format, args...)
+}
+
+func Forbidden(format string, args ...any) error {
+	return newError(ErrForbidden, format, args...)
+}
+
+func PreconditionFailed(format string, args ...any) error {
+	return newError(ErrPreconditionFailed, format, args...)
+}
+
+// Message возвращает клиентское сообщение доменной ошибки или пустую строку
+func Message(err error) string {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Message
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package event
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lastEpoch - эпоха последнего созданного брокера, чтобы эпохи не совпадали даже в одном процессе
+var lastEpoch atomic.Int64
+
+// Message - событие вопроса для потока клиентам. ID растут на единицу с каждым событием
+// брокера; клиенту он отдается вместе с эпохой брокера (EventID), и по нему клиент
+// возобновляет поток (Last-Event-ID) после переподключения.
+type Message struct {
+	ID         uint64
+	QuestionId int
+	Event      entity.Event
+}
+
+// Broker раздает события ответов подписчикам вопроса. Он подписывается на Bus, поэтому публикация
+// не должна блокироваться: у каждого подписчика свой буфер, и подписчик, который не успевает его
+// разбирать, отключается. Клиент переподключается с Last-Event-ID и получает пропущенное из
+// общего кольцевого буфера последних событий.
+type Broker struct {
+	mu          sync.Mutex
+	epoch       string // время создания брокера: ID прошлого запуска с ней не совпадут
+	lastId      uint64
+	replay      []Message // кольцевой буфер последних событий, replay[next] - самое старое при заполнении
+	next        int
+	replaySize  int
+	bufferSize  int
+	subscribers map[int]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBroker создает брокер с буфером повтора из replaySize последних событий
+// и буфером из bufferSize событий на подписчика
+func NewBroker(replaySize int, bufferSize int) *Broker {
+	return &Broker{
+		epoch:       strconv.FormatInt(nextEpoch(), 10),
+		replay:      make([]Message, 0, replaySize),
+		replaySize:  replaySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+// nextEpoch возвращает текущее время в наносекундах, но больше эпохи предыдущего брокера
+func nextEpoch() int64 {
+	for {
+		last := lastEpoch.Load()
+		epoch := max(time.Now().UnixNano(), last+1)
+		if lastEpoch.CompareAndSwap(last, epoch) {
+			return epoch
+		}
+	}
+}
+
+// EventID - ID события для клиента в виде "<эпоха>-<номер>"
+func (b *Broker) EventID(id uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(id, 10)
+}
+
+// parseEventID возвращает номер события из ID этого брокера; ok=false - ID другой эпохи или не ID
+func (b *Broker) parseEventID(eventId string) (id uint64, ok bool) {
+	epoch, raw, found := strings.Cut(eventId, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	return id, err == nil
+}
+
+// Subscription - подписка на события вопроса. Канал C закрывается, когда подписка закрыта,
+// подписчик отстал и переполнил буфер или брокер остановлен.
+type Subscription struct {
+	C <-chan Message
+	// Replay - события вопроса после Last-Event-ID, которые нужно отправить до C
+	Replay []Message
+	// Lost - часть событий после Last-Event-ID уже вытеснена из буфера или ID не из этого
+	// запуска брокера, клиенту нужно заново загрузить вопрос
+	Lost bool
+	// LastId - ID последнего события брокера на момент подписки
+	LastId uint64
+
+	broker     *Broker
+	questionId int
+	messages   chan Message
+	closed     bool
+}
+
+// HandleEvent передает подписчикам события о добавлении и удалении ответов. Ответы, удаленные вместе
+// с вопросом, своих событий не публикуют, поэтому по каждому из них подписчики получают answer.deleted.
+func (b *Broker) HandleEvent(_ context.Context, event entity.Event) {
+	var questionId int
+	var events []entity.Event
+	switch e := event.(type) {
+	case entity.AnswerCreated:
+		questionId, events = e.QuestionId, []entity.Event{e}
+	case entity.AnswerDeleted:
+		questionId, events = e.QuestionId, []entity.Event{e}
+	case entity.QuestionDeleted:
+		questionId = e.QuestionId
+		for _, answerId := range e.AnswerIds {
+			events = append(events, entity.AnswerDeleted{AnswerId: answerId, QuestionId: e.QuestionId, At: e.At})
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, event := range events {
+		b.publishLocked(questionId, event)
+	}
+}
+
+// publishLocked присваивает событию следующий ID, сохраняет его для повтора и раздает подписчикам вопроса
+func (b *Broker) publishLocked(questionId int, event entity.Event) {
+	b.lastId++
+	message := Message{ID: b.lastId, QuestionId: questionId, Event: event}
+	if b.replaySize > 0 {
+		if len(b.replay) < b.replaySize {
+			b.replay = append(b.replay, message)
+		} else {
+			b.replay[b.next] = message
+			b.next = (b.next + 1) % b.replaySize
+		}
+	}
+
+	for sub := range b.subscribers[questionId] {
+		select {
+		case sub.messages <- message:
+		default:
+			// Медленный подписчик не задерживает публикацию: он отключается и догонит по Last-Event-ID
+			b.closeLocked(sub)
+		}
+	}
+}
+
+// Subscribe подписывает на события вопроса. С непустым lastEventId в Replay попадают события после него,
+// которые еще есть в буфере; подписка и выборка атомарны, поэтому между ними ничего не теряется.
+func (b *Broker) Subscribe(questionId int, lastEventId string) *Subscription {
+	messages := make(chan Message, b.bufferSize)
+	sub := &Subscription{C: messages, broker: b, questionId: questionId, messages: messages}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.closed = true
+		close(messages)
+		return sub
+	}
+	sub.LastId = b.lastId
+	if lastEventId != "" {
+		if id, ok := b.parseEventID(lastEventId); ok {
+			sub.Replay, sub.Lost = b.since(questionId, id)
+		} else {
+			sub.Lost = true
+		}
+	}
+	if b.subscribers[questionId] == nil {
+		b.subscribers[questionId] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[questionId][sub] = struct{}{}
+	return sub
+}
+
+// since возвращает события вопроса после lastEventId и признак того, что часть из них уже недоступна
+func (b *Broker) since(questionId int, lastEventId uint64) ([]Message, bool) {
+	// ID из буфера идут подряд, поэтому самое старое доступное событие - lastId-len+1
+	oldest := b.lastId - uint64(len(b.replay)) + 1
+	lost := lastEventId > b.lastId || lastEventId+1 < oldest
+
+	var messages []Message
+	for i := range b.replay {
+		message := b.replay[(b.next+i)%len(b.replay)]
+		if message.ID > lastEventId && message.QuestionId == questionId {
+			messages = append(messages, message)
+		}
+	}
+	return messages, lost
+}
+
+// Close отписывается от событий; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.closeLocked(s)
+}
+
+func (b *Broker) closeLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.messages)
+	delete(b.subscribers[sub.questionId], sub)
+	if len(b.subscribers[sub.questionId]) == 0 {
+		delete(b.subscribers, sub.questionId)
+	}
+}
+
+// Close закрывает все подписки, чтобы открытые потоки завершились при остановке сервера;
+// новые подписки сразу закрыты
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			b.closeLocked(sub)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package event
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishAnswer(broker *Broker, answerId int, questionId int) {
+	broker.HandleEvent(context.Background(), entity.AnswerCreated{AnswerId: answerId, QuestionId: questionId})
+}
+
+// drain читает из канала все накопленные сообщения; closed - канал закрыт
+func drain(sub *Subscription) (ids []uint64, closed bool) {
+	for {
+		select {
+		case message, ok := <-sub.C:
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, message.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func messageIds(messages []Message) []uint64 {
+	var ids []uint64
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestBrokerDelivers(t *testing.T) {
+	broker := NewBroker(10, 10)
+	sub := broker.Subscribe(1, "")
+	defer sub.Close()
+
+	publishAnswer(broker, 1, 1)
+	publishAnswer(broker, 2, 2)
+	broker.HandleEvent(context.Background(), entity.AnswerDeleted{AnswerId: 1, QuestionId: 1})
+	// Остальные события в поток не попадают и ID не занимают
+	broker.HandleEvent(context.Background(), entity.AnswerVoted{AnswerId: 1, QuestionId: 1})
+
+	ids, closed := drain(sub)
+	assert.Equal(t, []uint64{1, 3}, ids)
+	assert.False(t, closed)
+
+	sub.Close()
+	sub.Close()
+	_, closed = drain(sub)
+	assert.True(t, closed)
+}
+
+func TestBrokerQuestionDeleted(t *testing.T) {
+	broker := NewBroker(10, 10)
+	sub := broker.Subscribe(1, "")
+	defer sub.Close()
+
+	// Ответы, удаленные вместе с вопросом, приходят отдельными answer.deleted
+	broker.HandleEvent(context.Background(), entity.QuestionDeleted{QuestionId: 1, AnswerIds: []int{2, 3}})
+	broker.HandleEvent(context.Background(), entity.QuestionDeleted{QuestionId: 4})
+
+	var deleted []int
+	for i := 0; i < 2; i++ {
+		message := <-sub.C
+		require.IsType(t, entity.AnswerDeleted{}, message.Event)
+		assert.Equal(t, 1, message.QuestionId)
+		deleted = append(deleted, message.Event.(entity.AnswerDeleted).AnswerId)
+	}
+	assert.Equal(t, []int{2, 3}, deleted)
+	ids, _ := drain(sub)
+	assert.Empty(t, ids)
+
+	replayed := broker.Subscribe(1, broker.EventID(0))
+	defer replayed.Close()
+	assert.Equal(t, []uint64{1, 2}, messageIds(replayed.Replay))
+}
+
+func TestBrokerReplay(t *testing.T) {
+	broker := NewBroker(3, 10)
+	for i := 1; i <= 5; i++ {
+		publishAnswer(broker, i, 1+i%2)
+	}
+	// В буфере остались события 3, 4, 5; вопросу 2 принадлежат нечетные
+
+	tests := []struct {
+		name        string
+		lastEventId uint64
+		resume      bool
+		replay      []uint64
+		lost        bool
+	}{
+		{"without Last-Event-ID", 0, false, nil, false},
+		{"up to date", 5, true, nil, false},
+		{"inside buffer", 3, true, []uint64{5}, false},
+		{"right before buffer", 2, true, []uint64{3, 5}, false},
+		{"evicted", 1, true, []uint64{3, 5}, true},
+		{"from previous run", 9, true, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lastEventId := ""
+			if tt.resume {
+				lastEventId = broker.EventID(tt.lastEventId)
+			}
+			sub := broker.Subscribe(2, lastEventId)
+			defer sub.Close()
+			assert.Equal(t, tt.replay, messageIds(sub.Replay))
+			assert.Equal(t, tt.lost, sub.Lost)
+			assert.Equal(t, uint64(5), sub.LastId)
+		})
+	}
+}
+
+func TestBrokerResumeAfterRestart(t *testing.T) {
+	previous := NewBroker(10, 10)
+	publishAnswer(previous, 1, 1)
+	lastEventId := previous.EventID(1)
+
+	// Номера нового брокера начинаются заново, но ID прошлого запуска ему не подходят
+	broker := NewBroker(10, 10)
+	publishAnswer(broker, 2, 1)
+	publishAnswer(broker, 3, 1)
+	assert.NotEqual(t, lastEventId, broker.EventID(1))
+
+	for _, eventId := range []string{lastEventId, "1", "abc", broker.EventID(0) + "x"} {
+		sub := broker.Subscribe(1, eventId)
+		assert.True(t, sub.Lost, eventId)
+		assert.Empty(t, sub.Replay, eventId)
+		sub.Close()
+	}
+
+	sub := broker.Subscribe(1, broker.EventID(1))
+	defer sub.Close()
+	assert.False(t, sub.Lost)
+	assert.Equal(t, []uint64{2}, messageIds(sub.Replay))
+}
+
+func TestBrokerDisconnectsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(10, 2)
+	slow := broker.Subscribe(1, "")
+	fast := broker.Subscribe(1, "")
+	defer fast.Close()
+
+	publishAnswer(broker, 1, 1)
+	publishAnswer(broker, 2, 1)
+	ids, _ := drain(fast)
+	assert.Equal(t, []uint64{1, 2}, ids)
+
+	// Переполненный буфер не блокирует публикацию: отстающий подписчик отключается
+	publishAnswer(broker, 3, 1)
+	ids, closed := drain(slow)
+	assert.Equal(t, []uint64{1, 2}, ids)
+	assert.True(t, closed)
+	ids, closed = drain(fast)
+	assert.Equal(t, []uint64{3}, ids)
+	assert.False(t, closed)
+
+	// Переподключившись, он догоняет по Last-Event-ID
+	resumed := broker.Subscribe(1, broker.EventID(2))
+	defer resumed.Close()
+	assert.Equal(t, []uint64{3}, messageIds(resumed.Replay))
+	assert.False(t, resumed.Lost)
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker(10, 10)
+	sub := broker.Subscribe(1, "")
+
+	broker.Close()
+	_, closed := drain(sub)
+	assert.True(t, closed)
+	sub.Close()
+
+	late := broker.Subscribe(1, "")
+	_, closed = drain(late)
+	require.True(t, closed)
+	publishAnswer(broker, 1, 1)
+}
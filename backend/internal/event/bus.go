@@ -0,0 +1,40 @@
+// Package event - шина доменных событий внутри процесса
+package event
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/port/service"
+	"context"
+	"sync"
+)
+
+var _ service.EventPublisher = (*Bus)(nil)
+
+// Handler обрабатывает событие; по типу события подписчик сам выбирает нужные
+type Handler func(ctx context.Context, event entity.Event)
+
+// Bus вызывает подписчиков синхронно в порядке подписки
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event entity.Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
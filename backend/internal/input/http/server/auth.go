@@ -0,0 +1,51 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Authenticator проверяет bearer-токен и возвращает пользователя запроса
+type Authenticator interface {
+	Verify(token string) (auth.Identity, error)
+}
+
+// authenticate кладет пользователя из заголовка Authorization в контекст запроса.
+// Запрос без заголовка считается анонимным, права на операцию проверяют cases.
+// Невалидный токен сразу отклоняется с 401. Пользователь валидного токена заводится в профилях.
+// Перед проверкой токена действует лимит по IP, иначе невалидные токены обходили бы лимиты.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	header := r.Header.Get("Authorization")
+	if s.authenticator == nil || header == "" {
+		return r, true
+	}
+	if !s.allowAuthentication(w, r) {
+		return r, false
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		writeProblem(w, r, http.StatusUnauthorized, "bearer token required")
+		return r, false
+	}
+
+	identity, err := s.authenticator.Verify(strings.TrimSpace(token))
+	if err != nil {
+		s.logger.Info("Rejected token", zap.String("path", r.URL.Path), zap.Error(err))
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+		writeProblem(w, r, http.StatusUnauthorized, "invalid token")
+		return r, false
+	}
+
+	if s.userCase != nil {
+		if err := s.userCase.RegisterUser(r.Context(), identity); err != nil {
+			writeError(w, r, s.logger, err)
+			return r, false
+		}
+	}
+	return r.WithContext(auth.WithIdentity(r.Context(), identity)), true
+}
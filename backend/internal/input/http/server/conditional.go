@@ -0,0 +1,149 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// questionETag - сильный ETag представления вопроса: версия вопроса и вариант представления
+// (порядок ответов, встроенные комментарии), чтобы варианты не подменяли друг друга в кешах
+func questionETag(version int, variant string) string {
+	if variant == "" {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+	return fmt.Sprintf(`"%d-%s"`, version, variant)
+}
+
+// questionVariant описывает вариант представления вопроса для ETag; ok false - неизвестный порядок
+// ответов, такой запрос не проверяется по версии и получает ошибку валидации
+func questionVariant(answerSort entity.AnswerSort, withComments bool) (string, bool) {
+	var parts []string
+	switch answerSort {
+	case "", entity.AnswerSortOldest:
+	case entity.AnswerSortNewest, entity.AnswerSortScore:
+		parts = append(parts, string(answerSort))
+	default:
+		return "", false
+	}
+	if withComments {
+		parts = append(parts, "comments")
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// etagVersion извлекает версию вопроса из сильного ETag любого варианта представления
+func etagVersion(etag string) (int, bool) {
+	value, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	value, ok = strings.CutSuffix(value, `"`)
+	if !ok {
+		return 0, false
+	}
+	value, _, _ = strings.Cut(value, "-")
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// parseETags разбирает заголовок If-Match или If-None-Match: список ETag через запятую или "*"
+func parseETags(header string) (etags []string, any bool) {
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		switch etag {
+		case "":
+		case "*":
+			any = true
+		default:
+			etags = append(etags, etag)
+		}
+	}
+	return etags, any
+}
+
+// setValidators выставляет ETag и Last-Modified представления. Кеши должны сверять
+// сохраненный ответ с сервером перед каждым использованием.
+func setValidators(w http.ResponseWriter, etag string, modifiedAt time.Time) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !modifiedAt.IsZero() {
+		w.Header().Set("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// conditionalGet сообщает, что у запроса есть условия If-None-Match или If-Modified-Since
+func conditionalGet(r *http.Request) bool {
+	return r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != ""
+}
+
+// notModified проверяет условия GET: If-None-Match (слабое сравнение) или, если его нет,
+// If-Modified-Since с точностью до секунды, как в заголовке Last-Modified
+func notModified(r *http.Request, etag string, modifiedAt time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		etags, any := parseETags(header)
+		if any {
+			return true
+		}
+		return slices.ContainsFunc(etags, func(candidate string) bool {
+			return strings.TrimPrefix(candidate, "W/") == etag
+		})
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modifiedAt.IsZero() {
+		return false
+	}
+	return !modifiedAt.Truncate(time.Second).After(since)
+}
+
+// writeNotModified отвечает 304 с валидаторами текущего представления
+func writeNotModified(w http.ResponseWriter, etag string, modifiedAt time.Time) {
+	setValidators(w, etag, modifiedAt)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// ifMatchVersion переводит If-Match в версию вопроса, которую ожидает клиент; 0 - без проверки
+// (заголовка нет или "*"). Сравнение сильное: слабые и чужие ETag не совпадают ни с одной версией,
+// и запрос сразу отклоняется с 412. Из нескольких ETag выбирается совпадающий с текущей версией,
+// а окончательно версию сверяет QuestionCase вместе с изменением.
+func (h *Handlers) ifMatchVersion(w http.ResponseWriter, r *http.Request, questionId int) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	etags, any := parseETags(header)
+	if any {
+		return 0, true
+	}
+	var versions []int
+	for _, etag := range etags {
+		if version, ok := etagVersion(etag); ok {
+			versions = append(versions, version)
+		}
+	}
+	switch len(versions) {
+	case 0:
+		writeProblem(w, r, http.StatusPreconditionFailed, "If-Match does not match the current question version")
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	current, err := h.questionCase.GetQuestionVersion(r.Context(), questionId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return 0, false
+	}
+	if !slices.Contains(versions, current.Version) {
+		writeProblem(w, r, http.StatusPreconditionFailed, "If-Match does not match the current question version")
+		return 0, false
+	}
+	return current.Version, true
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/event"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// streamWriteTimeout - сколько ждать записи одного события в поток. WriteTimeout сервера рассчитан
+// на обычные запросы и оборвал бы поток, поэтому дедлайн записи продлевается перед каждой отправкой.
+const streamWriteTimeout = 10 * time.Second
+
+// streamResetEvent сообщает клиенту, что часть событий потеряна и вопрос нужно загрузить заново
+const streamResetEvent = "stream.reset"
+
+// answerEvent - данные события ответа в потоке
+type answerEvent struct {
+	AnswerId   int       `json:"answer_id"`
+	QuestionId int       `json:"question_id"`
+	UserId     string    `json:"user_id,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// StreamQuestionEvents отдает события ответов вопроса как Server-Sent Events. С Last-Event-ID поток
+// начинается с пропущенных событий из буфера брокера; пока событий нет, идут комментарии-heartbeat,
+// чтобы прокси не закрывали соединение.
+func (h *Handlers) StreamQuestionEvents(w http.ResponseWriter, r *http.Request, questionId int) {
+	if _, err := h.questionCase.GetQuestionVersion(r.Context(), questionId); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	// ID не этого запуска сервера брокер считает потерянными событиями, клиент получит stream.reset
+	sub := h.eventBroker.Subscribe(questionId, r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Буферизующие прокси (nginx) иначе придерживают события
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	stream := &eventStream{w: w, rc: http.NewResponseController(w), broker: h.eventBroker}
+	if err := stream.write(""); err != nil {
+		return
+	}
+
+	if sub.Lost {
+		// Часть событий уже не восстановить: клиент перезагружает вопрос и продолжает с текущего ID
+		if err := stream.send(sub.LastId, streamResetEvent, map[string]int{"question_id": questionId}); err != nil {
+			return
+		}
+	} else {
+		for _, message := range sub.Replay {
+			if err := stream.message(message); err != nil {
+				return
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(h.streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message, ok := <-sub.C:
+			// Канал закрыт: подписчик отстал или сервер останавливается, клиент переподключится
+			if !ok {
+				return
+			}
+			if err := stream.message(message); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := stream.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// eventStream пишет события в формате text/event-stream
+type eventStream struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	broker *event.Broker
+}
+
+func (s *eventStream) message(message event.Message) error {
+	var data answerEvent
+	switch e := message.Event.(type) {
+	case entity.AnswerCreated:
+		data = answerEvent{AnswerId: e.AnswerId, QuestionId: e.QuestionId, UserId: e.AuthorId, At: e.At}
+	case entity.AnswerDeleted:
+		data = answerEvent{AnswerId: e.AnswerId, QuestionId: e.QuestionId, At: e.At}
+	default:
+		return nil
+	}
+	return s.send(message.ID, message.Event.EventName(), data)
+}
+
+func (s *eventStream) send(id uint64, name string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", s.broker.EventID(id), name, body))
+}
+
+func (s *eventStream) write(chunk string) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := fmt.Fprint(s.w, chunk); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -3,19 +3,29 @@ package server
 import (
 	"HiTalent_TestTask/backend/internal/cases"
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/event"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type Handlers struct {
-	questionCase *cases.QuestionCase
-	answerCase   *cases.AnswerCase
-	logger       *zap.Logger
+	questionCase    *cases.QuestionCase
+	answerCase      *cases.AnswerCase
+	searchCase      *cases.SearchCase
+	trashCase       *cases.TrashCase
+	tagCase         *cases.TagCase
+	commentCase     *cases.CommentCase
+	userCase        *cases.UserCase
+	reputationCase  *cases.ReputationCase
+	eventBroker     *event.Broker
+	streamHeartbeat time.Duration // период комментариев-heartbeat в потоке событий
+	logger          *zap.Logger
 }
 
 func NewHandlers(questionCase *cases.QuestionCase, answerCase *cases.AnswerCase, logger *zap.Logger) *Handlers {
@@ -29,154 +39,599 @@ func NewHandlers(questionCase *cases.QuestionCase, answerCase *cases.AnswerCase,
 // Question Handlers
 
 func (h *Handlers) GetQuestionList(w http.ResponseWriter, r *http.Request) {
-	questions, err := h.questionCase.GetQuestionList(r.Context())
-	if err != nil {
-		h.logger.Error("Failed to get question list", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	query := r.URL.Query()
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(questions); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	tagMode := entity.TagMode(query.Get("tag_mode"))
+	page, err := h.questionCase.GetQuestionList(r.Context(), limit, query.Get("cursor"), query["tag"], tagMode)
+	if err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+// createQuestionRequest - тело запроса создания вопроса, теги передаются именами или синонимами
+type createQuestionRequest struct {
+	Text string   `json:"text"`
+	Tags []string `json:"tags"`
 }
 
 func (h *Handlers) CreateQuestion(w http.ResponseWriter, r *http.Request) {
-	var question entity.Question
-	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
-		h.logger.Error("Failed to decode request body", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var request createQuestionRequest
+	if !h.decodeBody(w, r, &request) {
 		return
 	}
 
-	if question.Text == "" {
-		http.Error(w, "Text is required", http.StatusBadRequest)
-		return
+	question := entity.Question{Text: request.Text}
+	for _, name := range request.Tags {
+		question.Tags = append(question.Tags, entity.Tag{Name: name})
 	}
 
 	if err := h.questionCase.CreateQuestion(r.Context(), &question); err != nil {
-		h.logger.Error("Failed to create question", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(question); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
-		return
-	}
+	h.writeJSON(w, http.StatusCreated, question)
 }
 
 func (h *Handlers) GetQuestion(w http.ResponseWriter, r *http.Request, questionId int) {
-	question, err := h.questionCase.GetQuestion(r.Context(), questionId)
-	if err != nil {
-		if err.Error() == "question not found" {
-			http.Error(w, "Question not found", http.StatusNotFound)
+	answerSort := entity.AnswerSort(r.URL.Query().Get("sort"))
+
+	// ?include=comments встраивает в ответы ветки комментариев
+	withComments := false
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case "comments":
+			withComments = h.commentCase != nil
+		default:
+			writeProblem(w, r, http.StatusBadRequest, fmt.Sprintf("unsupported include %q", include))
 			return
 		}
-		h.logger.Error("Failed to get question", zap.Int("id", questionId), zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+
+	// Условный запрос проверяется по версии вопроса, не загружая ответы
+	variant, known := questionVariant(answerSort, withComments)
+	if known && conditionalGet(r) {
+		version, err := h.questionCase.GetQuestionVersion(r.Context(), questionId)
+		if err != nil {
+			writeError(w, r, h.logger, err)
+			return
+		}
+		if etag := questionETag(version.Version, variant); notModified(r, etag, version.ModifiedAt) {
+			writeNotModified(w, etag, version.ModifiedAt)
+			return
+		}
+	}
+
+	question, err := h.questionCase.GetQuestion(r.Context(), questionId, answerSort, withComments)
+	if err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(question); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
+	setValidators(w, questionETag(question.Version, variant), question.ModifiedAt)
+	h.writeJSON(w, http.StatusOK, question)
+}
+
+// textPatch - тело PATCH-запроса, отсутствующие поля не меняются
+type textPatch struct {
+	Text *string `json:"text"`
+}
+
+// decodeTextPatch читает тело PATCH-запроса, отсутствующий текст передается как пустой
+func (h *Handlers) decodeTextPatch(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var patch textPatch
+	if !h.decodeBody(w, r, &patch) {
+		return "", false
+	}
+	if patch.Text == nil {
+		return "", true
+	}
+	return *patch.Text, true
+}
+
+func (h *Handlers) UpdateQuestion(w http.ResponseWriter, r *http.Request, questionId int) {
+	text, ok := h.decodeTextPatch(w, r)
+	if !ok {
 		return
 	}
+	version, ok := h.ifMatchVersion(w, r, questionId)
+	if !ok {
+		return
+	}
+
+	question, err := h.questionCase.UpdateQuestion(r.Context(), questionId, text, version)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	setValidators(w, questionETag(question.Version, ""), question.ModifiedAt)
+	h.writeJSON(w, http.StatusOK, question)
+}
+
+// acceptRequest - тело запроса принятия ответа
+type acceptRequest struct {
+	AnswerId int `json:"answer_id"`
+}
+
+func (h *Handlers) AcceptAnswer(w http.ResponseWriter, r *http.Request, questionId int) {
+	var accept acceptRequest
+	if !h.decodeBody(w, r, &accept) {
+		return
+	}
+	version, ok := h.ifMatchVersion(w, r, questionId)
+	if !ok {
+		return
+	}
+
+	question, err := h.questionCase.AcceptAnswer(r.Context(), questionId, accept.AnswerId, version)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	setValidators(w, questionETag(question.Version, ""), question.ModifiedAt)
+	h.writeJSON(w, http.StatusOK, question)
+}
+
+func (h *Handlers) UnacceptAnswer(w http.ResponseWriter, r *http.Request, questionId int) {
+	version, ok := h.ifMatchVersion(w, r, questionId)
+	if !ok {
+		return
+	}
+
+	question, err := h.questionCase.UnacceptAnswer(r.Context(), questionId, version)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	setValidators(w, questionETag(question.Version, ""), question.ModifiedAt)
+	h.writeJSON(w, http.StatusOK, question)
 }
 
 func (h *Handlers) DeleteQuestion(w http.ResponseWriter, r *http.Request, questionId int) {
-	if err := h.questionCase.DeleteQuestion(r.Context(), questionId); err != nil {
-		if err.Error() == "question not found" {
-			http.Error(w, "Question not found", http.StatusNotFound)
+	hard := false
+	if rawHard := r.URL.Query().Get("hard"); rawHard != "" {
+		var err error
+		if hard, err = strconv.ParseBool(rawHard); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid hard")
 			return
 		}
-		h.logger.Error("Failed to delete question", zap.Int("id", questionId), zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+
+	version, ok := h.ifMatchVersion(w, r, questionId)
+	if !ok {
+		return
+	}
+
+	deleteQuestion := h.questionCase.DeleteQuestion
+	if hard {
+		deleteQuestion = h.questionCase.PurgeQuestion
+	}
+	if err := deleteQuestion(r.Context(), questionId, version); err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Answer Handlers
+// RestoreQuestion не учитывает If-Match: у вопроса в корзине нет представления и ETag,
+// а удаление уже подняло версию, так что ни один ETag, полученный клиентом, ей не соответствует
+func (h *Handlers) RestoreQuestion(w http.ResponseWriter, r *http.Request, questionId int) {
+	question, err := h.questionCase.RestoreQuestion(r.Context(), questionId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
 
-func (h *Handlers) CreateAnswer(w http.ResponseWriter, r *http.Request, questionId int) {
-	var answer entity.Answer
-	if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
-		h.logger.Error("Failed to decode request body", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	h.writeJSON(w, http.StatusOK, question)
+}
+
+// Search Handlers
+
+func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	hits, err := h.searchCase.Search(r.Context(), query.Get("q"), query.Get("lang"), limit)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, entity.Page[entity.SearchHit]{Items: hits})
+}
+
+// Trash Handlers
+
+func (h *Handlers) GetTrash(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
 		return
 	}
 
-	if answer.Text == "" {
-		http.Error(w, "Text is required", http.StatusBadRequest)
+	trash, err := h.trashCase.GetTrash(r.Context(), limit)
+	if err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	if answer.UserId == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	h.writeJSON(w, http.StatusOK, trash)
+}
+
+// Tag Handlers
+
+// tagRequest - тело запроса создания, переименования тега или добавления синонима
+type tagRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *Handlers) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tagCase.GetTags(r.Context())
+	if err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	answer.QuestionId = questionId
+	h.writeJSON(w, http.StatusOK, tags)
+}
 
-	if err := h.answerCase.CreateAnswer(r.Context(), &answer); err != nil {
-		if err.Error() == "question not found" {
-			http.Error(w, "Question not found", http.StatusNotFound)
-			return
-		}
-		h.logger.Error("Failed to create answer", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+func (h *Handlers) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var request tagRequest
+	if !h.decodeBody(w, r, &request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(answer); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
+	tag, err := h.tagCase.CreateTag(r.Context(), request.Name)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, tag)
+}
+
+func (h *Handlers) GetTag(w http.ResponseWriter, r *http.Request, tagId int) {
+	tag, err := h.tagCase.GetTag(r.Context(), tagId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, tag)
+}
+
+func (h *Handlers) RenameTag(w http.ResponseWriter, r *http.Request, tagId int) {
+	var request tagRequest
+	if !h.decodeBody(w, r, &request) {
+		return
+	}
+
+	tag, err := h.tagCase.RenameTag(r.Context(), tagId, request.Name)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, tag)
+}
+
+func (h *Handlers) AddTagSynonym(w http.ResponseWriter, r *http.Request, tagId int) {
+	var request tagRequest
+	if !h.decodeBody(w, r, &request) {
+		return
+	}
+
+	tag, err := h.tagCase.AddTagSynonym(r.Context(), tagId, request.Name)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, tag)
+}
+
+// Answer Handlers
+
+// createAnswerRequest - тело запроса создания ответа; остальные поля ответа выставляет сервер
+type createAnswerRequest struct {
+	Text string `json:"text"`
+}
+
+func (h *Handlers) CreateAnswer(w http.ResponseWriter, r *http.Request, questionId int) {
+	var request createAnswerRequest
+	if !h.decodeBody(w, r, &request) {
 		return
 	}
+
+	answer := entity.Answer{QuestionId: questionId, Text: request.Text}
+	version, ok := h.ifMatchVersion(w, r, questionId)
+	if !ok {
+		return
+	}
+
+	if err := h.answerCase.CreateAnswer(r.Context(), &answer, version); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, answer)
 }
 
 func (h *Handlers) GetAnswer(w http.ResponseWriter, r *http.Request, answerId int) {
 	answer, err := h.answerCase.GetAnswer(r.Context(), answerId)
 	if err != nil {
-		if err.Error() == "answer not found" {
-			http.Error(w, "Answer not found", http.StatusNotFound)
-			return
-		}
-		h.logger.Error("Failed to get answer", zap.Int("id", answerId), zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(answer); err != nil {
-		h.logger.Error("Failed to encode response", zap.Error(err))
+	h.writeJSON(w, http.StatusOK, answer)
+}
+
+func (h *Handlers) UpdateAnswer(w http.ResponseWriter, r *http.Request, answerId int) {
+	text, ok := h.decodeTextPatch(w, r)
+	if !ok {
 		return
 	}
+
+	answer, err := h.answerCase.UpdateAnswer(r.Context(), answerId, text)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, answer)
 }
 
 func (h *Handlers) DeleteAnswer(w http.ResponseWriter, r *http.Request, answerId int) {
 	if err := h.answerCase.DeleteAnswer(r.Context(), answerId); err != nil {
-		if err.Error() == "answer not found" {
-			http.Error(w, "Answer not found", http.StatusNotFound)
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) RestoreAnswer(w http.ResponseWriter, r *http.Request, answerId int) {
+	answer, err := h.answerCase.RestoreAnswer(r.Context(), answerId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, answer)
+}
+
+func (h *Handlers) GetAnswerRevisions(w http.ResponseWriter, r *http.Request, answerId int) {
+	revisions, err := h.answerCase.GetAnswerRevisions(r.Context(), answerId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, revisions)
+}
+
+// DiffAnswerRevision отдает разницу версии с предыдущей или с версией из ?from=, ?format=unified|word
+func (h *Handlers) DiffAnswerRevision(w http.ResponseWriter, r *http.Request, answerId int, number int) {
+	from := 0
+	if rawFrom := r.URL.Query().Get("from"); rawFrom != "" {
+		var err error
+		if from, err = parseInt(rawFrom); err != nil || from <= 0 {
+			writeProblem(w, r, http.StatusBadRequest, "invalid from")
 			return
 		}
-		h.logger.Error("Failed to delete answer", zap.Int("id", answerId), zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+	format := entity.DiffFormat(r.URL.Query().Get("format"))
+
+	result, err := h.answerCase.DiffAnswerRevision(r.Context(), answerId, number, from, format)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handlers) RollbackAnswer(w http.ResponseWriter, r *http.Request, answerId int, number int) {
+	answer, err := h.answerCase.RollbackAnswer(r.Context(), answerId, number)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, answer)
+}
+
+// Comment Handlers
+
+// commentRequest - тело запроса создания комментария, parent_id задает ответ на комментарий
+type commentRequest struct {
+	Text     string `json:"text"`
+	ParentId *int   `json:"parent_id"`
+}
+
+func (h *Handlers) GetComments(w http.ResponseWriter, r *http.Request, answerId int) {
+	comments, err := h.commentCase.GetComments(r.Context(), answerId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, comments)
+}
+
+func (h *Handlers) CreateComment(w http.ResponseWriter, r *http.Request, answerId int) {
+	var request commentRequest
+	if !h.decodeBody(w, r, &request) {
+		return
+	}
+
+	comment, err := h.commentCase.CreateComment(r.Context(), answerId, request.ParentId, request.Text)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, comment)
+}
+
+func (h *Handlers) DeleteComment(w http.ResponseWriter, r *http.Request, answerId int, commentId int) {
+	if err := h.commentCase.DeleteComment(r.Context(), answerId, commentId); err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// User Handlers
+
+// updateUserRequest - тело запроса изменения профиля, отсутствующее поле не меняется
+type updateUserRequest struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+}
+
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request, userId string) {
+	user, err := h.userCase.GetUser(r.Context(), userId)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, user)
+}
+
+func (h *Handlers) UpdateUser(w http.ResponseWriter, r *http.Request, userId string) {
+	var request updateUserRequest
+	if !h.decodeBody(w, r, &request) {
+		return
+	}
+
+	user, err := h.userCase.UpdateUser(r.Context(), userId, request.DisplayName, request.Bio)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, user)
+}
+
+func (h *Handlers) GetUserAnswers(w http.ResponseWriter, r *http.Request, userId string) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.userCase.GetUserAnswers(r.Context(), userId, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+func (h *Handlers) GetUserQuestions(w http.ResponseWriter, r *http.Request, userId string) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.userCase.GetUserQuestions(r.Context(), userId, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+func (h *Handlers) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	window := entity.LeaderboardWindow(r.URL.Query().Get("window"))
+	leaders, err := h.reputationCase.GetLeaderboard(r.Context(), window, limit)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, leaders)
+}
+
+// voteRequest - тело запроса голосования за ответ, голосующий берется из токена
+type voteRequest struct {
+	Value int `json:"value"`
+}
+
+func (h *Handlers) VoteAnswer(w http.ResponseWriter, r *http.Request, answerId int) {
+	var vote voteRequest
+	if !h.decodeBody(w, r, &vote) {
+		return
+	}
+
+	answer, err := h.answerCase.VoteAnswer(r.Context(), answerId, vote.Value)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, answer)
+}
+
+// decodeBody читает JSON-тело запроса, при ошибке сам отвечает 400
+func (h *Handlers) decodeBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		h.logger.Error("Failed to decode request body", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+// writeJSON отправляет JSON-ответ с указанным статусом
+func (h *Handlers) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Failed to encode response", zap.Error(err))
+	}
+}
+
+// parseLimit читает необязательный параметр limit, при ошибке сам отвечает 400
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	rawLimit := r.URL.Query().Get("limit")
+	if rawLimit == "" {
+		return 0, true
+	}
+	limit, err := parseInt(rawLimit)
+	if err != nil || limit <= 0 {
+		writeProblem(w, r, http.StatusBadRequest, "invalid limit")
+		return 0, false
+	}
+	return limit, true
+}
+
 // extractID извлекает ID из пути
 func extractID(path string, prefix string) string {
 	path = strings.TrimPrefix(path, prefix)
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotent - middleware маршрутов создания: запрос выполняется не более одного раза на заголовок
+// Idempotency-Key, повтор с тем же ключом и телом получает сохраненный ответ. Запрос без заголовка
+// выполняется как обычно. Ответ 5xx не сохраняется, такой запрос можно повторить с тем же ключом.
+// Ключи принадлежат пользователю токена, поэтому анонимный запрос с ключом отклоняется с 401:
+// иначе анонимные клиенты делили бы ключи и получали чужие ответы.
+func (s *Server) idempotent(create http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || s.idempotencyCase == nil {
+			create.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := auth.FromContext(r.Context()); !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			writeProblem(w, r, http.StatusUnauthorized, "Idempotency-Key requires authentication")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := s.idempotencyCase.Begin(r.Context(), key, requestFingerprint(r, body))
+		if err != nil {
+			writeError(w, r, s.logger, err)
+			return
+		}
+		if stored != nil {
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		completed := false
+		defer func() {
+			// Запрос упал или паникует - ключ освобождается, ошибка уже залогирована
+			if !completed {
+				_ = s.idempotencyCase.Release(context.WithoutCancel(r.Context()), key)
+			}
+		}()
+		create.ServeHTTP(recorder, r)
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		// Ответ клиенту уже отправлен; если сохранить его не удалось, ключ остается незавершенным до истечения
+		_ = s.idempotencyCase.Complete(context.WithoutCancel(r.Context()), key,
+			recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		completed = true
+	})
+}
+
+// requestFingerprint - хеш метода, пути и тела: тот же ключ с другим запросом отклоняется
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter передает ответ клиенту и запоминает его для повторов
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPISpec - спецификация OpenAPI 3.1 маршрутов /questions и /answers
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerInitializer заменяет демонстрационный swagger-initializer.js из комплекта Swagger UI,
+// чтобы страница открывала спецификацию сервера
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// openAPIHandler обрабатывает GET /openapi.json
+func openAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeProblem(w, r, http.StatusMethodNotAllowed, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPISpec)
+	})
+}
+
+// swaggerUIHandler отдает страницу Swagger UI на /docs/ из файлов, встроенных в бинарник
+func swaggerUIHandler() http.Handler {
+	files := http.StripPrefix("/docs/", http.FileServerFS(swaggerFiles.FS))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeProblem(w, r, http.StatusMethodNotAllowed, "")
+			return
+		}
+		if r.URL.Path == "/docs/swagger-initializer.js" {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			_, _ = w.Write([]byte(swaggerInitializer))
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadOpenAPI загружает спецификацию с /openapi.json и проверяет ее по стандарту OpenAPI
+func loadOpenAPI(t *testing.T, server *Server) *openapi3.T {
+	t.Helper()
+	w := doAs(t, server, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	// kin-openapi проверяет документ по правилам 3.0, где нет типа null из OpenAPI 3.1, поэтому
+	// проверяется копия без него; сами значения null схемы допускают при разборе ответов
+	validated, err := openapi3.NewLoader().LoadFromData(w.Body.Bytes())
+	require.NoError(t, err)
+	visited := make(map[*openapi3.Schema]bool)
+	for _, schema := range validated.Components.Schemas {
+		withoutNullType(schema, visited)
+	}
+	for _, item := range validated.Paths.Map() {
+		for _, operation := range item.Operations() {
+			if operation.RequestBody != nil {
+				for _, media := range operation.RequestBody.Value.Content {
+					withoutNullType(media.Schema, visited)
+				}
+			}
+		}
+	}
+	require.NoError(t, validated.Validate(context.Background()))
+
+	doc, err := openapi3.NewLoader().LoadFromData(w.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	return doc
+}
+
+// withoutNullType убирает null из списков типов схемы и вложенных в нее схем
+func withoutNullType(ref *openapi3.SchemaRef, visited map[*openapi3.Schema]bool) {
+	schema := ref.Value
+	if schema == nil || visited[schema] {
+		return
+	}
+	visited[schema] = true
+	if schema.Type != nil {
+		types := slices.DeleteFunc(slices.Clone(*schema.Type), func(typ string) bool { return typ == openapi3.TypeNull })
+		schema.Type = &types
+	}
+	for _, property := range schema.Properties {
+		withoutNullType(property, visited)
+	}
+	if schema.Items != nil {
+		withoutNullType(schema.Items, visited)
+	}
+}
+
+// documentedRoute - маршрут, который описывает спецификация
+func documentedRoute(route string) bool {
+	_, path, _ := strings.Cut(route, " ")
+	return strings.HasPrefix(path, "/questions/") || strings.HasPrefix(path, "/answers/")
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	server, _, _ := setupTestServer()
+	doc := loadOpenAPI(t, server)
+
+	var specRoutes []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			specRoutes = append(specRoutes, method+" "+path)
+		}
+	}
+	var serverRoutes []string
+	for _, route := range server.Routes() {
+		if documentedRoute(route) {
+			serverRoutes = append(serverRoutes, route)
+		}
+	}
+	// Каждый маршрут сервера описан в спецификации, и спецификация не описывает лишних
+	assert.ElementsMatch(t, serverRoutes, specRoutes)
+
+	// Каждая описанная операция действительно обрабатывается: сервер не отвечает на нее
+	// 405 или 404 маршрута (у 404 несуществующей записи есть detail)
+	for _, route := range specRoutes {
+		method, path, _ := strings.Cut(route, " ")
+		w := doJSONAs(t, server, method, pathParam.ReplaceAllString(path, "1"), "{}", "alice")
+		assert.NotEqual(t, http.StatusMethodNotAllowed, w.Code, route)
+		if w.Code == http.StatusNotFound {
+			var body problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), route)
+			assert.NotEmpty(t, body.Detail, route)
+		}
+	}
+
+	// Методы, которых нет в спецификации, отклоняются с 405 и списком описанных в Allow
+	for path, item := range doc.Paths.Map() {
+		operations := item.Operations()
+		var allowed []string
+		for _, method := range routeMethods {
+			if operations[method] != nil || method == http.MethodHead && operations[http.MethodGet] != nil {
+				allowed = append(allowed, method)
+			}
+		}
+		for _, method := range routeMethods {
+			if slices.Contains(allowed, method) {
+				continue
+			}
+			w := doJSONAs(t, server, method, pathParam.ReplaceAllString(path, "1"), "{}", "alice")
+			require.Equal(t, http.StatusMethodNotAllowed, w.Code, method+" "+path)
+			assert.Equal(t, strings.Join(allowed, ", "), w.Header().Get("Allow"), method+" "+path)
+		}
+	}
+}
+
+// jsonFields возвращает имена полей структуры в JSON и те из них, что присутствуют всегда
+func jsonFields(typ reflect.Type) (fields []string, required []string) {
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || name == "" {
+			continue
+		}
+		fields = append(fields, name)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	return fields, required
+}
+
+func TestOpenAPISchemasMatchEntities(t *testing.T) {
+	server, _, _ := setupTestServer()
+	doc := loadOpenAPI(t, server)
+
+	types := map[string]reflect.Type{
+		"Question":       reflect.TypeFor[entity.Question](),
+		"Answer":         reflect.TypeFor[entity.Answer](),
+		"Comment":        reflect.TypeFor[entity.Comment](),
+		"Tag":            reflect.TypeFor[entity.Tag](),
+		"AnswerRevision": reflect.TypeFor[entity.AnswerRevision](),
+		"RevisionDiff":   reflect.TypeFor[entity.RevisionDiff](),
+		"QuestionPage":   reflect.TypeFor[entity.Page[entity.Question]](),
+		"Problem":        reflect.TypeFor[problem](),
+	}
+	for name, typ := range types {
+		schemaRef, ok := doc.Components.Schemas[name]
+		require.True(t, ok, name)
+		schema := schemaRef.Value
+
+		fields, required := jsonFields(typ)
+		var properties []string
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+		assert.ElementsMatch(t, fields, properties, name)
+		assert.ElementsMatch(t, required, schema.Required, name)
+	}
+}
+
+func TestOpenAPIResponsesMatchSchemas(t *testing.T) {
+	server, _, _ := setupTestServer()
+	doc := loadOpenAPI(t, server)
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Answer"}`, "bob").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/answers/1/comments", `{"text": "Comment"}`, "alice").Code)
+	require.Equal(t, http.StatusOK, doJSONAs(t, server, http.MethodPut, "/questions/1/accepted-answer", `{"answer_id": 1}`, "alice").Code)
+
+	tests := []struct {
+		url    string
+		path   string
+		status string
+	}{
+		{"/questions/", "/questions/", "200"},
+		{"/questions/1?include=comments", "/questions/{id}", "200"},
+		{"/answers/1", "/answers/{id}", "200"},
+		{"/answers/1/revisions", "/answers/{id}/revisions", "200"},
+		{"/answers/1/revisions/1/diff", "/answers/{id}/revisions/{number}/diff", "200"},
+		{"/answers/1/comments", "/answers/{id}/comments", "200"},
+		{"/answers/42", "/answers/{id}", "404"},
+	}
+	for _, tt := range tests {
+		w := doAs(t, server, http.MethodGet, tt.url, "")
+		require.Equal(t, tt.status, strconv.Itoa(w.Code), tt.url)
+
+		response := doc.Paths.Find(tt.path).Get.Responses.Value(tt.status).Value
+		var content *openapi3.MediaType
+		for _, media := range response.Content {
+			content = media
+		}
+		require.NotNil(t, content, tt.url)
+
+		var body any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tt.url)
+		assert.NoError(t, content.Schema.Value.VisitJSON(body), tt.url)
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	w := doAs(t, server, http.MethodGet, "/docs/", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "swagger-ui")
+
+	// Страница открывает спецификацию сервера, а не демонстрационную
+	w = doAs(t, server, http.MethodGet, "/docs/swagger-initializer.js", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+
+	w = doAs(t, server, http.MethodGet, "/docs/swagger-ui-bundle.js", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
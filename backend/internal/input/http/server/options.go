@@ -0,0 +1,128 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/cases"
+	"HiTalent_TestTask/backend/internal/event"
+	"HiTalent_TestTask/backend/internal/metrics"
+	"HiTalent_TestTask/backend/internal/ratelimit"
+	"net/netip"
+	"time"
+)
+
+// options - необязательные зависимости сервера
+type options struct {
+	searchCase      *cases.SearchCase
+	trashCase       *cases.TrashCase
+	tagCase         *cases.TagCase
+	commentCase     *cases.CommentCase
+	userCase        *cases.UserCase
+	reputationCase  *cases.ReputationCase
+	idempotencyCase *cases.IdempotencyCase
+	authenticator   Authenticator
+	metrics         *metrics.Metrics
+	serveMetrics    bool
+	rateLimiter     *ratelimit.Limiter
+	authRateLimit   ratelimit.Limit
+	trustedProxies  []netip.Prefix
+	eventBroker     *event.Broker
+	streamHeartbeat time.Duration
+}
+
+// Option подключает к серверу дополнительную функциональность
+type Option func(*options)
+
+// WithSearchCase включает GET /search
+func WithSearchCase(searchCase *cases.SearchCase) Option {
+	return func(o *options) {
+		o.searchCase = searchCase
+	}
+}
+
+// WithTrashCase включает GET /admin/trash
+func WithTrashCase(trashCase *cases.TrashCase) Option {
+	return func(o *options) {
+		o.trashCase = trashCase
+	}
+}
+
+// WithTagCase включает управление тегами /tags
+func WithTagCase(tagCase *cases.TagCase) Option {
+	return func(o *options) {
+		o.tagCase = tagCase
+	}
+}
+
+// WithCommentCase включает комментарии /answers/{id}/comments
+func WithCommentCase(commentCase *cases.CommentCase) Option {
+	return func(o *options) {
+		o.commentCase = commentCase
+	}
+}
+
+// WithUserCase включает профили /users и заводит пользователя при первом аутентифицированном запросе
+func WithUserCase(userCase *cases.UserCase) Option {
+	return func(o *options) {
+		o.userCase = userCase
+	}
+}
+
+// WithReputationCase включает GET /leaderboard
+func WithReputationCase(reputationCase *cases.ReputationCase) Option {
+	return func(o *options) {
+		o.reputationCase = reputationCase
+	}
+}
+
+// WithIdempotencyCase включает заголовок Idempotency-Key в POST /questions/ и POST /questions/{id}/answers/
+func WithIdempotencyCase(idempotencyCase *cases.IdempotencyCase) Option {
+	return func(o *options) {
+		o.idempotencyCase = idempotencyCase
+	}
+}
+
+// WithAuthenticator включает проверку bearer-токенов из заголовка Authorization
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithMetrics включает метрики запросов; serveEndpoint отдает их на GET /metrics основного сервера,
+// иначе метрики отдаются отдельно (например, admin-листенером)
+func WithMetrics(m *metrics.Metrics, serveEndpoint bool) Option {
+	return func(o *options) {
+		o.metrics = m
+		o.serveMetrics = serveEndpoint
+	}
+}
+
+// WithRateLimiter включает ограничение частоты запросов: превысившие лимит получают 429
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// WithAuthRateLimit ограничивает по IP запросы с заголовком Authorization еще до проверки токена,
+// чтобы поток невалидных токенов не нагружал проверку подписи и регистрацию пользователей.
+// Ведра хранятся в лимитере из WithRateLimiter, без него или с нулевым limit ограничение не действует.
+func WithAuthRateLimit(limit ratelimit.Limit) Option {
+	return func(o *options) {
+		o.authRateLimit = limit
+	}
+}
+
+// WithTrustedProxies задает прокси, от которых принимается X-Forwarded-For при определении IP клиента
+func WithTrustedProxies(proxies []netip.Prefix) Option {
+	return func(o *options) {
+		o.trustedProxies = proxies
+	}
+}
+
+// WithEventBroker включает поток событий GET /questions/{id}/events с heartbeat раз в heartbeat
+func WithEventBroker(broker *event.Broker, heartbeat time.Duration) Option {
+	return func(o *options) {
+		o.eventBroker = broker
+		o.streamHeartbeat = heartbeat
+	}
+}
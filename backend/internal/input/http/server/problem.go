@@ -0,0 +1,77 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/errs"
+	"HiTalent_TestTask/backend/internal/tracing"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem - тело ответа об ошибке по RFC 7807
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// writeProblem отправляет ответ об ошибке в формате application/problem+json
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// statusForError сопоставляет доменную ошибку HTTP-статусу
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError - единая точка преобразования ошибок cases в HTTP-ответ.
+// Детали внутренних ошибок клиенту не отдаются, только логируются.
+func writeError(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error) {
+	status := statusForError(err)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	}
+	if status == http.StatusInternalServerError {
+		tracing.Fail(trace.SpanFromContext(r.Context()), err)
+		tracing.Logger(r.Context(), logger).Error("Request failed",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+		writeProblem(w, r, status, "")
+		return
+	}
+	writeProblem(w, r, status, errs.Message(err))
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/ratelimit"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// allowRequest списывает токен клиента за запрос и выставляет заголовки RateLimit-*.
+// При исчерпанном лимите сам отвечает 429 с Retry-After и возвращает false.
+// Если хранилище лимитов недоступно, запрос пропускается: лимиты не должны останавливать API.
+func (s *Server) allowRequest(w http.ResponseWriter, r *http.Request, route string) bool {
+	if s.rateLimiter == nil {
+		return true
+	}
+	result, rule, limited, err := s.rateLimiter.Allow(r.Context(), s.rateLimitClient(r), r.Method, route)
+	if err != nil {
+		s.logger.Error("Rate limit store failed, request allowed", zap.String("route", route), zap.Error(err))
+		return true
+	}
+	if !limited {
+		return true
+	}
+
+	header := w.Header()
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.Limit.Requests, ratelimit.Seconds(rule.Limit.Period)))
+	header.Set("RateLimit-Limit", strconv.Itoa(rule.Limit.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(result.Reset)))
+	if result.Allowed {
+		return true
+	}
+	header.Set("Retry-After", strconv.Itoa(ratelimit.Seconds(result.RetryAfter)))
+	writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+	return false
+}
+
+// allowAuthentication списывает токен IP клиента за проверку bearer-токена. Заголовки RateLimit-*
+// здесь не выставляются, чтобы не путать их с лимитом маршрута: клиент видит только 429 с Retry-After.
+func (s *Server) allowAuthentication(w http.ResponseWriter, r *http.Request) bool {
+	if s.rateLimiter == nil || s.authRateLimit.Requests == 0 {
+		return true
+	}
+	result, err := s.rateLimiter.Take(r.Context(), "ip:"+clientIP(r, s.trustedProxies)+"|auth", s.authRateLimit)
+	if err != nil {
+		s.logger.Error("Rate limit store failed, authentication allowed", zap.Error(err))
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(ratelimit.Seconds(result.RetryAfter)))
+	writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+	return false
+}
+
+// rateLimitClient - чьи это запросы: пользователь токена, а для анонимных запросов - IP клиента
+func (s *Server) rateLimitClient(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return "user:" + identity.Subject
+	}
+	return "ip:" + clientIP(r, s.trustedProxies)
+}
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается, только если запрос пришел
+// от доверенного прокси: список читается справа налево, доверенные прокси пропускаются,
+// и клиентом считается первый недоверенный адрес. Подделать можно только адреса левее него.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !trusted(remote, trustedProxies) {
+		return host
+	}
+
+	client := remote
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr
+		if !trusted(addr, trustedProxies) {
+			break
+		}
+	}
+	return client.Unmap().String()
+}
+
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies разбирает список адресов и подсетей доверенных прокси через запятую
+// ("10.0.0.0/8, 192.168.1.10")
+func ParseTrustedProxies(spec string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, raw := range strings.Split(spec, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			addr, err := netip.ParseAddr(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", raw, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", raw, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routeMethods - методы, которые проверяются при поиске разрешенных для пути (заголовок Allow)
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// routeTemplate приводит шаблон маршрута ServeMux к метке для метрик и спанов (/questions/{id}),
+// чтобы она не зависела от конкретных id. Запросы без маршрута сводятся к одному значению "unmatched".
+func routeTemplate(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	_, path, _ := strings.Cut(pattern, " ")
+	if trimmed, exact := strings.CutSuffix(path, "/{$}"); exact {
+		return trimmed
+	}
+	return path
+}
+
+// Routes возвращает маршруты сервера в виде "METHOD /path" в порядке регистрации.
+// По этому списку тесты сверяют спецификацию OpenAPI с кодом.
+func (s *Server) Routes() []string {
+	routes := make([]string, 0, len(s.routes))
+	for _, pattern := range s.routes {
+		routes = append(routes, strings.TrimSuffix(pattern, "{$}"))
+	}
+	return routes
+}
+
+// routeNotFound отвечает на запрос без маршрута: 405 с заголовком Allow, если путь обслуживается
+// другими методами, иначе 404
+func (s *Server) routeNotFound(w http.ResponseWriter, r *http.Request) {
+	if allowed := s.allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	writeProblem(w, r, http.StatusNotFound, "")
+}
+
+// allowedMethods возвращает методы, для которых у пути запроса есть маршрут
+func (s *Server) allowedMethods(r *http.Request) []string {
+	var allowed []string
+	probe := r.Clone(r.Context())
+	for _, method := range routeMethods {
+		probe.Method = method
+		if _, pattern := s.mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
@@ -2,171 +2,246 @@ package server
 
 import (
 	"HiTalent_TestTask/backend/internal/cases"
+	"HiTalent_TestTask/backend/internal/metrics"
+	"HiTalent_TestTask/backend/internal/ratelimit"
+	"HiTalent_TestTask/backend/internal/tracing"
 	"net/http"
-	"strings"
+	"net/netip"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type Server struct {
-	mux    *http.ServeMux
-	logger *zap.Logger
+	mux             *http.ServeMux
+	authenticator   Authenticator
+	userCase        *cases.UserCase        // заводит профиль пользователя токена, nil - профили выключены
+	idempotencyCase *cases.IdempotencyCase // повторы запросов создания по Idempotency-Key, nil - заголовок игнорируется
+	metrics         *metrics.Metrics
+	rateLimiter     *ratelimit.Limiter // nil - запросы не ограничиваются
+	authRateLimit   ratelimit.Limit    // лимит проверок токена с одного IP, нулевой - без ограничения
+	trustedProxies  []netip.Prefix     // прокси, которым можно доверять X-Forwarded-For
+	routes          []string           // шаблоны зарегистрированных маршрутов в порядке регистрации
+	logger          *zap.Logger
 }
 
-func NewServer(questionCase *cases.QuestionCase, answerCase *cases.AnswerCase, logger *zap.Logger) *Server {
+func NewServer(questionCase *cases.QuestionCase, answerCase *cases.AnswerCase, logger *zap.Logger, opts ...Option) *Server {
 	s := &Server{
 		mux:    http.NewServeMux(),
 		logger: logger,
 	}
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s.authenticator = o.authenticator
+	s.userCase = o.userCase
+	s.idempotencyCase = o.idempotencyCase
+	s.metrics = o.metrics
+	s.rateLimiter = o.rateLimiter
+	s.authRateLimit = o.authRateLimit
+	s.trustedProxies = o.trustedProxies
+
 	handlers := NewHandlers(questionCase, answerCase, logger)
+	handlers.searchCase = o.searchCase
+	handlers.trashCase = o.trashCase
+	handlers.tagCase = o.tagCase
+	handlers.commentCase = o.commentCase
+	handlers.userCase = o.userCase
+	handlers.reputationCase = o.reputationCase
+	handlers.eventBroker = o.eventBroker
+	handlers.streamHeartbeat = o.streamHeartbeat
+
+	// Вопросы
+	s.handle("GET /questions/{$}", http.HandlerFunc(handlers.GetQuestionList))
+	s.handle("POST /questions/{$}", http.HandlerFunc(handlers.CreateQuestion), s.idempotent)
+	s.handle("GET /questions/{id}", withID("invalid question ID", handlers.GetQuestion))
+	s.handle("PATCH /questions/{id}", withID("invalid question ID", handlers.UpdateQuestion))
+	// ?hard=true - минуя корзину
+	s.handle("DELETE /questions/{id}", withID("invalid question ID", handlers.DeleteQuestion))
+	s.handle("POST /questions/{id}/answers/{$}", withID("invalid question ID", handlers.CreateAnswer), s.idempotent)
+	s.handle("PUT /questions/{id}/accepted-answer", withID("invalid question ID", handlers.AcceptAnswer))
+	s.handle("DELETE /questions/{id}/accepted-answer", withID("invalid question ID", handlers.UnacceptAnswer))
+	s.handle("POST /questions/{id}/restore", withID("invalid question ID", handlers.RestoreQuestion))
+	if o.eventBroker != nil {
+		s.handle("GET /questions/{id}/events", withID("invalid question ID", handlers.StreamQuestionEvents))
+	}
 
-	// Регистрируем обработчики
-	s.mux.HandleFunc("/questions/", s.questionsHandler(handlers))
-	s.mux.HandleFunc("/answers/", s.answersHandler(handlers))
+	// Ответы
+	s.handle("GET /answers/{id}", withID("invalid answer ID", handlers.GetAnswer))
+	s.handle("PATCH /answers/{id}", withID("invalid answer ID", handlers.UpdateAnswer))
+	s.handle("DELETE /answers/{id}", withID("invalid answer ID", handlers.DeleteAnswer))
+	s.handle("PUT /answers/{id}/vote", withID("invalid answer ID", handlers.VoteAnswer))
+	s.handle("POST /answers/{id}/restore", withID("invalid answer ID", handlers.RestoreAnswer))
+	s.handle("GET /answers/{id}/revisions", withID("invalid answer ID", handlers.GetAnswerRevisions))
+	s.handle("GET /answers/{id}/revisions/{number}/diff", withRevision(handlers.DiffAnswerRevision))
+	s.handle("POST /answers/{id}/revisions/{number}/rollback", withRevision(handlers.RollbackAnswer))
+	if o.commentCase != nil {
+		s.handle("GET /answers/{id}/comments", withID("invalid answer ID", handlers.GetComments))
+		s.handle("POST /answers/{id}/comments", withID("invalid answer ID", handlers.CreateComment))
+		s.handle("DELETE /answers/{id}/comments/{comment_id}", withComment(handlers.DeleteComment))
+	}
+
+	if o.searchCase != nil {
+		s.handle("GET /search", http.HandlerFunc(handlers.Search))
+	}
+	if o.trashCase != nil {
+		s.handle("GET /admin/trash", http.HandlerFunc(handlers.GetTrash))
+	}
+	if o.tagCase != nil {
+		s.handle("GET /tags", http.HandlerFunc(handlers.GetTags))
+		s.handle("POST /tags", http.HandlerFunc(handlers.CreateTag))
+		s.handle("GET /tags/{id}", withID("invalid tag ID", handlers.GetTag))
+		s.handle("PATCH /tags/{id}", withID("invalid tag ID", handlers.RenameTag))
+		s.handle("POST /tags/{id}/synonyms", withID("invalid tag ID", handlers.AddTagSynonym))
+	}
+	if o.userCase != nil {
+		s.handle("GET /users/{id}", withUserID(handlers.GetUser))
+		s.handle("PATCH /users/{id}", withUserID(handlers.UpdateUser))
+		s.handle("GET /users/{id}/answers", withUserID(handlers.GetUserAnswers))
+		s.handle("GET /users/{id}/questions", withUserID(handlers.GetUserQuestions))
+	}
+	if o.reputationCase != nil {
+		s.handle("GET /leaderboard", http.HandlerFunc(handlers.GetLeaderboard))
+	}
+	if o.metrics != nil && o.serveMetrics {
+		s.handle("GET /metrics", o.metrics.Handler())
+	}
+	s.handle("GET /openapi.json", openAPIHandler())
+	s.handle("GET /docs/", swaggerUIHandler())
 
 	return s
 }
 
-// questionsHandler обрабатывает все запросы к /questions/
-func (s *Server) questionsHandler(h *Handlers) http.HandlerFunc {
+// Middleware оборачивает обработчик маршрута
+type Middleware func(http.Handler) http.Handler
+
+// handle регистрирует обработчик маршрута "METHOD /path" (шаблон http.ServeMux) с цепочкой middleware.
+// Первый middleware - внешний: он получает запрос первым.
+func (s *Server) handle(pattern string, handler http.Handler, middlewares ...Middleware) {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	s.mux.Handle(pattern, handler)
+	s.routes = append(s.routes, pattern)
+}
+
+// withID передает обработчику числовой параметр пути {id}, невалидный id - 400 с detail
+func withID(detail string, handle func(http.ResponseWriter, *http.Request, int)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		fullPath := r.URL.Path
-
-		// Проверяем специальный случай: POST /questions/{id}/answers/
-		if strings.Contains(fullPath, "/answers") && r.Method == http.MethodPost {
-			questionID, err := s.extractQuestionIDFromAnswerPath(fullPath)
-			if err != nil {
-				http.Error(w, "Invalid question ID", http.StatusBadRequest)
-				return
-			}
-			h.CreateAnswer(w, r, questionID)
+		id, err := parseInt(r.PathValue("id"))
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, detail)
 			return
 		}
+		handle(w, r, id)
+	}
+}
 
-		// Обрабатываем остальные пути
-		path := strings.TrimPrefix(fullPath, "/questions")
-		path = strings.Trim(path, "/")
-
-		if path == "" {
-			// Путь /questions/ или /questions
-			switch r.Method {
-			case http.MethodGet:
-				// GET /questions/
-				h.GetQuestionList(w, r)
-			case http.MethodPost:
-				// POST /questions/
-				h.CreateQuestion(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
+// withRevision передает обработчику id ответа и номер версии из /answers/{id}/revisions/{number}
+func withRevision(handle func(http.ResponseWriter, *http.Request, int, int)) http.HandlerFunc {
+	return withID("invalid answer ID", func(w http.ResponseWriter, r *http.Request, answerID int) {
+		number, err := parseInt(r.PathValue("number"))
+		if err != nil || number <= 0 {
+			writeProblem(w, r, http.StatusBadRequest, "invalid revision number")
 			return
 		}
+		handle(w, r, answerID, number)
+	})
+}
 
-		// Путь содержит ID: /questions/{id}
-		questionID, err := s.extractID(path)
+// withComment передает обработчику id ответа и комментария из /answers/{id}/comments/{comment_id}
+func withComment(handle func(http.ResponseWriter, *http.Request, int, int)) http.HandlerFunc {
+	return withID("invalid answer ID", func(w http.ResponseWriter, r *http.Request, answerID int) {
+		commentID, err := parseInt(r.PathValue("comment_id"))
 		if err != nil {
-			http.Error(w, "Invalid question ID", http.StatusBadRequest)
+			writeProblem(w, r, http.StatusBadRequest, "invalid comment ID")
 			return
 		}
-
-		switch r.Method {
-		case http.MethodGet:
-			// GET /questions/{id}
-			h.GetQuestion(w, r, questionID)
-		case http.MethodDelete:
-			// DELETE /questions/{id}
-			h.DeleteQuestion(w, r, questionID)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
+		handle(w, r, answerID, commentID)
+	})
 }
 
-// answersHandler обрабатывает все запросы к /answers/
-func (s *Server) answersHandler(h *Handlers) http.HandlerFunc {
+// withUserID передает обработчику id пользователя. Это строка из токена, экранированный "/"
+// в ней остается частью id: ServeMux сопоставляет сегменты пути до декодирования.
+func withUserID(handle func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/answers")
-		path = strings.Trim(path, "/")
-
-		answerID, err := s.extractID(path)
-		if err != nil {
-			http.Error(w, "Invalid answer ID", http.StatusBadRequest)
+		userID := r.PathValue("id")
+		if userID == "" {
+			writeProblem(w, r, http.StatusBadRequest, "invalid user ID")
 			return
 		}
-
-		switch r.Method {
-		case http.MethodGet:
-			// GET /answers/{id}
-			h.GetAnswer(w, r, answerID)
-
-		case http.MethodDelete:
-			// DELETE /answers/{id}
-			h.DeleteAnswer(w, r, answerID)
-
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}
-}
-
-// extractID извлекает ID из пути
-func (s *Server) extractID(path string) (int, error) {
-	if path == "" {
-		return 0, http.ErrMissingFile
+		handle(w, r, userID)
 	}
-	// Берем первую часть пути как ID
-	parts := strings.Split(path, "/")
-	return parseInt(parts[0])
 }
 
-// extractQuestionIDFromAnswerPath извлекает ID вопроса из пути вида /questions/{id}/answers/ или /questions/{id}/answers
-func (s *Server) extractQuestionIDFromAnswerPath(fullPath string) (int, error) {
-	// Убираем префикс /questions/
-	path := strings.TrimPrefix(fullPath, "/questions/")
-	// Убираем суффикс /answers/ или /answers
-	path = strings.TrimSuffix(path, "/answers/")
-	path = strings.TrimSuffix(path, "/answers")
-	path = strings.Trim(path, "/")
+// ServeHTTP реализует http.Handler с middleware для логирования и recovery
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	_, pattern := s.mux.Handler(r)
+	route := routeTemplate(pattern)
 
-	// Берем первую часть как ID вопроса
-	parts := strings.Split(path, "/")
-	if len(parts) == 0 || parts[0] == "" {
-		return 0, http.ErrMissingFile
+	// Обертка для ResponseWriter для отслеживания статус-кода
+	wrapped := &responseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
 	}
 
-	return parseInt(parts[0])
-}
+	// Метрики и спан закрываются последними, чтобы учесть статус после восстановления от паники
+	if s.metrics != nil {
+		finished := s.metrics.RequestStarted()
+		defer func() {
+			finished(route, r.Method, wrapped.statusCode, time.Since(start))
+		}()
+	}
 
-// ServeHTTP реализует http.Handler с middleware для логирования и recovery
-func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
+	// Серверный спан продолжает трассу из заголовка traceparent, если он есть
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header)), r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+		),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+		span.End()
+	}()
+	r = r.WithContext(ctx)
 
 	// Recovery middleware
 	defer func() {
 		if err := recover(); err != nil {
-			s.logger.Error("Panic recovered",
+			tracing.Logger(ctx, s.logger).Error("Panic recovered",
 				zap.Any("error", err),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 			)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeProblem(wrapped, r, http.StatusInternalServerError, "")
 		}
 	}()
 
-	// Обертка для ResponseWriter для отслеживания статус-кода
-	wrapped := &responseWriter{
-		ResponseWriter: w,
-		statusCode:     http.StatusOK,
+	// Обрабатываем запрос; лимит считается после аутентификации, чтобы учитывать пользователя, а не IP
+	if authenticated, ok := s.authenticate(wrapped, r); ok && s.allowRequest(wrapped, authenticated, route) {
+		if pattern == "" {
+			s.routeNotFound(wrapped, authenticated)
+		} else {
+			s.mux.ServeHTTP(wrapped, authenticated)
+		}
 	}
 
-	// Обрабатываем запрос
-	s.mux.ServeHTTP(wrapped, r)
-
 	// Логирование после обработки
 	duration := time.Since(start)
-	s.logger.Info("HTTP request",
+	tracing.Logger(ctx, s.logger).Info("HTTP request",
 		zap.String("method", r.Method),
 		zap.String("path", r.URL.Path),
 		zap.Int("status", wrapped.statusCode),
@@ -184,3 +259,8 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap открывает исходный ResponseWriter для http.ResponseController (Flush, дедлайны в потоке событий)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -2,30 +2,133 @@ package server
 
 import (
 	"HiTalent_TestTask/backend/internal/adapter/repo/memory"
+	"HiTalent_TestTask/backend/internal/auth"
 	"HiTalent_TestTask/backend/internal/cases"
 	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/event"
+	"HiTalent_TestTask/backend/internal/metrics"
+	"HiTalent_TestTask/backend/internal/policy"
+	"HiTalent_TestTask/backend/internal/ratelimit"
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func setupTestServer() (*Server, *memory.QuestionRepo, *memory.AnswerRepo) {
-	logger := zap.NewNop()
+	env := newTestEnv(zap.NewNop())
+	return env.server, env.questionRepo, env.answerRepo
+}
+
+// testEnv - сервер вместе с зависимостями, к которым тестам нужен прямой доступ
+type testEnv struct {
+	server         *Server
+	questionRepo   *memory.QuestionRepo
+	answerRepo     *memory.AnswerRepo
+	reputationCase *cases.ReputationCase
+	eventBroker    *event.Broker
+}
+
+// newTestEnv собирает сервер со всеми cases; opts добавляются к стандартным опциям
+func newTestEnv(logger *zap.Logger, opts ...Option) testEnv {
 	questionRepo := memory.NewQuestionRepo()
 	answerRepo := memory.NewAnswerRepo(questionRepo)
+	tagRepo := memory.NewTagRepo(questionRepo)
+	commentRepo := memory.NewCommentRepo(answerRepo)
 
-	questionCase := cases.NewQuestionCase(questionRepo, logger)
-	answerCase := cases.NewAnswerCase(answerRepo, logger)
+	accessPolicy, err := policy.Default()
+	if err != nil {
+		panic(err)
+	}
+	userRepo := memory.NewUserRepo(answerRepo)
+	reputationCase := cases.NewReputationCase(memory.NewReputationRepo(userRepo), testReputationWeights, logger)
+	bus := event.NewBus()
+	bus.Subscribe(reputationCase.HandleEvent)
+	appMetrics := metrics.New()
+	bus.Subscribe(appMetrics.HandleEvent)
+	eventBroker := event.NewBroker(testSSEReplaySize, testSSEClientBuffer)
+	bus.Subscribe(eventBroker.HandleEvent)
+
+	questionCase := cases.NewQuestionCase(questionRepo, tagRepo, commentRepo, accessPolicy, bus, logger)
+	answerCase := cases.NewAnswerCase(answerRepo, accessPolicy, bus, logger)
+	searchCase := cases.NewSearchCase(memory.NewSearchRepo(questionRepo), "simple", logger)
+
+	verifier := auth.NewVerifier("", "")
+	verifier.AddHMACKey("", []byte(testJWTSecret))
+
+	trashCase := cases.NewTrashCase(memory.NewTrashRepo(questionRepo), accessPolicy, time.Hour, logger)
+
+	server := NewServer(questionCase, answerCase, logger, append([]Option{
+		WithSearchCase(searchCase),
+		WithTrashCase(trashCase),
+		WithTagCase(cases.NewTagCase(tagRepo, accessPolicy, logger)),
+		WithCommentCase(cases.NewCommentCase(commentRepo, accessPolicy, bus, testCommentMaxDepth, logger)),
+		WithUserCase(cases.NewUserCase(userRepo, accessPolicy, logger)),
+		WithReputationCase(reputationCase),
+		WithIdempotencyCase(cases.NewIdempotencyCase(memory.NewIdempotencyRepo(), time.Hour, logger)),
+		WithAuthenticator(verifier),
+		WithMetrics(appMetrics, true),
+		WithEventBroker(eventBroker, testSSEHeartbeat),
+	}, opts...)...)
+	return testEnv{
+		server:         server,
+		questionRepo:   questionRepo,
+		answerRepo:     answerRepo,
+		reputationCase: reputationCase,
+		eventBroker:    eventBroker,
+	}
+}
 
-	server := NewServer(questionCase, answerCase, logger)
-	return server, questionRepo, answerRepo
+const (
+	testJWTSecret       = "test-secret"
+	testCommentMaxDepth = 2
+	testSSEReplaySize   = 4
+	testSSEClientBuffer = 8
+	testSSEHeartbeat    = 20 * time.Millisecond
+)
+
+var testReputationWeights = entity.ReputationWeights{Upvote: 10, DownvotePenalty: 2, Accepted: 15, Accept: 2}
+
+// bearer возвращает значение заголовка Authorization с токеном HS256 для пользователя
+func bearer(t *testing.T, subject string, roles ...string) string {
+	t.Helper()
+	return signedBearer(t, map[string]any{
+		"sub":   subject,
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+// signedBearer подписывает произвольные claims тестовым секретом
+func signedBearer(t *testing.T, payload map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT"})
+	claims, _ := json.Marshal(payload)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signingInput))
+	return "Bearer " + signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
 
 func TestGetQuestionList(t *testing.T) {
@@ -51,10 +154,11 @@ func TestGetQuestionList(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 
-	var questions []entity.Question
-	err := json.Unmarshal(w.Body.Bytes(), &questions)
+	var page entity.Page[entity.Question]
+	err := json.Unmarshal(w.Body.Bytes(), &page)
 	require.NoError(t, err)
-	assert.Len(t, questions, 2)
+	assert.Len(t, page.Items, 2)
+	assert.Empty(t, page.NextCursor)
 }
 
 func TestGetQuestionListEmpty(t *testing.T) {
@@ -67,10 +171,66 @@ func TestGetQuestionListEmpty(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var questions []entity.Question
-	err := json.Unmarshal(w.Body.Bytes(), &questions)
+	var page entity.Page[entity.Question]
+	err := json.Unmarshal(w.Body.Bytes(), &page)
 	require.NoError(t, err)
-	assert.Len(t, questions, 0)
+	assert.NotNil(t, page.Items)
+	assert.Len(t, page.Items, 0)
+}
+
+func TestGetQuestionListPagination(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+
+	for i := 1; i <= 5; i++ {
+		questionRepo.SetQuestionForTesting(&entity.Question{
+			Id:   i,
+			Text: "Test Question",
+		})
+	}
+
+	// Обходим все страницы по курсору
+	var ids []int
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		url := "/questions/?limit=2"
+		if cursor != "" {
+			url += "&cursor=" + cursor
+		}
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page entity.Page[entity.Question]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.LessOrEqual(t, len(page.Items), 2)
+		for _, question := range page.Items {
+			ids = append(ids, question.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+}
+
+func TestGetQuestionListInvalidParams(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	for _, url := range []string{
+		"/questions/?limit=abc",
+		"/questions/?limit=-1",
+		"/questions/?cursor=not-a-cursor",
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
 }
 
 func TestCreateQuestion(t *testing.T) {
@@ -159,6 +319,7 @@ func TestDeleteQuestion(t *testing.T) {
 	questionRepo.SetQuestionForTesting(question)
 
 	req := httptest.NewRequest(http.MethodDelete, "/questions/1", nil)
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
 	w := httptest.NewRecorder()
 
 	server.ServeHTTP(w, req)
@@ -176,6 +337,7 @@ func TestDeleteQuestionNotFound(t *testing.T) {
 	server, _, _ := setupTestServer()
 
 	req := httptest.NewRequest(http.MethodDelete, "/questions/999", nil)
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
 	w := httptest.NewRecorder()
 
 	server.ServeHTTP(w, req)
@@ -200,6 +362,7 @@ func TestCreateAnswer(t *testing.T) {
 	body, _ := json.Marshal(answer)
 
 	req := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -217,6 +380,27 @@ func TestCreateAnswer(t *testing.T) {
 	assert.NotZero(t, createdAnswer.ID)
 }
 
+func TestCreateAnswerIgnoresServerFields(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+
+	// Поля, которые выставляет сервер, из тела не берутся: ответ не попадает сразу в корзину
+	w := doJSONAs(t, server, http.MethodPost, "/questions/1/answers/",
+		`{"id": 42, "text": "Answer", "score": 100, "deleted_at": "2024-01-01T00:00:00Z", "created_at": "2000-01-01T00:00:00Z"}`, "bob")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created entity.Answer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, 1, created.ID)
+	assert.Zero(t, created.Score)
+	assert.False(t, created.DeletedAt.Valid)
+	assert.True(t, created.CreatedAt.After(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	assert.Equal(t, http.StatusOK, doAs(t, server, http.MethodGet, "/answers/1", "").Code)
+	w = doAs(t, server, http.MethodGet, "/questions/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"text":"Answer"`)
+}
+
 func TestCreateAnswerQuestionNotFound(t *testing.T) {
 	server, _, _ := setupTestServer()
 
@@ -227,6 +411,7 @@ func TestCreateAnswerQuestionNotFound(t *testing.T) {
 	body, _ := json.Marshal(answer)
 
 	req := httptest.NewRequest(http.MethodPost, "/questions/999/answers/", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -251,6 +436,7 @@ func TestCreateAnswerEmptyText(t *testing.T) {
 	body, _ := json.Marshal(answer)
 
 	req := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -259,7 +445,7 @@ func TestCreateAnswerEmptyText(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-func TestCreateAnswerEmptyUserId(t *testing.T) {
+func TestCreateAnswerUnauthenticated(t *testing.T) {
 	server, questionRepo, _ := setupTestServer()
 
 	question := &entity.Question{
@@ -269,7 +455,7 @@ func TestCreateAnswerEmptyUserId(t *testing.T) {
 	questionRepo.SetQuestionForTesting(question)
 
 	answer := entity.Answer{
-		UserId: "",
+		UserId: "user-123",
 		Text:   "Test Answer",
 	}
 	body, _ := json.Marshal(answer)
@@ -280,7 +466,8 @@ func TestCreateAnswerEmptyUserId(t *testing.T) {
 
 	server.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
 }
 
 func TestGetAnswer(t *testing.T) {
@@ -349,6 +536,7 @@ func TestDeleteAnswer(t *testing.T) {
 	answerRepo.SetAnswerForTesting(answer)
 
 	req := httptest.NewRequest(http.MethodDelete, "/answers/1", nil)
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	w := httptest.NewRecorder()
 
 	server.ServeHTTP(w, req)
@@ -366,6 +554,7 @@ func TestDeleteAnswerNotFound(t *testing.T) {
 	server, _, _ := setupTestServer()
 
 	req := httptest.NewRequest(http.MethodDelete, "/answers/999", nil)
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	w := httptest.NewRecorder()
 
 	server.ServeHTTP(w, req)
@@ -468,6 +657,7 @@ func TestMultipleAnswersSameUser(t *testing.T) {
 	body1, _ := json.Marshal(answer1)
 
 	req1 := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBuffer(body1))
+	req1.Header.Set("Authorization", bearer(t, "user-123"))
 	req1.Header.Set("Content-Type", "application/json")
 	w1 := httptest.NewRecorder()
 
@@ -482,6 +672,7 @@ func TestMultipleAnswersSameUser(t *testing.T) {
 	body2, _ := json.Marshal(answer2)
 
 	req2 := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBuffer(body2))
+	req2.Header.Set("Authorization", bearer(t, "user-123"))
 	req2.Header.Set("Content-Type", "application/json")
 	w2 := httptest.NewRecorder()
 
@@ -520,6 +711,7 @@ func TestCreateAnswerInvalidJSON(t *testing.T) {
 	questionRepo.SetQuestionForTesting(question)
 
 	req := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBufferString("invalid json"))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -566,6 +758,7 @@ func TestAnswerCreatedAt(t *testing.T) {
 	body, _ := json.Marshal(answer)
 
 	req := httptest.NewRequest(http.MethodPost, "/questions/1/answers/", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -654,6 +847,7 @@ func TestDeleteQuestionCascadeAnswers(t *testing.T) {
 
 	// Удаляем вопрос
 	req := httptest.NewRequest(http.MethodDelete, "/questions/1", nil)
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
 	w := httptest.NewRecorder()
 	server.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusNoContent, w.Code)
@@ -664,6 +858,1528 @@ func TestDeleteQuestionCascadeAnswers(t *testing.T) {
 	server.ServeHTTP(getW, getReq)
 	assert.Equal(t, http.StatusNotFound, getW.Code)
 
-	// Примечание: В реальной БД ответы удаляются каскадно, но в in-memory репозитории
-	// мы не реализуем каскадное удаление, так как это требует дополнительной логики
+	// Ответы удалены каскадно
+	for _, url := range []string{"/answers/1", "/answers/2"} {
+		answerReq := httptest.NewRequest(http.MethodGet, url, nil)
+		answerW := httptest.NewRecorder()
+		server.ServeHTTP(answerW, answerReq)
+		assert.Equal(t, http.StatusNotFound, answerW.Code, url)
+	}
+}
+
+func TestUpdateQuestion(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+
+	createdAt := time.Now().Add(-time.Hour)
+	questionRepo.SetQuestionForTesting(&entity.Question{
+		Id:        1,
+		Text:      "Tset Question",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/questions/1", bytes.NewBufferString(`{"text": "Test Question"}`))
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updatedQuestion entity.Question
+	err := json.Unmarshal(w.Body.Bytes(), &updatedQuestion)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Question", updatedQuestion.Text)
+	assert.True(t, updatedQuestion.UpdatedAt.After(createdAt))
+}
+
+func TestUpdateQuestionNotFound(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPatch, "/questions/999", bytes.NewBufferString(`{"text": "Test Question"}`))
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateQuestionEmptyText(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{
+		Id:   1,
+		Text: "Test Question",
+	})
+
+	for _, body := range []string{`{}`, `{"text": ""}`} {
+		req := httptest.NewRequest(http.MethodPatch, "/questions/1", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestUpdateAnswer(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{
+		Id:   1,
+		Text: "Test Question",
+	})
+	answerRepo.SetAnswerForTesting(&entity.Answer{
+		ID:         1,
+		QuestionId: 1,
+		UserId:     "user-123",
+		Text:       "Tset Answer",
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/answers/1", bytes.NewBufferString(`{"text": "Test Answer"}`))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updatedAnswer entity.Answer
+	err := json.Unmarshal(w.Body.Bytes(), &updatedAnswer)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Answer", updatedAnswer.Text)
+	assert.Equal(t, "user-123", updatedAnswer.UserId)
+	assert.NotZero(t, updatedAnswer.UpdatedAt)
+
+	// Ответ остается привязанным к вопросу
+	getReq := httptest.NewRequest(http.MethodGet, "/questions/1", nil)
+	getW := httptest.NewRecorder()
+	server.ServeHTTP(getW, getReq)
+
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(getW.Body.Bytes(), &question))
+	require.Len(t, question.Answers, 1)
+	assert.Equal(t, "Test Answer", question.Answers[0].Text)
+}
+
+func TestUpdateAnswerNotFound(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodPatch, "/answers/999", bytes.NewBufferString(`{"text": "Test Answer"}`))
+	req.Header.Set("Authorization", bearer(t, "user-123"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestErrorResponsesAreProblemJSON(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	require.NoError(t, questionRepo.CreateQuestion(context.Background(), &entity.Question{Text: "Question"}))
+
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/questions/999", "", http.StatusNotFound},
+		{http.MethodDelete, "/answers/999", "", http.StatusNotFound},
+		{http.MethodDelete, "/questions/1", "", http.StatusUnauthorized},
+		{http.MethodPost, "/questions/", `{"text": ""}`, http.StatusBadRequest},
+		{http.MethodPost, "/questions/", "invalid json", http.StatusBadRequest},
+		{http.MethodGet, "/questions/abc", "", http.StatusBadRequest},
+		{http.MethodPut, "/questions/", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		require.Equal(t, tt.status, w.Code, tt.url)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"), tt.url)
+
+		var body problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tt.url)
+		assert.Equal(t, tt.status, body.Status, tt.url)
+		assert.Equal(t, http.StatusText(tt.status), body.Title, tt.url)
+	}
+}
+
+func TestNotFoundProblemDetail(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/questions/999", nil)
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	var body problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "question 999 not found", body.Detail)
+	assert.Equal(t, "/questions/999", body.Instance)
+}
+
+func TestSearch(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Как установить Go?"})
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 2, Text: "Индексы в Postgres"})
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 3, Text: "Docker networking"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{
+		ID:         1,
+		QuestionId: 2,
+		UserId:     "user-1",
+		Text:       "Используйте драйвер pgx для Go",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=go", nil)
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var page entity.Page[entity.SearchHit]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, 1, page.Items[0].Id)
+	assert.Equal(t, 2, page.Items[1].Id)
+	assert.Greater(t, page.Items[0].Rank, page.Items[1].Rank)
+}
+
+func TestSearchInvalidParams(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	for _, url := range []string{
+		"/search",
+		"/search?q=go&lang=klingon",
+		"/search?q=go&limit=0",
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func voteForAnswer(t *testing.T, server *Server, answerId int, userId string, value int) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"value": value})
+	req := httptest.NewRequest(http.MethodPut, "/answers/"+strconv.Itoa(answerId)+"/vote", bytes.NewBuffer(body))
+	if userId != "" {
+		req.Header.Set("Authorization", bearer(t, userId))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestVoteAnswer(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Answer"})
+
+	w := voteForAnswer(t, server, 1, "user-1", 1)
+	require.Equal(t, http.StatusOK, w.Code)
+	var answer entity.Answer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+	assert.Equal(t, 1, answer.Score)
+
+	// Пользователь может изменить голос, но не проголосовать дважды
+	voteForAnswer(t, server, 1, "user-1", 1)
+	w = voteForAnswer(t, server, 1, "user-1", -1)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+	assert.Equal(t, -1, answer.Score)
+}
+
+func TestVoteAnswerInvalid(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Answer"})
+
+	assert.Equal(t, http.StatusBadRequest, voteForAnswer(t, server, 1, "user-1", 2).Code)
+	assert.Equal(t, http.StatusUnauthorized, voteForAnswer(t, server, 1, "", 1).Code)
+	assert.Equal(t, http.StatusNotFound, voteForAnswer(t, server, 999, "user-1", 1).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/answers/1/vote", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestGetQuestionAnswerSort(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	now := time.Now()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "user-1", Text: "Old", CreatedAt: now.Add(-2 * time.Hour)})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 2, QuestionId: 1, UserId: "user-2", Text: "Middle", CreatedAt: now.Add(-time.Hour)})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 3, QuestionId: 1, UserId: "user-3", Text: "New", CreatedAt: now})
+
+	voteForAnswer(t, server, 2, "voter-1", 1)
+	voteForAnswer(t, server, 2, "voter-2", 1)
+	voteForAnswer(t, server, 3, "voter-1", 1)
+	voteForAnswer(t, server, 1, "voter-1", -1)
+
+	tests := map[string][]int{
+		"":       {1, 2, 3},
+		"oldest": {1, 2, 3},
+		"newest": {3, 2, 1},
+		"score":  {2, 3, 1},
+	}
+	for sort, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/questions/1?sort="+sort, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, sort)
+
+		var question entity.Question
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+		var got []int
+		for _, answer := range question.Answers {
+			got = append(got, answer.ID)
+		}
+		assert.Equal(t, want, got, sort)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/questions/1?sort=random", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func acceptAnswer(t *testing.T, server *Server, questionId int, answerId int) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"answer_id": answerId})
+	req := httptest.NewRequest(http.MethodPut, "/questions/"+strconv.Itoa(questionId)+"/accepted-answer", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestAcceptAnswer(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "user-1", Text: "Answer 1"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 2, QuestionId: 1, UserId: "user-2", Text: "Answer 2"})
+
+	w := acceptAnswer(t, server, 1, 1)
+	require.Equal(t, http.StatusOK, w.Code)
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	require.NotNil(t, question.AcceptedAnswerId)
+	assert.Equal(t, 1, *question.AcceptedAnswerId)
+
+	// Принятым может быть только один ответ
+	w = acceptAnswer(t, server, 1, 2)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	require.NotNil(t, question.AcceptedAnswerId)
+	assert.Equal(t, 2, *question.AcceptedAnswerId)
+
+	req := httptest.NewRequest(http.MethodDelete, "/questions/1/accepted-answer", nil)
+	req.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	question = entity.Question{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	assert.Nil(t, question.AcceptedAnswerId)
+
+	// Вопрос при этом не удаляется
+	getReq := httptest.NewRequest(http.MethodGet, "/questions/1", nil)
+	getW := httptest.NewRecorder()
+	server.ServeHTTP(getW, getReq)
+	assert.Equal(t, http.StatusOK, getW.Code)
+}
+
+func TestAcceptAnswerFromOtherQuestion(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 2, Text: "Other Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 2, UserId: "user-1", Text: "Answer"})
+
+	assert.Equal(t, http.StatusBadRequest, acceptAnswer(t, server, 1, 1).Code)
+	assert.Equal(t, http.StatusBadRequest, acceptAnswer(t, server, 1, 999).Code)
+	assert.Equal(t, http.StatusNotFound, acceptAnswer(t, server, 999, 1).Code)
+}
+
+func TestDeleteAcceptedAnswerClearsFlag(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "user-1", Text: "Answer"})
+	require.Equal(t, http.StatusOK, acceptAnswer(t, server, 1, 1).Code)
+
+	req := httptest.NewRequest(http.MethodDelete, "/answers/1", nil)
+	req.Header.Set("Authorization", bearer(t, "user-1"))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	getReq := httptest.NewRequest(http.MethodGet, "/questions/1", nil)
+	getW := httptest.NewRecorder()
+	server.ServeHTTP(getW, getReq)
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(getW.Body.Bytes(), &question))
+	assert.Nil(t, question.AcceptedAnswerId)
+}
+
+func TestCreateAnswerAuthorFromToken(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+
+	req := httptest.NewRequest(http.MethodPost, "/questions/1/answers/",
+		bytes.NewBufferString(`{"user_id": "someone-else", "text": "Answer"}`))
+	req.Header.Set("Authorization", bearer(t, "user-1"))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var answer entity.Answer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+	assert.Equal(t, "user-1", answer.UserId)
+}
+
+func TestInvalidToken(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+
+	for _, header := range []string{
+		"Bearer not-a-token",
+		"Basic dXNlcjpwYXNz",
+		bearer(t, "user-1") + "x",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/questions/1", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"), header)
+	}
+}
+
+func TestAnswerOwnership(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Answer 1"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 2, QuestionId: 1, UserId: "author", Text: "Answer 2"})
+
+	patch := httptest.NewRequest(http.MethodPatch, "/answers/1", bytes.NewBufferString(`{"text": "Hijacked"}`))
+	patch.Header.Set("Authorization", bearer(t, "intruder"))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, patch)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	del := httptest.NewRequest(http.MethodDelete, "/answers/1", nil)
+	del.Header.Set("Authorization", bearer(t, "intruder"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, del)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	del = httptest.NewRequest(http.MethodDelete, "/answers/1", nil)
+	del.Header.Set("Authorization", bearer(t, "author"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, del)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	del = httptest.NewRequest(http.MethodDelete, "/answers/2", nil)
+	del.Header.Set("Authorization", bearer(t, "root", "admin"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, del)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestQuestionModerationRequiresRole(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+
+	del := httptest.NewRequest(http.MethodDelete, "/questions/1", nil)
+	del.Header.Set("Authorization", bearer(t, "user-1"))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, del)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	patch := httptest.NewRequest(http.MethodPatch, "/questions/1", bytes.NewBufferString(`{"text": "Changed"}`))
+	patch.Header.Set("Authorization", bearer(t, "user-1"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, patch)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	del = httptest.NewRequest(http.MethodDelete, "/questions/1", nil)
+	del.Header.Set("Authorization", bearer(t, "moderator-1", "moderator"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, del)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+// doAs выполняет запрос от имени пользователя с ролями; пустой subject - анонимный запрос
+func doAs(t *testing.T, server *Server, method string, url string, subject string, roles ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, url, nil)
+	if subject != "" {
+		req.Header.Set("Authorization", bearer(t, subject, roles...))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestRestoreQuestion(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "user-1", Text: "Answer"})
+
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1", "mod", "moderator").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/questions/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/answers/1", "").Code)
+
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodPost, "/questions/1/restore", "user-1").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, doAs(t, server, http.MethodGet, "/questions/1/restore", "mod", "moderator").Code)
+
+	w := doAs(t, server, http.MethodPost, "/questions/1/restore", "mod", "moderator")
+	require.Equal(t, http.StatusOK, w.Code)
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	require.Len(t, question.Answers, 1)
+	assert.Equal(t, 1, question.Answers[0].ID)
+	assert.NotContains(t, w.Body.String(), "deleted_at")
+
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodPost, "/questions/1/restore", "mod", "moderator").Code)
+}
+
+func TestRestoreAnswer(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "user-1", Text: "Answer"})
+
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1", "user-1").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodPost, "/answers/1/restore", "user-1").Code)
+	assert.Equal(t, http.StatusOK, doAs(t, server, http.MethodPost, "/answers/1/restore", "mod", "moderator").Code)
+	assert.Equal(t, http.StatusOK, doAs(t, server, http.MethodGet, "/answers/1", "").Code)
+
+	// Ответ удаленного вопроса восстанавливается вместе с вопросом
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1", "user-1").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1", "mod", "moderator").Code)
+	assert.Equal(t, http.StatusConflict, doAs(t, server, http.MethodPost, "/answers/1/restore", "mod", "moderator").Code)
+}
+
+func TestHardDeleteQuestion(t *testing.T) {
+	server, questionRepo, _ := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodDelete, "/questions/1?hard=maybe", "root", "admin").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodDelete, "/questions/1?hard=true", "mod", "moderator").Code)
+	assert.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1?hard=true", "root", "admin").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodPost, "/questions/1/restore", "root", "admin").Code)
+}
+
+func TestGetTrash(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Deleted"})
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 2, Text: "Live"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 2, UserId: "user-1", Text: "Answer"})
+
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1", "mod", "moderator").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1", "user-1").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, doAs(t, server, http.MethodGet, "/admin/trash", "").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodGet, "/admin/trash", "mod", "moderator").Code)
+
+	w := doAs(t, server, http.MethodGet, "/admin/trash", "root", "admin")
+	require.Equal(t, http.StatusOK, w.Code)
+	var trash entity.Trash
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	require.Len(t, trash.Questions, 1)
+	assert.Equal(t, 1, trash.Questions[0].Id)
+	assert.True(t, trash.Questions[0].DeletedAt.Valid)
+	require.Len(t, trash.Answers, 1)
+	assert.Equal(t, 1, trash.Answers[0].ID)
+}
+
+// editAnswer меняет текст ответа от имени пользователя
+func editAnswer(t *testing.T, server *Server, answerId int, subject string, text string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/answers/%d", answerId), bytes.NewBufferString(fmt.Sprintf(`{"text": %q}`, text)))
+	req.Header.Set("Authorization", bearer(t, subject))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAnswerRevisions(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Go is a compiled language"})
+	editAnswer(t, server, 1, "author", "Go is a statically typed language")
+
+	w := doAs(t, server, http.MethodGet, "/answers/1/revisions", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var revisions []entity.AnswerRevision
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.Equal(t, "author", revisions[1].EditorId)
+
+	w = doAs(t, server, http.MethodGet, "/answers/1/revisions/2/diff", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var result entity.RevisionDiff
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, entity.RevisionDiff{
+		AnswerId: 1,
+		From:     1,
+		To:       2,
+		Format:   entity.DiffFormatUnified,
+		Diff: "--- revision 1\n+++ revision 2\n@@ -1 +1 @@\n" +
+			"-Go is a compiled language\n+Go is a statically typed language\n",
+	}, result)
+
+	w = doAs(t, server, http.MethodGet, "/answers/1/revisions/2/diff?format=word", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "Go is a [-compiled-]{+statically typed+} language", result.Diff)
+
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/answers/1/revisions/2/diff?format=html", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/answers/1/revisions/x/diff", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/answers/1/revisions/3/diff", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/answers/999/revisions", "").Code)
+}
+
+func TestRollbackAnswer(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Original"})
+	editAnswer(t, server, 1, "author", "Vandalized")
+
+	assert.Equal(t, http.StatusUnauthorized, doAs(t, server, http.MethodPost, "/answers/1/revisions/1/rollback", "").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodPost, "/answers/1/revisions/1/rollback", "intruder").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodPost, "/answers/1/revisions/5/rollback", "author").Code)
+
+	w := doAs(t, server, http.MethodPost, "/answers/1/revisions/1/rollback", "author")
+	require.Equal(t, http.StatusOK, w.Code)
+	var answer entity.Answer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+	assert.Equal(t, "Original", answer.Text)
+
+	// Откат не переписывает историю, а добавляет новую версию
+	w = doAs(t, server, http.MethodGet, "/answers/1/revisions", "")
+	var revisions []entity.AnswerRevision
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 3)
+	assert.Equal(t, "Original", revisions[2].Text)
+}
+
+// doJSONAs выполняет запрос с JSON-телом от имени пользователя; пустой subject - анонимный запрос
+func doJSONAs(t *testing.T, server *Server, method string, url string, body string, subject string, roles ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if subject != "" {
+		req.Header.Set("Authorization", bearer(t, subject, roles...))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestTags(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	assert.Equal(t, http.StatusUnauthorized, doJSONAs(t, server, http.MethodPost, "/tags", `{"name": "Go"}`, "").Code)
+	assert.Equal(t, http.StatusBadRequest, doJSONAs(t, server, http.MethodPost, "/tags", `{"name": "go/lang"}`, "user-1").Code)
+
+	w := doJSONAs(t, server, http.MethodPost, "/tags", `{"name": "  Go  Modules "}`, "user-1")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var tag entity.TagInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tag))
+	assert.Equal(t, "go-modules", tag.Name)
+	assert.Equal(t, http.StatusConflict, doJSONAs(t, server, http.MethodPost, "/tags", `{"name": "GO-MODULES"}`, "user-1").Code)
+
+	url := fmt.Sprintf("/tags/%d", tag.Id)
+	assert.Equal(t, http.StatusForbidden, doJSONAs(t, server, http.MethodPatch, url, `{"name": "modules"}`, "user-1").Code)
+	w = doJSONAs(t, server, http.MethodPatch, url, `{"name": "Modules"}`, "mod", "moderator")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tag))
+	assert.Equal(t, "modules", tag.Name)
+
+	w = doJSONAs(t, server, http.MethodPost, url+"/synonyms", `{"name": "Go Mod"}`, "mod", "moderator")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tag))
+	assert.Equal(t, []string{"go-mod"}, tag.Synonyms)
+
+	w = doAs(t, server, http.MethodGet, "/tags", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tags []entity.TagInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	require.Len(t, tags, 1)
+	assert.Equal(t, "modules", tags[0].Name)
+
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/tags/999", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/tags/abc", "").Code)
+}
+
+func TestQuestionListByTags(t *testing.T) {
+	server, _, _ := setupTestServer()
+	for _, name := range []string{"go", "sql"} {
+		require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/tags", fmt.Sprintf(`{"name": %q}`, name), "user-1").Code)
+	}
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/tags/1/synonyms", `{"name": "golang"}`, "mod", "moderator").Code)
+
+	w := doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Query from Go", "tags": ["Golang", "SQL", "go"]}`, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created entity.Question
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Len(t, created.Tags, 2, "синоним и основное имя - один тег")
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Goroutines", "tags": ["go"]}`, "").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Untagged"}`, "").Code)
+	assert.Equal(t, http.StatusBadRequest, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Rust", "tags": ["rust"]}`, "").Code)
+
+	list := func(query string) []int {
+		t.Helper()
+		w := doAs(t, server, http.MethodGet, "/questions/"+query, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var page entity.Page[entity.Question]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		ids := []int{}
+		for _, question := range page.Items {
+			ids = append(ids, question.Id)
+		}
+		return ids
+	}
+	assert.Equal(t, []int{1}, list("?tag=go&tag=sql"))
+	assert.Equal(t, []int{1, 2}, list("?tag=golang&tag=sql&tag_mode=any"))
+	assert.Equal(t, []int{1, 2}, list("?tag=rust&tag=go&tag_mode=any"))
+	assert.Equal(t, []int{}, list("?tag=rust&tag=go"))
+	assert.Equal(t, []int{1, 2, 3}, list(""))
+
+	w = doAs(t, server, http.MethodGet, "/questions/?tag=go&tag_mode=some", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doAs(t, server, http.MethodGet, "/tags", "")
+	var tags []entity.TagInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	require.Len(t, tags, 2)
+	assert.Equal(t, 2, tags[0].QuestionCount)
+	assert.Equal(t, 1, tags[1].QuestionCount)
+}
+
+// postComment добавляет комментарий от имени пользователя и возвращает его
+func postComment(t *testing.T, server *Server, answerId int, parentId int, subject string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := `{"text": "comment"}`
+	if parentId != 0 {
+		body = fmt.Sprintf(`{"text": "reply", "parent_id": %d}`, parentId)
+	}
+	return doJSONAs(t, server, http.MethodPost, fmt.Sprintf("/answers/%d/comments", answerId), body, subject)
+}
+
+func TestCommentThreads(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Answer"})
+
+	assert.Equal(t, http.StatusUnauthorized, postComment(t, server, 1, 0, "").Code)
+	assert.Equal(t, http.StatusNotFound, postComment(t, server, 999, 0, "user-1").Code)
+	assert.Equal(t, http.StatusBadRequest, doJSONAs(t, server, http.MethodPost, "/answers/1/comments", `{"text": ""}`, "user-1").Code)
+
+	// Глубина ограничена testCommentMaxDepth: корень 0, ответы 1 и 2
+	parent := 0
+	for depth := 0; depth <= testCommentMaxDepth; depth++ {
+		w := postComment(t, server, 1, parent, "user-1")
+		require.Equal(t, http.StatusCreated, w.Code)
+		var comment entity.Comment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+		assert.Equal(t, depth, comment.Depth)
+		assert.Equal(t, "user-1", comment.UserId)
+		parent = comment.Id
+	}
+	assert.Equal(t, http.StatusBadRequest, postComment(t, server, 1, parent, "user-1").Code)
+	assert.Equal(t, http.StatusNotFound, postComment(t, server, 1, 999, "user-1").Code)
+
+	w := doAs(t, server, http.MethodGet, "/answers/1/comments", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var threads []entity.Comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	require.Len(t, threads, 1)
+	require.Len(t, threads[0].Replies, 1)
+	require.Len(t, threads[0].Replies[0].Replies, 1)
+	assert.Equal(t, parent, threads[0].Replies[0].Replies[0].Id)
+
+	// Без include комментарии не встраиваются
+	w = doAs(t, server, http.MethodGet, "/questions/1", "")
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	assert.Empty(t, question.Answers[0].Comments)
+
+	w = doAs(t, server, http.MethodGet, "/questions/1?include=comments", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	require.Len(t, question.Answers[0].Comments, 1)
+	assert.Len(t, question.Answers[0].Comments[0].Replies, 1)
+
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/questions/1?include=votes", "").Code)
+}
+
+func TestDeleteComment(t *testing.T) {
+	server, questionRepo, answerRepo := setupTestServer()
+	questionRepo.SetQuestionForTesting(&entity.Question{Id: 1, Text: "Test Question"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 1, QuestionId: 1, UserId: "author", Text: "Answer 1"})
+	answerRepo.SetAnswerForTesting(&entity.Answer{ID: 2, QuestionId: 1, UserId: "author", Text: "Answer 2"})
+	require.Equal(t, http.StatusCreated, postComment(t, server, 1, 0, "user-1").Code)
+	require.Equal(t, http.StatusCreated, postComment(t, server, 1, 1, "user-2").Code)
+
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodDelete, "/answers/2/comments/1", "user-1").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodDelete, "/answers/1/comments/1", "user-2").Code)
+	assert.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1/comments/2", "mod", "moderator").Code)
+	assert.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1/comments/1", "user-1").Code)
+
+	w := doAs(t, server, http.MethodGet, "/answers/1/comments", "")
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	// Комментарии удаленного ответа недоступны
+	require.Equal(t, http.StatusCreated, postComment(t, server, 2, 0, "user-1").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/2", "author").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/answers/2/comments", "").Code)
+}
+
+func TestUserProfile(t *testing.T) {
+	server, _, _ := setupTestServer()
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/users/alice", "").Code)
+
+	// Пользователь заводится первым запросом с токеном, имя берется из claim name
+	req := httptest.NewRequest(http.MethodPost, "/questions/", bytes.NewBufferString(`{"text": "Question"}`))
+	req.Header.Set("Authorization", signedBearer(t, map[string]any{
+		"sub":  "alice",
+		"name": "Alice Liddell",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &question))
+	require.NotNil(t, question.AuthorId)
+	assert.Equal(t, "alice", *question.AuthorId)
+
+	w = doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Anonymous"}`, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"author_id":null`)
+
+	w = doJSONAs(t, server, http.MethodPost, fmt.Sprintf("/questions/%d/answers/", question.Id), `{"text": "Answer"}`, "alice")
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = doAs(t, server, http.MethodGet, "/users/alice", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var user entity.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, "Alice Liddell", user.DisplayName)
+	assert.Empty(t, user.Bio)
+
+	var questions entity.Page[entity.Question]
+	w = doAs(t, server, http.MethodGet, "/users/alice/questions", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &questions))
+	require.Len(t, questions.Items, 1)
+	assert.Equal(t, question.Id, questions.Items[0].Id)
+
+	var answers entity.Page[entity.Answer]
+	w = doAs(t, server, http.MethodGet, "/users/alice/answers?limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answers))
+	require.Len(t, answers.Items, 1)
+	assert.Equal(t, "Answer", answers.Items[0].Text)
+
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/users/alice/answers?limit=0", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/users/alice/comments", "").Code)
+	assert.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/users/bob/answers", "").Code)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	server, _, _ := setupTestServer()
+	// Профиль заводится любым запросом с токеном, в том числе запросом самого профиля
+	require.Equal(t, http.StatusOK, doAs(t, server, http.MethodGet, "/users/alice", "alice").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, doJSONAs(t, server, http.MethodPatch, "/users/alice", `{"bio": "x"}`, "").Code)
+	assert.Equal(t, http.StatusForbidden, doJSONAs(t, server, http.MethodPatch, "/users/alice", `{"bio": "x"}`, "bob").Code)
+	assert.Equal(t, http.StatusBadRequest, doJSONAs(t, server, http.MethodPatch, "/users/alice", `{"display_name": " "}`, "alice").Code)
+
+	w := doJSONAs(t, server, http.MethodPatch, "/users/alice", `{"bio": "Curious"}`, "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	var user entity.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, "alice", user.DisplayName)
+	assert.Equal(t, "Curious", user.Bio)
+
+	w = doJSONAs(t, server, http.MethodPatch, "/users/alice", `{"display_name": "Alice"}`, "root", "admin")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, "Alice", user.DisplayName)
+	assert.Equal(t, "Curious", user.Bio)
+}
+
+func TestQuestionAuthorOwnership(t *testing.T) {
+	server, _, _ := setupTestServer()
+	w := doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice")
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Equal(t, http.StatusForbidden, doJSONAs(t, server, http.MethodPatch, "/questions/1", `{"text": "Changed"}`, "bob").Code)
+	assert.Equal(t, http.StatusOK, doJSONAs(t, server, http.MethodPatch, "/questions/1", `{"text": "Changed"}`, "alice").Code)
+	assert.Equal(t, http.StatusForbidden, doAs(t, server, http.MethodDelete, "/questions/1", "bob").Code)
+	assert.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1", "alice").Code)
+}
+
+func getUser(t *testing.T, server *Server, userId string) entity.User {
+	t.Helper()
+	w := doAs(t, server, http.MethodGet, "/users/"+userId, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var user entity.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	return user
+}
+
+func TestReputation(t *testing.T) {
+	env := newTestEnv(zap.NewNop())
+	server := env.server
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Bob"}`, "bob").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Carol"}`, "carol").Code)
+
+	// Голос за свой ответ очков не дает, смена голоса отменяет прежний
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 1, "bob", 1).Code)
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 1, "carol", 1).Code)
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 1, "dave", 1).Code)
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 1, "dave", -1).Code)
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 2, "alice", 1).Code)
+	assert.Equal(t, 8, getUser(t, server, "bob").Reputation)
+
+	// Выбор другого принятого ответа переносит очки
+	require.Equal(t, http.StatusOK, doJSONAs(t, server, http.MethodPut, "/questions/1/accepted-answer", `{"answer_id": 1}`, "alice").Code)
+	assert.Equal(t, 23, getUser(t, server, "bob").Reputation)
+	require.Equal(t, http.StatusOK, doJSONAs(t, server, http.MethodPut, "/questions/1/accepted-answer", `{"answer_id": 2}`, "alice").Code)
+	assert.Equal(t, 8, getUser(t, server, "bob").Reputation)
+	assert.Equal(t, 25, getUser(t, server, "carol").Reputation)
+	assert.Equal(t, 2, getUser(t, server, "alice").Reputation)
+
+	expected := `[
+		{"user_id": "carol", "display_name": "carol", "reputation": 25},
+		{"user_id": "bob", "display_name": "bob", "reputation": 8},
+		{"user_id": "alice", "display_name": "alice", "reputation": 2}
+	]`
+	w := doAs(t, server, http.MethodGet, "/leaderboard", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+	w = doAs(t, server, http.MethodGet, "/leaderboard?window=week&limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"user_id": "carol", "display_name": "carol", "reputation": 25}]`, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/leaderboard?window=year", "").Code)
+
+	// Пересчет с нуля дает те же суммы, что и накопленный журнал
+	require.NoError(t, env.reputationCase.RebuildReputation(context.Background()))
+	w = doAs(t, server, http.MethodGet, "/leaderboard?window=month", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, expected, w.Body.String())
+	assert.Equal(t, 8, getUser(t, server, "bob").Reputation)
+
+	// Снятие отметки возвращает очки за принятие
+	require.Equal(t, http.StatusOK, doAs(t, server, http.MethodDelete, "/questions/1/accepted-answer", "alice").Code)
+	assert.Equal(t, 10, getUser(t, server, "carol").Reputation)
+	assert.Equal(t, 0, getUser(t, server, "alice").Reputation)
+}
+
+// postIdempotent отправляет POST с заголовком Idempotency-Key от имени пользователя
+func postIdempotent(t *testing.T, server *Server, url string, body string, key string, subject string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	if subject != "" {
+		req.Header.Set("Authorization", bearer(t, subject))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	first := postIdempotent(t, server, "/questions/", `{"text": "Question"}`, "q-1", "alice")
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// Повтор получает тот же ответ, второй вопрос не создается
+	retry := postIdempotent(t, server, "/questions/", `{"text": "Question"}`, "q-1", "alice")
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	var page entity.Page[entity.Question]
+	require.NoError(t, json.Unmarshal(doAs(t, server, http.MethodGet, "/questions/", "").Body.Bytes(), &page))
+	assert.Len(t, page.Items, 1)
+
+	// Тот же ключ с другим телом - конфликт
+	w := postIdempotent(t, server, "/questions/", `{"text": "Other"}`, "q-1", "alice")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+	// Ключи принадлежат пользователю: у другого пользователя тот же ключ создает свой вопрос
+	w = postIdempotent(t, server, "/questions/", `{"text": "Question"}`, "q-1", "bob")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	// Ответы: повтор не создает дубликат, тот же ключ на другой вопрос - конфликт
+	first = postIdempotent(t, server, "/questions/1/answers/", `{"text": "Answer"}`, "a-1", "bob")
+	require.Equal(t, http.StatusCreated, first.Code)
+	retry = postIdempotent(t, server, "/questions/1/answers/", `{"text": "Answer"}`, "a-1", "bob")
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(doAs(t, server, http.MethodGet, "/questions/1", "").Body.Bytes(), &question))
+	assert.Len(t, question.Answers, 1)
+	assert.Equal(t, http.StatusConflict, postIdempotent(t, server, "/questions/2/answers/", `{"text": "Answer"}`, "a-1", "bob").Code)
+
+	// Ошибки клиента тоже сохраняются: повтор запроса с пустым текстом получает тот же 400
+	w = postIdempotent(t, server, "/questions/", `{"text": ""}`, "q-2", "alice")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	retry = postIdempotent(t, server, "/questions/", `{"text": ""}`, "q-2", "alice")
+	assert.Equal(t, http.StatusBadRequest, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+
+	// Без заголовка запросы выполняются каждый раз
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+
+	w = postIdempotent(t, server, "/questions/", `{"text": "Question"}`, strings.Repeat("k", 256), "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// У анонимных запросов нет владельца ключей: два анонимных клиента не получают ответы друг друга
+	for i := 0; i < 2; i++ {
+		w = postIdempotent(t, server, "/questions/", `{"text": "Question"}`, "anonymous-1", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var body problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "Idempotency-Key requires authentication", body.Detail)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	rules, err := ratelimit.ParseRules("POST /questions/{id}/answers=2/1m")
+	require.NoError(t, err)
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+	env := newTestEnv(zap.NewNop(),
+		WithRateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rules)),
+		WithTrustedProxies(proxies),
+	)
+	server := env.server
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+
+	w := doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "First"}`, "bob")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Second"}`, "bob").Code)
+
+	w = doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Third"}`, "bob")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// Лимит принадлежит пользователю, другие маршруты и пользователи не ограничены
+	assert.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Other"}`, "carol").Code)
+	w = doAs(t, server, http.MethodGet, "/questions/1", "bob")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	env := newTestEnv(zap.NewNop(),
+		WithRateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil)),
+		WithAuthRateLimit(ratelimit.Limit{Requests: 2, Period: time.Minute}),
+	)
+	server := env.server
+	request := func(remote string, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/questions/", nil)
+		req.RemoteAddr = remote
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusUnauthorized, request("203.0.113.5:1234", "Bearer invalid").Code)
+	require.Equal(t, http.StatusUnauthorized, request("203.0.113.5:1234", "Bearer invalid").Code)
+
+	// Исчерпав лимит невалидными токенами, IP не доходит до проверки токена, даже с валидным
+	w := request("203.0.113.5:1234", "Bearer invalid")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.5:1234", bearer(t, "alice")).Code)
+
+	// Анонимные запросы и другие IP не ограничены
+	assert.Equal(t, http.StatusOK, request("203.0.113.5:1234", "").Code)
+	assert.Equal(t, http.StatusOK, request("198.51.100.1:1234", bearer(t, "alice")).Code)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10, ::1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		{"direct client", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer cannot spoof", "203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.1.2.3:80", []string{"6.6.6.6, 198.51.100.1, 192.168.1.10"}, "198.51.100.1"},
+		{"several headers", "10.1.2.3:80", []string{"6.6.6.6", "198.51.100.1"}, "198.51.100.1"},
+		{"only proxies", "10.1.2.3:80", []string{"10.9.9.9"}, "10.9.9.9"},
+		{"garbage stops the walk", "10.1.2.3:80", []string{"198.51.100.1, junk"}, "10.1.2.3"},
+		{"ipv6 proxy", "[::1]:80", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"ipv4-mapped proxy", "[::ffff:10.1.2.3]:80", []string{"198.51.100.1"}, "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/questions/", nil)
+		req.RemoteAddr = tt.remote
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		assert.Equal(t, tt.expected, clientIP(req, proxies), tt.name)
+	}
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies("proxy.local")
+	assert.Error(t, err)
+}
+
+func TestMetrics(t *testing.T) {
+	server, _, _ := setupTestServer()
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Answer"}`, "bob").Code)
+	require.Equal(t, http.StatusOK, doAs(t, server, http.MethodGet, "/questions/1", "").Code)
+	require.Equal(t, http.StatusNotFound, doAs(t, server, http.MethodGet, "/questions/42", "").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/answers/1", "bob").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, server, http.MethodDelete, "/questions/1?hard=true", "admin", "admin").Code)
+
+	w := doAs(t, server, http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+
+	// Метки маршрута - шаблон, а не конкретный путь
+	assert.Contains(t, body, `hitalent_http_requests_total{method="GET",route="/questions/{id}",status="200"} 1`)
+	assert.Contains(t, body, `hitalent_http_requests_total{method="GET",route="/questions/{id}",status="404"} 1`)
+	assert.Contains(t, body, `hitalent_http_requests_total{method="POST",route="/questions/{id}/answers",status="201"} 1`)
+	assert.Contains(t, body, `hitalent_http_request_duration_seconds_count{method="DELETE",route="/answers/{id}",status="204"} 1`)
+	assert.NotContains(t, body, `route="/questions/1"`)
+	// Текущий запрос к /metrics еще обрабатывается
+	assert.Contains(t, body, "hitalent_http_requests_in_flight 1")
+
+	assert.Contains(t, body, "hitalent_questions_created_total 1")
+	assert.Contains(t, body, "hitalent_answers_created_total 1")
+	assert.Contains(t, body, `hitalent_deletes_total{hard="false",kind="answer"} 1`)
+	assert.Contains(t, body, `hitalent_deletes_total{hard="true",kind="question"} 1`)
+}
+
+func TestRouteTemplate(t *testing.T) {
+	server := newTestEnv(zap.NewNop()).server
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodGet, "/questions/", "/questions"},
+		{http.MethodHead, "/questions/", "/questions"},
+		{http.MethodGet, "/questions/12", "/questions/{id}"},
+		{http.MethodPost, "/questions/12/answers/", "/questions/{id}/answers"},
+		{http.MethodPut, "/questions/12/accepted-answer", "/questions/{id}/accepted-answer"},
+		{http.MethodGet, "/answers/7/revisions/2/diff", "/answers/{id}/revisions/{number}/diff"},
+		{http.MethodDelete, "/answers/7/comments/3", "/answers/{id}/comments/{comment_id}"},
+		{http.MethodGet, "/users/auth0%7Cabc/questions", "/users/{id}/questions"},
+		{http.MethodGet, "/admin/trash", "/admin/trash"},
+		{http.MethodGet, "/openapi.json", "/openapi.json"},
+		{http.MethodGet, "/docs/swagger-ui.css", "/docs/"},
+		{http.MethodGet, "/unknown/path", "unmatched"},
+		{http.MethodGet, "/answers/7/revisions/2/diff/extra", "unmatched"},
+		{http.MethodPost, "/questions/12", "unmatched"},
+	}
+	for _, tt := range tests {
+		_, pattern := server.mux.Handler(httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.expected, routeTemplate(pattern), tt.method+" "+tt.path)
+	}
+}
+
+func TestStrictRouting(t *testing.T) {
+	server := newTestEnv(zap.NewNop()).server
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		// Лишние сегменты больше не отбрасываются
+		{http.MethodGet, "/questions/1/garbage", http.StatusNotFound, ""},
+		{http.MethodGet, "/questions/1/", http.StatusNotFound, ""},
+		{http.MethodPost, "/questions/1/answers/extra", http.StatusNotFound, ""},
+		{http.MethodGet, "/answers/1/revisions/1", http.StatusNotFound, ""},
+		{http.MethodGet, "/users/alice/badges", http.StatusNotFound, ""},
+		{http.MethodGet, "/", http.StatusNotFound, ""},
+		// Путь есть, но с другими методами
+		{http.MethodPut, "/questions/", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{http.MethodGet, "/questions/1/answers/", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPost, "/questions/1", http.StatusMethodNotAllowed, "GET, HEAD, PATCH, DELETE"},
+		{http.MethodGet, "/answers/1/vote", http.StatusMethodNotAllowed, "PUT"},
+		{http.MethodDelete, "/tags", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+	}
+	for _, tt := range tests {
+		w := doAs(t, server, tt.method, tt.path, "alice")
+		require.Equal(t, tt.status, w.Code, tt.method+" "+tt.path)
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"), tt.method+" "+tt.path)
+		assert.Equal(t, tt.allow, w.Header().Get("Allow"), tt.method+" "+tt.path)
+	}
+
+	// Путь без завершающего "/" перенаправляется с сохранением метода
+	w := doAs(t, server, http.MethodPost, "/questions/1/answers", "alice")
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "/questions/1/answers/", w.Header().Get("Location"))
+
+	// Параметры пути по-прежнему проверяются обработчиком
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodGet, "/answers/1/revisions/0/diff", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doAs(t, server, http.MethodDelete, "/answers/1/comments/x", "alice").Code)
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	server := newTestEnv(zap.NewNop()).server
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	server.handle("GET /ping/{name}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler "+r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	}), trace("outer"), trace("inner"))
+
+	w := doAs(t, server, http.MethodGet, "/ping/pong", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"outer", "inner", "handler pong"}, calls)
+	assert.Contains(t, server.Routes(), "GET /ping/{name}")
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	core, logs := observer.New(zap.InfoLevel)
+	server := newTestEnv(zap.New(core)).server
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/questions/", bytes.NewBufferString(`{"text": "Question"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, "alice"))
+	req.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	serverSpan, ok := spans["POST /questions"]
+	require.True(t, ok, "server span is missing")
+	caseSpan, ok := spans["QuestionCase.CreateQuestion"]
+	require.True(t, ok, "case span is missing")
+
+	// Серверный спан продолжает трассу клиента, спан case вложен в него
+	assert.Equal(t, traceId, serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Contains(t, serverSpan.Attributes(), attribute.Int("http.response.status_code", http.StatusCreated))
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), caseSpan.Parent().SpanID())
+
+	created := logs.FilterMessage("Question created successfully").All()
+	require.Len(t, created, 1)
+	assert.Equal(t, traceId, created[0].ContextMap()["trace_id"])
+	assert.Equal(t, caseSpan.SpanContext().SpanID().String(), created[0].ContextMap()["span_id"])
+	requests := logs.FilterMessage("HTTP request").All()
+	require.Len(t, requests, 1)
+	assert.Equal(t, traceId, requests[0].ContextMap()["trace_id"])
+}
+
+// doConditional выполняет запрос с условным заголовком
+func doConditional(t *testing.T, server *Server, method string, url string, body string, header string, value string, subject string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(header, value)
+	if subject != "" {
+		req.Header.Set("Authorization", bearer(t, subject))
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestQuestionETag(t *testing.T) {
+	server, _, _ := setupTestServer()
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+
+	w := doAs(t, server, http.MethodGet, "/questions/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	assert.Equal(t, `"1"`, etag)
+	assert.NotEmpty(t, lastModified)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	// Неизменившийся вопрос не передается повторно, в том числе по слабому сравнению
+	for _, value := range []string{etag, `"0", ` + etag, "W/" + etag, "*"} {
+		w = doConditional(t, server, http.MethodGet, "/questions/1", "", "If-None-Match", value, "")
+		assert.Equal(t, http.StatusNotModified, w.Code, value)
+		assert.Empty(t, w.Body.String(), value)
+		assert.Equal(t, etag, w.Header().Get("ETag"), value)
+	}
+	assert.Equal(t, http.StatusNotModified, doConditional(t, server, http.MethodGet, "/questions/1", "", "If-Modified-Since", lastModified, "").Code)
+
+	// Варианты представления различаются
+	w = doConditional(t, server, http.MethodGet, "/questions/1?sort=score&include=comments", "", "If-None-Match", etag, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1-score-comments"`, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusBadRequest, doConditional(t, server, http.MethodGet, "/questions/1?sort=bogus", "", "If-None-Match", etag, "").Code)
+
+	// Новый ответ и голос меняют версию вопроса
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Answer"}`, "bob").Code)
+	w = doConditional(t, server, http.MethodGet, "/questions/1", "", "If-None-Match", etag, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	answered := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, answered)
+
+	require.Equal(t, http.StatusOK, voteForAnswer(t, server, 1, "carol", 1).Code)
+	w = doConditional(t, server, http.MethodGet, "/questions/1", "", "If-None-Match", answered, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, answered, w.Header().Get("ETag"))
+
+	// Дата в прошлом не совпадает с Last-Modified, а If-None-Match важнее If-Modified-Since
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	assert.Equal(t, http.StatusOK, doConditional(t, server, http.MethodGet, "/questions/1", "", "If-Modified-Since", past, "").Code)
+	assert.Equal(t, http.StatusNotFound, doConditional(t, server, http.MethodGet, "/questions/42", "", "If-None-Match", etag, "").Code)
+}
+
+func TestQuestionIfMatch(t *testing.T) {
+	server, _, _ := setupTestServer()
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	etag := doAs(t, server, http.MethodGet, "/questions/1", "").Header().Get("ETag")
+
+	w := doConditional(t, server, http.MethodPatch, "/questions/1", `{"text": "First"}`, "If-Match", etag, "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	updated := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, updated)
+
+	// Изменение по устаревшей версии отклоняется и ничего не меняет
+	for _, value := range []string{etag, "W/" + updated, `"unknown"`} {
+		w = doConditional(t, server, http.MethodPatch, "/questions/1", `{"text": "Second"}`, "If-Match", value, "alice")
+		require.Equal(t, http.StatusPreconditionFailed, w.Code, value)
+		assert.Equal(t, problemContentType, w.Header().Get("Content-Type"), value)
+	}
+	assert.Equal(t, http.StatusPreconditionFailed, doConditional(t, server, http.MethodDelete, "/questions/1", "", "If-Match", etag, "alice").Code)
+
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(doAs(t, server, http.MethodGet, "/questions/1", "").Body.Bytes(), &question))
+	assert.Equal(t, "First", question.Text)
+
+	// Из нескольких ETag подходит совпадающий с текущей версией, любой вариант представления
+	w = doConditional(t, server, http.MethodPatch, "/questions/1", `{"text": "Second"}`, "If-Match", etag+`, "2-score"`, "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNoContent, doConditional(t, server, http.MethodDelete, "/questions/1", "", "If-Match", w.Header().Get("ETag"), "alice").Code)
+	assert.Equal(t, http.StatusNotFound, doConditional(t, server, http.MethodDelete, "/questions/1", "", "If-Match", "*", "alice").Code)
+}
+
+func TestAnswerIfMatch(t *testing.T) {
+	server, _, _ := setupTestServer()
+	require.Equal(t, http.StatusCreated, doJSONAs(t, server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	etag := doAs(t, server, http.MethodGet, "/questions/1", "").Header().Get("ETag")
+
+	// Новый ответ, принятие и снятие отметки тоже меняют вопрос и сверяют If-Match
+	w := doConditional(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Answer"}`, "If-Match", etag, "bob")
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doConditional(t, server, http.MethodPost, "/questions/1/answers/", `{"text": "Late"}`, "If-Match", etag, "carol")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	answered := doAs(t, server, http.MethodGet, "/questions/1", "").Header().Get("ETag")
+
+	w = doConditional(t, server, http.MethodPut, "/questions/1/accepted-answer", `{"answer_id": 1}`, "If-Match", etag, "alice")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	var question entity.Question
+	require.NoError(t, json.Unmarshal(doAs(t, server, http.MethodGet, "/questions/1", "").Body.Bytes(), &question))
+	assert.Nil(t, question.AcceptedAnswerId)
+	assert.Len(t, question.Answers, 1)
+
+	w = doConditional(t, server, http.MethodPut, "/questions/1/accepted-answer", `{"answer_id": 1}`, "If-Match", answered, "alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	accepted := w.Header().Get("ETag")
+	assert.NotEqual(t, answered, accepted)
+
+	assert.Equal(t, http.StatusPreconditionFailed, doConditional(t, server, http.MethodDelete, "/questions/1/accepted-answer", "", "If-Match", answered, "alice").Code)
+	assert.Equal(t, http.StatusOK, doConditional(t, server, http.MethodDelete, "/questions/1/accepted-answer", "", "If-Match", accepted, "alice").Code)
+}
+
+// sseEvent - событие из потока text/event-stream
+type sseEvent struct {
+	id   string
+	name string
+	data map[string]any
+}
+
+// openEventStream подключается к потоку событий вопроса; lastEventId пустой - без возобновления
+func openEventStream(t *testing.T, baseURL string, questionId int, lastEventId string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/questions/%d/events", baseURL, questionId), nil)
+	require.NoError(t, err)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readEvent читает следующее событие потока и сообщает, были ли перед ним комментарии-heartbeat
+func readEvent(t *testing.T, reader *bufio.Reader) (event sseEvent, heartbeats bool) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if event.name != "" {
+				return event, heartbeats
+			}
+		case strings.HasPrefix(line, ":"):
+			heartbeats = heartbeats || line == ": heartbeat"
+		default:
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.name = value
+			case "data":
+				require.NoError(t, json.Unmarshal([]byte(value), &event.data))
+			}
+		}
+	}
+}
+
+func TestQuestionEvents(t *testing.T) {
+	env := newTestEnv(zap.NewNop())
+	// Поток живет дольше таймаутов сервера
+	ts := httptest.NewUnstartedServer(env.server)
+	ts.Config.ReadTimeout = 50 * time.Millisecond
+	ts.Config.WriteTimeout = 50 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+	defer env.eventBroker.Close()
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, env.server, http.MethodPost, "/questions/", `{"text": "Question"}`, "alice").Code)
+	require.Equal(t, http.StatusCreated, doJSONAs(t, env.server, http.MethodPost, "/questions/", `{"text": "Other"}`, "alice").Code)
+
+	resp, _ := openEventStream(t, ts.URL, 42, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	// ID не этого запуска сервера (или не ID вовсе) означает потерянные события
+	resp, stale := openEventStream(t, ts.URL, 1, "abc")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	event, _ := readEvent(t, stale)
+	assert.Equal(t, "stream.reset", event.name)
+	assert.Equal(t, env.eventBroker.EventID(0), event.id)
+
+	resp, stream := openEventStream(t, ts.URL, 1, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	time.Sleep(100 * time.Millisecond)
+
+	require.Equal(t, http.StatusCreated, doJSONAs(t, env.server, http.MethodPost, "/questions/1/answers/", `{"text": "Answer"}`, "bob").Code)
+	event, heartbeats := readEvent(t, stream)
+	assert.True(t, heartbeats)
+	assert.Equal(t, env.eventBroker.EventID(1), event.id)
+	assert.Equal(t, "answer.created", event.name)
+	assert.Equal(t, map[string]any{"answer_id": 1.0, "question_id": 1.0, "user_id": "bob", "at": event.data["at"]}, event.data)
+
+	// События другого вопроса в поток не попадают
+	require.Equal(t, http.StatusCreated, doJSONAs(t, env.server, http.MethodPost, "/questions/2/answers/", `{"text": "Answer"}`, "bob").Code)
+	require.Equal(t, http.StatusNoContent, doAs(t, env.server, http.MethodDelete, "/answers/1", "bob").Code)
+	event, _ = readEvent(t, stream)
+	assert.Equal(t, env.eventBroker.EventID(3), event.id)
+	assert.Equal(t, "answer.deleted", event.name)
+	assert.Equal(t, 1.0, event.data["answer_id"])
+
+	// Переподключение с Last-Event-ID начинается с пропущенных событий вопроса
+	_, resumed := openEventStream(t, ts.URL, 1, env.eventBroker.EventID(1))
+	event, _ = readEvent(t, resumed)
+	assert.Equal(t, env.eventBroker.EventID(3), event.id)
+	assert.Equal(t, "answer.deleted", event.name)
+
+	// Если пропущенные события уже вытеснены из буфера, клиент получает stream.reset с текущим ID
+	for i := 0; i < testSSEReplaySize; i++ {
+		require.Equal(t, http.StatusCreated, doJSONAs(t, env.server, http.MethodPost, "/questions/2/answers/", `{"text": "Answer"}`, "bob").Code)
+	}
+	_, reset := openEventStream(t, ts.URL, 1, env.eventBroker.EventID(1))
+	event, _ = readEvent(t, reset)
+	assert.Equal(t, "stream.reset", event.name)
+	assert.Equal(t, env.eventBroker.EventID(7), event.id)
+
+	// При остановке брокера потоки завершаются
+	env.eventBroker.Close()
+	_, err := stream.ReadString('\n')
+	for err == nil {
+		_, err = stream.ReadString('\n')
+	}
+	assert.ErrorIs(t, err, io.EOF)
 }
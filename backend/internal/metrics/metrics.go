@@ -0,0 +1,116 @@
+// Package metrics - метрики Prometheus: HTTP-запросы, пул соединений БД и доменные счетчики
+package metrics
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "hitalent"
+
+// Metrics хранит собственный реестр, чтобы несколько экземпляров (например, в тестах) не конфликтовали
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+
+	questionsCreated prometheus.Counter
+	answersCreated   prometheus.Counter
+	deletes          *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+		questionsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "questions_created_total",
+			Help:      "Number of created questions.",
+		}),
+		answersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "answers_created_total",
+			Help:      "Number of created answers.",
+		}),
+		deletes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "deletes_total",
+			Help:      "Number of deleted records by kind; hard is true for deletes bypassing the trash.",
+		}, []string{"kind", "hard"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.inFlight,
+		m.questionsCreated,
+		m.answersCreated,
+		m.deletes,
+	)
+	return m
+}
+
+// RegisterDB добавляет статистику пула соединений database/sql
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted отмечает начало обработки запроса, возвращенная функция - его завершение
+func (m *Metrics) RequestStarted() func(route string, method string, status int, duration time.Duration) {
+	m.inFlight.Inc()
+	return func(route string, method string, status int, duration time.Duration) {
+		m.inFlight.Dec()
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(route, method, code).Inc()
+		m.requestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+	}
+}
+
+// HandleEvent считает доменные события, подписывается на шину событий
+func (m *Metrics) HandleEvent(_ context.Context, event entity.Event) {
+	switch e := event.(type) {
+	case entity.QuestionCreated:
+		m.questionsCreated.Inc()
+	case entity.AnswerCreated:
+		m.answersCreated.Inc()
+	case entity.QuestionDeleted:
+		m.deletes.WithLabelValues("question", strconv.FormatBool(e.Hard)).Inc()
+	case entity.AnswerDeleted:
+		m.deletes.WithLabelValues("answer", "false").Inc()
+	case entity.CommentDeleted:
+		m.deletes.WithLabelValues("comment", "false").Inc()
+	}
+}
@@ -0,0 +1,165 @@
+package policy
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ownSuffix ограничивает право ресурсами, автор которых - сам пользователь
+const ownSuffix = ":own"
+
+//go:embed default.json
+var defaultPolicy []byte
+
+// document - формат файла политики
+type document struct {
+	AnonymousRoles     []string        `json:"anonymous_roles"`     // роли запроса без токена
+	AuthenticatedRoles []string        `json:"authenticated_roles"` // роли любого пользователя с токеном
+	Roles              map[string]role `json:"roles"`
+}
+
+type role struct {
+	Inherits    []string `json:"inherits"`
+	Permissions []string `json:"permissions"`
+}
+
+// grant - итоговое право роли: на любые ресурсы или только на свои
+type grant struct {
+	any bool
+	own bool
+}
+
+// Policy - декларативная ролевая модель доступа.
+// Права ролей вычисляются при загрузке с учетом наследования.
+type Policy struct {
+	anonymousRoles     []string
+	authenticatedRoles []string
+	grants             map[string]map[entity.Permission]grant
+}
+
+// Default возвращает встроенную политику по умолчанию
+func Default() (*Policy, error) {
+	return Parse(defaultPolicy)
+}
+
+// LoadFile читает политику из JSON-файла
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse разбирает политику и проверяет ссылки между ролями и имена прав
+func Parse(data []byte) (*Policy, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	p := &Policy{
+		anonymousRoles:     doc.AnonymousRoles,
+		authenticatedRoles: doc.AuthenticatedRoles,
+		grants:             make(map[string]map[entity.Permission]grant, len(doc.Roles)),
+	}
+	for name := range doc.Roles {
+		if _, err := p.resolve(doc.Roles, name, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range append(append([]string{}, doc.AnonymousRoles...), doc.AuthenticatedRoles...) {
+		if _, ok := doc.Roles[name]; !ok {
+			return nil, fmt.Errorf("invalid policy: unknown role %q", name)
+		}
+	}
+	return p, nil
+}
+
+// resolve вычисляет права роли вместе с унаследованными, path нужен для поиска циклов
+func (p *Policy) resolve(roles map[string]role, name string, path []string) (map[entity.Permission]grant, error) {
+	if grants, ok := p.grants[name]; ok {
+		return grants, nil
+	}
+	for _, visited := range path {
+		if visited == name {
+			return nil, fmt.Errorf("invalid policy: inheritance cycle %s", strings.Join(append(path, name), " -> "))
+		}
+	}
+	definition, ok := roles[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid policy: unknown role %q", name)
+	}
+
+	grants := make(map[entity.Permission]grant)
+	for _, parent := range definition.Inherits {
+		inherited, err := p.resolve(roles, parent, append(path, name))
+		if err != nil {
+			return nil, err
+		}
+		for permission, g := range inherited {
+			grants[permission] = merge(grants[permission], g)
+		}
+	}
+	for _, raw := range definition.Permissions {
+		permission, own := strings.CutSuffix(raw, ownSuffix)
+		if permission == "" {
+			return nil, fmt.Errorf("invalid policy: empty permission in role %q", name)
+		}
+		// Опечатка в имени или суффиксе иначе молча не выдала бы никакого права
+		if !slices.Contains(entity.Permissions, entity.Permission(permission)) {
+			return nil, fmt.Errorf("invalid policy: unknown permission %q in role %q", raw, name)
+		}
+		grants[entity.Permission(permission)] = merge(grants[entity.Permission(permission)], grant{any: !own, own: own})
+	}
+
+	p.grants[name] = grants
+	return grants, nil
+}
+
+func merge(a, b grant) grant {
+	return grant{any: a.any || b.any, own: a.own || b.own}
+}
+
+// Allowed сообщает, разрешено ли действие пользователю; nil - анонимный запрос.
+// Роли из токена, которых нет в политике, игнорируются.
+func (p *Policy) Allowed(identity *auth.Identity, permission entity.Permission, ownerId string) bool {
+	roles := p.anonymousRoles
+	if identity != nil {
+		roles = append(append([]string{}, p.authenticatedRoles...), identity.Roles...)
+	}
+
+	for _, name := range roles {
+		g := p.grants[name][permission]
+		if g.any {
+			return true
+		}
+		if g.own && identity != nil && ownerId != "" && ownerId == identity.Subject {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize реализует service.Authorizer для пользователя из контекста запроса
+func (p *Policy) Authorize(ctx context.Context, permission entity.Permission, ownerId string) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		if p.Allowed(nil, permission, ownerId) {
+			return nil
+		}
+		return errs.Unauthorized("authentication required")
+	}
+	if !p.Allowed(&identity, permission, ownerId) {
+		return errs.Forbidden("permission %s denied", permission)
+	}
+	return nil
+}
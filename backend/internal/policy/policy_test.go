@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"HiTalent_TestTask/backend/internal/auth"
+	"HiTalent_TestTask/backend/internal/entity"
+	"HiTalent_TestTask/backend/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	p, err := Default()
+	require.NoError(t, err)
+
+	author := &auth.Identity{Subject: "user-1"}
+	moderator := &auth.Identity{Subject: "mod", Roles: []string{"moderator"}}
+	admin := &auth.Identity{Subject: "root", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		permission entity.Permission
+		ownerId    string
+		allowed    bool
+	}{
+		{"anonymous creates question", nil, entity.PermissionQuestionCreate, "", true},
+		{"anonymous answers", nil, entity.PermissionAnswerCreate, "", false},
+		{"author answers", author, entity.PermissionAnswerCreate, "", true},
+		{"author deletes own answer", author, entity.PermissionAnswerDelete, "user-1", true},
+		{"author deletes other answer", author, entity.PermissionAnswerDelete, "user-2", false},
+		{"author deletes question", author, entity.PermissionQuestionDelete, "", false},
+		{"moderator deletes other answer", moderator, entity.PermissionAnswerDelete, "user-2", true},
+		{"moderator edits other answer", moderator, entity.PermissionAnswerUpdate, "user-2", false},
+		{"moderator deletes question", moderator, entity.PermissionQuestionDelete, "", true},
+		{"moderator hard-deletes question", moderator, entity.PermissionQuestionHardDelete, "", false},
+		{"admin hard-deletes question", admin, entity.PermissionQuestionHardDelete, "", true},
+		{"moderator restores question", moderator, entity.PermissionQuestionRestore, "", true},
+		{"moderator reads trash", moderator, entity.PermissionTrashRead, "", false},
+		{"admin reads trash", admin, entity.PermissionTrashRead, "", true},
+		{"author deletes own comment", author, entity.PermissionCommentDelete, "user-1", true},
+		{"author deletes other comment", author, entity.PermissionCommentDelete, "user-2", false},
+		{"moderator deletes other comment", moderator, entity.PermissionCommentDelete, "user-2", true},
+		{"author creates tag", author, entity.PermissionTagCreate, "", true},
+		{"author renames tag", author, entity.PermissionTagUpdate, "", false},
+		{"moderator renames tag", moderator, entity.PermissionTagUpdate, "", true},
+		{"author edits own question", author, entity.PermissionQuestionUpdate, "user-1", true},
+		{"author edits own profile", author, entity.PermissionUserUpdate, "user-1", true},
+		{"moderator edits other profile", moderator, entity.PermissionUserUpdate, "user-2", false},
+		{"admin edits other profile", admin, entity.PermissionUserUpdate, "user-2", true},
+		{"admin inherits author rights", admin, entity.PermissionAnswerVote, "", true},
+		{"own scope needs owner", author, entity.PermissionQuestionDelete, "", false},
+		{"unknown role is ignored", &auth.Identity{Subject: "x", Roles: []string{"superuser"}}, entity.PermissionQuestionDelete, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, p.Allowed(tt.identity, tt.permission, tt.ownerId))
+		})
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	p, err := Default()
+	require.NoError(t, err)
+
+	err = p.Authorize(context.Background(), entity.PermissionAnswerCreate, "")
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Subject: "user-1"})
+	assert.NoError(t, p.Authorize(ctx, entity.PermissionAnswerCreate, ""))
+	assert.ErrorIs(t, p.Authorize(ctx, entity.PermissionQuestionDelete, ""), errs.ErrForbidden)
+}
+
+func TestParseCustomPolicy(t *testing.T) {
+	p, err := Parse([]byte(`{
+		"authenticated_roles": ["member"],
+		"roles": {
+			"member": {"permissions": ["answer.delete:own"]},
+			"janitor": {"inherits": ["member"], "permissions": ["question.delete"]}
+		}
+	}`))
+	require.NoError(t, err)
+
+	janitor := &auth.Identity{Subject: "j", Roles: []string{"janitor"}}
+	assert.True(t, p.Allowed(janitor, entity.PermissionQuestionDelete, ""))
+	assert.True(t, p.Allowed(janitor, entity.PermissionAnswerDelete, "j"))
+	assert.False(t, p.Allowed(janitor, entity.PermissionAnswerDelete, "other"))
+	assert.False(t, p.Allowed(nil, entity.PermissionQuestionCreate, ""))
+}
+
+func TestParseInvalidPolicy(t *testing.T) {
+	tests := map[string]string{
+		"malformed":         `{`,
+		"unknown parent":    `{"roles": {"a": {"inherits": ["b"]}}}`,
+		"cycle":             `{"roles": {"a": {"inherits": ["b"]}, "b": {"inherits": ["a"]}}}`,
+		"unknown anonymous": `{"anonymous_roles": ["guest"], "roles": {}}`,
+		"empty permission":  `{"roles": {"a": {"permissions": [":own"]}}}`,
+		"misspelled grant":  `{"roles": {"a": {"permissions": ["answer.delte"]}}}`,
+		"unknown suffix":    `{"roles": {"a": {"permissions": ["answer.delete:mine"]}}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -6,11 +6,31 @@ import (
 )
 
 type AnswerRepo interface {
-	CreateAnswer(ctx context.Context, answer *entity.Answer) error
+	// CreateAnswer создает ответ и его первую версию от имени автора ответа. Ненулевой version
+	// создает ответ, только если версия вопроса совпадает, иначе - errs.ErrPreconditionFailed.
+	CreateAnswer(ctx context.Context, answer *entity.Answer, version int) error
 	GetAnswer(ctx context.Context, answerId int) (*entity.Answer, error)
-	DeleteAnswer(ctx context.Context, answerId int) error
+	// UpdateAnswer меняет текст ответа и сохраняет его следующей версией от имени editorId
+	UpdateAnswer(ctx context.Context, answer *entity.Answer, editorId string) error
+	// GetAnswerRevisions возвращает версии ответа по возрастанию номера
+	GetAnswerRevisions(ctx context.Context, answerId int) ([]entity.AnswerRevision, error)
+	GetAnswerRevision(ctx context.Context, answerId int, number int) (*entity.AnswerRevision, error)
+	// DeleteAnswer переносит ответ в корзину и снимает с него отметку о принятом ответе.
+	// Возвращает снятую отметку или nil, если ответ не был принят.
+	DeleteAnswer(ctx context.Context, answerId int) (*entity.AnswerUnaccepted, error)
+	// RestoreAnswer возвращает ответ из корзины; вопрос ответа не должен быть удален
+	RestoreAnswer(ctx context.Context, answerId int) error
+	// VoteAnswer сохраняет голос пользователя (Value 0 снимает голос), пересчитывает
+	// счет ответа и возвращает предыдущее значение голоса (0, если голоса не было)
+	VoteAnswer(ctx context.Context, vote *entity.AnswerVote) (int, error)
 }
 
 //POST /questions/{id}/answers/ — добавить ответ к вопросу
 //GET /answers/{id} — получить конкретный ответ
-//DELETE /answers/{id} — удалить ответ
+//PATCH /answers/{id} — изменить текст ответа
+//GET /answers/{id}/revisions — история правок ответа
+//GET /answers/{id}/revisions/{n}/diff?format=&from= — разница между версиями
+//POST /answers/{id}/revisions/{n}/rollback — откатить ответ к версии
+//DELETE /answers/{id} — удалить ответ в корзину
+//POST /answers/{id}/restore — восстановить ответ из корзины
+//PUT /answers/{id}/vote — проголосовать за ответ
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// CommentRepo хранит комментарии к ответам. Комментарии ответа в корзине недоступны,
+// как если бы их не было, и возвращаются вместе с ответом.
+type CommentRepo interface {
+	// CreateComment сохраняет комментарий; ответ должен существовать, глубину задает вызывающий
+	CreateComment(ctx context.Context, comment *entity.Comment) error
+	GetComment(ctx context.Context, commentId int) (*entity.Comment, error)
+	// GetAnswerComments возвращает комментарии ответа списком по возрастанию id
+	GetAnswerComments(ctx context.Context, answerId int) ([]entity.Comment, error)
+	// GetQuestionComments возвращает комментарии ко всем ответам вопроса списком по возрастанию id
+	GetQuestionComments(ctx context.Context, questionId int) ([]entity.Comment, error)
+	// DeleteComment удаляет комментарий вместе со всеми ответами на него
+	DeleteComment(ctx context.Context, commentId int) error
+}
+
+//GET /answers/{id}/comments — ветки комментариев к ответу
+//POST /answers/{id}/comments — добавить комментарий, parent_id - ответ на комментарий
+//DELETE /answers/{id}/comments/{commentId} — удалить комментарий с ответами на него
+//GET /questions/{id}?include=comments — вопрос с комментариями к ответам
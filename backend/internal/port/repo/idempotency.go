@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"time"
+)
+
+// IdempotencyRepo хранит ключи идемпотентности вместе с ответами на запросы
+type IdempotencyRepo interface {
+	// ReserveKey сохраняет запись незавершенного запроса и возвращает nil. Если у пользователя уже есть
+	// ключ, не истекший к record.CreatedAt, ничего не меняет и возвращает сохраненную запись;
+	// истекшая запись заменяется новой.
+	ReserveKey(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+	// CompleteKey сохраняет ответ на запрос зарезервированного ключа
+	CompleteKey(ctx context.Context, record *entity.IdempotencyRecord) error
+	// ReleaseKey удаляет ключ незавершенного запроса, чтобы запрос можно было повторить; ключ с ответом не удаляется
+	ReleaseKey(ctx context.Context, userId string, key string) error
+	// PurgeKeys удаляет ключи, истекшие к before, и возвращает их число
+	PurgeKeys(ctx context.Context, before time.Time) (int, error)
+}
@@ -5,14 +5,49 @@ import (
 	"context"
 )
 
+// QuestionListFilter - параметры keyset-пагинации списка вопросов.
+// Вопросы отдаются по возрастанию id, начиная с первого id > AfterId.
+// Непустой TagIds оставляет только вопросы со всеми (TagModeAll) или хотя бы одним (TagModeAny) из тегов.
+type QuestionListFilter struct {
+	AfterId int
+	Limit   int
+	TagIds  []int
+	TagMode entity.TagMode
+}
+
 type QuestionRepo interface {
-	GetQuestionList(ctx context.Context) (*[]entity.Question, error)
+	GetQuestionList(ctx context.Context, filter QuestionListFilter) (*[]entity.Question, error)
+	// CreateQuestion создает вопрос и привязывает к нему существующие теги из question.Tags
 	CreateQuestion(ctx context.Context, question *entity.Question) error
+	// GetQuestion возвращает вопрос с ответами; версия вопроса читается раньше ответов,
+	// поэтому она не может оказаться новее возвращенного представления
 	GetQuestion(ctx context.Context, questionId int) (*entity.Question, error)
-	DeleteQuestion(ctx context.Context, questionId int) error
+	// GetQuestionVersion возвращает версию вопроса вне корзины, не загружая ответы
+	GetQuestionVersion(ctx context.Context, questionId int) (entity.QuestionVersion, error)
+	// UpdateQuestion меняет текст вопроса. Если question.Version не 0, вопрос меняется, только если
+	// его версия совпадает, иначе - errs.ErrPreconditionFailed.
+	UpdateQuestion(ctx context.Context, question *entity.Question) error
+	// SetAcceptedAnswer отмечает принятый ответ вопроса, nil снимает отметку.
+	// Ответ должен принадлежать вопросу. Возвращает id ответа, принятого до изменения (nil, если его не было):
+	// чтение и изменение атомарны, поэтому параллельные вызовы видят отметки друг друга.
+	// Ненулевой version меняет отметку, только если версия вопроса совпадает, иначе - errs.ErrPreconditionFailed.
+	SetAcceptedAnswer(ctx context.Context, questionId int, answerId *int, version int) (*int, error)
+	// DeleteQuestion переносит вопрос в корзину вместе с его ответами, помечая их одним временем удаления,
+	// и возвращает id этих ответов. Ненулевой version удаляет вопрос, только если его версия совпадает,
+	// иначе - errs.ErrPreconditionFailed.
+	DeleteQuestion(ctx context.Context, questionId int, version int) ([]int, error)
+	// RestoreQuestion возвращает вопрос из корзины вместе с ответами, удаленными вместе с ним
+	RestoreQuestion(ctx context.Context, questionId int) error
+	// PurgeQuestion окончательно удаляет вопрос (в том числе из корзины) вместе с ответами
+	// и возвращает id всех удаленных ответов. version проверяется как в DeleteQuestion.
+	PurgeQuestion(ctx context.Context, questionId int, version int) ([]int, error)
 }
 
-//GET /questions/ — список всех вопросов
+//GET /questions/?limit=&cursor=&tag=&tag_mode= — список вопросов постранично, с фильтром по тегам
 //POST /questions/ — создать новый вопрос
 //GET /questions/{id} — получить вопрос и все ответы на него
-//DELETE /questions/{id} — удалить вопрос (вместе с ответами)
+//PATCH /questions/{id} — изменить текст вопроса
+//PUT /questions/{id}/accepted-answer — принять ответ
+//DELETE /questions/{id}/accepted-answer — снять отметку о принятом ответе
+//DELETE /questions/{id} — удалить вопрос в корзину (вместе с ответами), ?hard=true — окончательно
+//POST /questions/{id}/restore — восстановить вопрос из корзины
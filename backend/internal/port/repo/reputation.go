@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"time"
+)
+
+// ReputationRepo хранит журнал репутации и сумму очков каждого пользователя
+type ReputationRepo interface {
+	// AddReputation дописывает записи в журнал и прибавляет их очки к репутации пользователей
+	AddReputation(ctx context.Context, entries []entity.ReputationEntry) error
+	// ReplaceReputation заменяет весь журнал записями entries и пересчитывает репутацию всех пользователей
+	ReplaceReputation(ctx context.Context, entries []entity.ReputationEntry) error
+	// GetLeaderboard возвращает пользователей с положительной суммой очков, начисленных не раньше since,
+	// по убыванию суммы; нулевое since - за все время
+	GetLeaderboard(ctx context.Context, since time.Time, limit int) ([]entity.LeaderboardEntry, error)
+	// GetVotes возвращает текущие голоса за ответы вне корзины как события голосования без предыдущего голоса;
+	// непустой answerIds оставляет только голоса за эти ответы
+	GetVotes(ctx context.Context, answerIds []int) ([]entity.AnswerVoted, error)
+	// GetAcceptedAnswers возвращает принятые ответы вопросов вне корзины; answerIds фильтрует как в GetVotes
+	GetAcceptedAnswers(ctx context.Context, answerIds []int) ([]entity.AnswerAccepted, error)
+	// GetAnswerReputation возвращает сумму очков журнала за ответы answerIds по пользователю,
+	// причине и ответу; нулевые суммы в результат не попадают
+	GetAnswerReputation(ctx context.Context, answerIds []int) ([]entity.ReputationEntry, error)
+}
+
+//GET /leaderboard?window=week|month|all&limit= — пользователи с наибольшей репутацией за период
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// SearchQuery - параметры полнотекстового поиска
+type SearchQuery struct {
+	Text     string
+	Language string // конфигурация текстового поиска: simple, english, russian
+	Limit    int
+}
+
+// AnswerRankWeight - вес совпадения в тексте ответа относительно совпадения в тексте вопроса
+const AnswerRankWeight = 0.5
+
+// SearchRepo ищет вопросы по тексту вопроса и текстам ответов на него.
+// Результаты упорядочены по убыванию релевантности.
+type SearchRepo interface {
+	Search(ctx context.Context, query SearchQuery) ([]entity.SearchHit, error)
+}
+
+//GET /search?q=&lang=&limit= — поиск вопросов
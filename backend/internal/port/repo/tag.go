@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// TagRepo хранит теги и их синонимы. Имена приходят уже нормализованными;
+// имя тега и имена синонимов вместе уникальны.
+type TagRepo interface {
+	// GetTags возвращает все теги по алфавиту
+	GetTags(ctx context.Context) ([]entity.TagInfo, error)
+	GetTag(ctx context.Context, tagId int) (*entity.TagInfo, error)
+	CreateTag(ctx context.Context, tag *entity.Tag) error
+	// RenameTag меняет имя тега; собственный синоним с этим именем при этом удаляется
+	RenameTag(ctx context.Context, tagId int, name string) error
+	AddTagSynonym(ctx context.Context, tagId int, name string) error
+	// ResolveTags находит теги по именам или синонимам; ключ результата - запрошенное имя,
+	// неизвестные имена в результат не попадают
+	ResolveTags(ctx context.Context, names []string) (map[string]entity.Tag, error)
+}
+
+//GET /tags — список тегов с числом вопросов
+//POST /tags — создать тег
+//GET /tags/{id} — получить тег
+//PATCH /tags/{id} — переименовать тег
+//POST /tags/{id}/synonyms — добавить синоним тега
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+	"time"
+)
+
+// TrashRepo управляет удаленными в корзину вопросами и ответами
+type TrashRepo interface {
+	// GetTrash возвращает не более limit вопросов и limit ответов, начиная с удаленных последними
+	GetTrash(ctx context.Context, limit int) (*entity.Trash, error)
+	// PurgeTrash окончательно удаляет записи, попавшие в корзину раньше before
+	PurgeTrash(ctx context.Context, before time.Time) (entity.PurgeResult, error)
+}
+
+//GET /admin/trash — содержимое корзины
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// UserListFilter - параметры keyset-пагинации вопросов и ответов пользователя,
+// элементы отдаются по возрастанию id, начиная с первого id > AfterId
+type UserListFilter struct {
+	AfterId int
+	Limit   int
+}
+
+type UserRepo interface {
+	// EnsureUser создает пользователя, если его еще нет; существующий профиль не меняется
+	EnsureUser(ctx context.Context, user *entity.User) error
+	GetUser(ctx context.Context, userId string) (*entity.User, error)
+	// UpdateUser сохраняет отображаемое имя и описание профиля
+	UpdateUser(ctx context.Context, user *entity.User) error
+	// GetUserAnswers возвращает ответы пользователя вне корзины
+	GetUserAnswers(ctx context.Context, userId string, filter UserListFilter) ([]entity.Answer, error)
+	// GetUserQuestions возвращает вопросы пользователя вне корзины вместе с тегами
+	GetUserQuestions(ctx context.Context, userId string, filter UserListFilter) ([]entity.Question, error)
+}
+
+//GET /users/{id} — профиль пользователя
+//PATCH /users/{id} — изменить имя и описание профиля
+//GET /users/{id}/answers?limit=&cursor= — ответы пользователя постранично
+//GET /users/{id}/questions?limit=&cursor= — вопросы пользователя постранично
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// Authorizer проверяет право пользователя из контекста на действие над ресурсом.
+// ownerId - автор ресурса, пустой для ресурсов без автора.
+// Возвращает errs.ErrUnauthorized для анонимного запроса и errs.ErrForbidden при отказе.
+type Authorizer interface {
+	Authorize(ctx context.Context, permission entity.Permission, ownerId string) error
+}
@@ -0,0 +1,12 @@
+package service
+
+import (
+	"HiTalent_TestTask/backend/internal/entity"
+	"context"
+)
+
+// EventPublisher доставляет доменные события подписчикам. Публикация синхронная и не
+// возвращает ошибку: изменение уже сохранено, а подписчик сам отвечает за свои сбои.
+type EventPublisher interface {
+	Publish(ctx context.Context, event entity.Event)
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// sweepInterval - как часто MemoryStore удаляет ведра, которые уже заполнились
+const sweepInterval = time.Minute
+
+// MemoryStore хранит ведра в памяти процесса. Ведро хранится как момент, когда оно снова будет
+// полным: так не нужно отдельно хранить число токенов и время последнего пополнения.
+// Заполнившиеся ведра ничем не отличаются от отсутствующих и периодически удаляются,
+// поэтому память растет только с числом активных клиентов.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	interval := limit.interval()
+	fullAt := now
+	if stored, exists := m.buckets[key]; exists && stored.After(now) {
+		fullAt = stored
+	}
+	// Токенов не хватает, пока до заполнения ведра больше, чем (Requests-1) интервалов
+	emptyFor := fullAt.Sub(now) - time.Duration(limit.Requests-1)*interval
+	if emptyFor > 0 {
+		return Result{
+			Allowed:    false,
+			Remaining:  0,
+			RetryAfter: emptyFor,
+			Reset:      fullAt.Sub(now),
+		}, nil
+	}
+
+	fullAt = fullAt.Add(interval)
+	m.buckets[key] = fullAt
+	return Result{
+		Allowed:   true,
+		Remaining: limit.Requests - int((fullAt.Sub(now)+interval-1)/interval),
+		Reset:     fullAt.Sub(now),
+	}, nil
+}
+
+// sweep удаляет заполнившиеся ведра не чаще раза в sweepInterval
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, fullAt := range m.buckets {
+		if !fullAt.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package ratelimit - ограничение частоты запросов по алгоритму token bucket
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit - ведро на Requests токенов, которое заполняется целиком за Period:
+// клиент может сделать Requests запросов подряд, дальше - по одному раз в Period/Requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// interval - время пополнения одного токена
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// Result - итог попытки списать токен
+type Result struct {
+	Allowed    bool
+	Remaining  int           // сколько запросов еще можно сделать сразу
+	RetryAfter time.Duration // через сколько появится следующий токен, 0 - если токены есть
+	Reset      time.Duration // через сколько ведро заполнится целиком
+}
+
+// Store хранит ведра токенов. MemoryStore держит их в памяти процесса; чтобы несколько экземпляров
+// делили лимиты, вместо него подключается общее хранилище (например, Redis) с тем же контрактом.
+type Store interface {
+	// Take списывает токен из ведра key с правилом limit на момент now
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Any - подстановка в правиле: любой метод или любой маршрут
+const Any = "*"
+
+// Rule - лимит для метода и шаблона маршрута (/questions/{id}/answers)
+type Rule struct {
+	Method string
+	Route  string
+	Limit  Limit
+}
+
+func (r Rule) String() string {
+	return r.Method + " " + r.Route + "=" + r.Limit.String()
+}
+
+// specificity - точное совпадение маршрута важнее точного совпадения метода
+func (r Rule) specificity() int {
+	specificity := 0
+	if r.Route != Any {
+		specificity += 2
+	}
+	if r.Method != Any {
+		specificity++
+	}
+	return specificity
+}
+
+func (r Rule) matches(method string, route string) bool {
+	return (r.Method == Any || r.Method == method) && (r.Route == Any || r.Route == route)
+}
+
+// Limiter выбирает правило запроса и списывает токен из ведра клиента для этого правила
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+func NewLimiter(store Store, rules []Rule) *Limiter {
+	return &Limiter{
+		store: store,
+		rules: rules,
+	}
+}
+
+// Allow списывает токен клиента client за запрос method route. Если ни одно правило не подходит,
+// запрос не ограничивается и ok=false. Самое точное правило выбирается так: маршрут и метод,
+// затем маршрут с любым методом, затем метод на любом маршруте, затем общее правило.
+// Запросы, подпавшие под одно правило, расходуют общее ведро клиента.
+func (l *Limiter) Allow(ctx context.Context, client string, method string, route string) (Result, Rule, bool, error) {
+	var matched *Rule
+	for i := range l.rules {
+		rule := &l.rules[i]
+		if rule.matches(method, route) && (matched == nil || rule.specificity() > matched.specificity()) {
+			matched = rule
+		}
+	}
+	if matched == nil {
+		return Result{Allowed: true}, Rule{}, false, nil
+	}
+	result, err := l.store.Take(ctx, client+"|"+matched.Method+" "+matched.Route, matched.Limit, time.Now())
+	return result, *matched, true, err
+}
+
+// Take списывает токен из ведра key с лимитом limit, минуя правила маршрутов
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.store.Take(ctx, key, limit, time.Now())
+}
+
+// ParseRules разбирает правила вида "POST /questions/{id}/answers=10/1m", разделенные ";".
+// Метод и маршрут можно заменить на "*"; у повторного правила для той же пары побеждает последнее.
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	index := make(map[string]int)
+	for _, raw := range strings.Split(spec, ";") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		target, rawLimit, found := strings.Cut(raw, "=")
+		fields := strings.Fields(target)
+		if !found || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rate limit rule %q: expected \"METHOD /route=REQUESTS/PERIOD\"", raw)
+		}
+		method := strings.ToUpper(fields[0])
+		route := fields[1]
+		if route != Any && !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("invalid rate limit rule %q: route must start with \"/\" or be \"*\"", raw)
+		}
+		limit, err := ParseLimit(rawLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit rule %q: %w", raw, err)
+		}
+
+		rule := Rule{Method: method, Route: route, Limit: limit}
+		if i, exists := index[method+" "+route]; exists {
+			rules[i] = rule
+			continue
+		}
+		index[method+" "+route] = len(rules)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseLimit разбирает "10/1m"; период без числа ("10/m") означает одну единицу
+func ParseLimit(raw string) (Limit, error) {
+	rawRequests, rawPeriod, found := strings.Cut(strings.TrimSpace(raw), "/")
+	if !found {
+		return Limit{}, fmt.Errorf("limit must be REQUESTS/PERIOD, got %q", raw)
+	}
+	requests, err := strconv.Atoi(rawRequests)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("requests must be a positive integer, got %q", rawRequests)
+	}
+	if rawPeriod != "" && (rawPeriod[0] < '0' || rawPeriod[0] > '9') {
+		rawPeriod = "1" + rawPeriod
+	}
+	period, err := time.ParseDuration(rawPeriod)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("period must be a positive duration, got %q", rawPeriod)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// Seconds округляет длительность вверх до целых секунд для заголовков ответа
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Now()
+
+	// Полное ведро позволяет сделать Requests запросов подряд
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := store.Take(ctx, "client", limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+		assert.Zero(t, result.RetryAfter)
+	}
+	result, err := store.Take(ctx, "client", limit, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// Отказ не расходует токены; через интервал появляется ровно один
+	result, err = store.Take(ctx, "client", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	result, err = store.Take(ctx, "client", limit, now.Add(1500*time.Millisecond))
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// Ведра разных ключей независимы
+	result, err = store.Take(ctx, "other", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Простоявшее ведро снова полное, а заполнившиеся ведра удаляются
+	result, err = store.Take(ctx, "client", limit, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestLimiterRuleMatching(t *testing.T) {
+	rules, err := ParseRules("* *=100/1m; POST *=50/1m; * /questions/{id}/answers=20/1m; POST /questions/{id}/answers=2/1m")
+	require.NoError(t, err)
+	limiter := NewLimiter(NewMemoryStore(), rules)
+	ctx := context.Background()
+
+	tests := []struct {
+		method   string
+		route    string
+		expected int
+	}{
+		{"POST", "/questions/{id}/answers", 2},
+		{"GET", "/questions/{id}/answers", 20},
+		{"POST", "/questions", 50},
+		{"GET", "/questions", 100},
+	}
+	for _, tt := range tests {
+		_, rule, limited, err := limiter.Allow(ctx, "client", tt.method, tt.route)
+		require.NoError(t, err)
+		require.True(t, limited)
+		assert.Equal(t, tt.expected, rule.Limit.Requests, tt.method+" "+tt.route)
+	}
+
+	// Запросы без подходящего правила не ограничиваются
+	limiter = NewLimiter(NewMemoryStore(), rules[3:])
+	result, _, limited, err := limiter.Allow(ctx, "client", "GET", "/questions")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.True(t, result.Allowed)
+
+	// Лимит считается отдельно для каждого клиента
+	for range 2 {
+		result, _, _, err = limiter.Allow(ctx, "alice", "POST", "/questions/{id}/answers")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, _, _, err = limiter.Allow(ctx, "alice", "POST", "/questions/{id}/answers")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	result, _, _, err = limiter.Allow(ctx, "bob", "POST", "/questions/{id}/answers")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" post /questions=10/m ;; GET /search=5/30s; POST /questions=20/1h ")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Method: "POST", Route: "/questions", Limit: Limit{Requests: 20, Period: time.Hour}},
+		{Method: "GET", Route: "/search", Limit: Limit{Requests: 5, Period: 30 * time.Second}},
+	}, rules)
+
+	rules, err = ParseRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, spec := range []string{
+		"POST /questions",
+		"/questions=10/1m",
+		"POST questions=10/1m",
+		"POST /questions=0/1m",
+		"POST /questions=10",
+		"POST /questions=10/soon",
+		"POST /questions=10/-1m",
+	} {
+		_, err := ParseRules(spec)
+		assert.Error(t, err, spec)
+	}
+}
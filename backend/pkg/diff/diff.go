@@ -0,0 +1,250 @@
+// Package diff строит разницу между двумя текстами: построчную в формате unified
+// и пословную в нотации git --word-diff.
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kind - вид операции редактирования
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Op - операция над одним элементом последовательности
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+// Diff возвращает кратчайший сценарий редактирования a в b (алгоритм Майерса)
+func Diff(a, b []string) []Op {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack восстанавливает путь по сохраненным состояниям фронта
+func backtrack(trace [][]int, a, b []string, offset int) []Op {
+	x, y := len(a), len(b)
+	var ops []Op
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, Op{Kind: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, Op{Kind: Insert, Text: b[y-1]})
+			} else {
+				ops = append(ops, Op{Kind: Delete, Text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// splitLines разбивает текст на строки без завершающих переводов строки
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified возвращает построчную разницу в формате unified с context строками контекста.
+// Для одинаковых текстов возвращается пустая строка.
+func Unified(a, b string, fromName, toName string, context int) string {
+	ops := Diff(splitLines(a), splitLines(b))
+
+	// Номера строк в a и b перед каждой операцией
+	lineA := make([]int, len(ops)+1)
+	lineB := make([]int, len(ops)+1)
+	for i, op := range ops {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if op.Kind != Insert {
+			lineA[i+1]++
+		}
+		if op.Kind != Delete {
+			lineB[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].Kind == Equal {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Соседние изменения, между которыми не больше 2*context общих строк, попадают в один фрагмент
+		lastChange := i
+		j := i
+		for ; j < len(ops); j++ {
+			if ops[j].Kind != Equal {
+				lastChange = j
+			} else if j-lastChange > 2*context {
+				break
+			}
+		}
+		start := max(i-context, 0)
+		end := min(lastChange+1+context, len(ops))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(lineA[start], lineA[end]-lineA[start]),
+			hunkRange(lineB[start], lineB[end]-lineB[start]))
+		for _, op := range ops[start:end] {
+			out.WriteString(linePrefix[op.Kind])
+			out.WriteString(op.Text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+var linePrefix = map[Kind]string{Equal: " ", Delete: "-", Insert: "+"}
+
+// hunkRange форматирует диапазон строк фрагмента; пустой диапазон указывает на строку перед ним
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitWords разбивает текст на слова и разделяющие их пробельные участки,
+// так что склейка токенов дает исходный текст
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	inSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// Words возвращает пословную разницу: удаленное в [-...-], добавленное в {+...+}.
+// Пробелы между соседними изменениями входят в изменение, чтобы замена нескольких слов
+// выводилась одним блоком.
+func Words(a, b string) string {
+	ops := Diff(splitWords(a), splitWords(b))
+
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			out.WriteString(ops[i].Text)
+			i++
+			continue
+		}
+
+		var removed, added strings.Builder
+		for ; i < len(ops); i++ {
+			op := ops[i]
+			if op.Kind == Equal && !(isSpace(op.Text) && i+1 < len(ops) && ops[i+1].Kind != Equal) {
+				break
+			}
+			if op.Kind != Insert {
+				removed.WriteString(op.Text)
+			}
+			if op.Kind != Delete {
+				added.WriteString(op.Text)
+			}
+		}
+		writeChange(&out, removed.String(), added.String())
+	}
+	return out.String()
+}
+
+// writeChange выводит блок изменения, вынося общие пробелы по краям за его пределы
+func writeChange(out *strings.Builder, removed, added string) {
+	prefix := commonSpace(removed, added, strings.HasPrefix, strings.TrimLeftFunc)
+	suffix := commonSpace(removed, added, strings.HasSuffix, strings.TrimRightFunc)
+	removed = strings.TrimSuffix(strings.TrimPrefix(removed, prefix), suffix)
+	added = strings.TrimSuffix(strings.TrimPrefix(added, prefix), suffix)
+
+	out.WriteString(prefix)
+	if removed != "" {
+		out.WriteString("[-" + removed + "-]")
+	}
+	if added != "" {
+		out.WriteString("{+" + added + "+}")
+	}
+	out.WriteString(suffix)
+}
+
+// commonSpace возвращает пробельный край первой строки, если вторая начинается (заканчивается) так же
+func commonSpace(a, b string, has func(s, edge string) bool, trim func(s string, f func(rune) bool) string) string {
+	edge := strings.Replace(a, trim(a, unicode.IsSpace), "", 1)
+	if edge == "" || edge == a || !has(b, edge) || edge == b {
+		return ""
+	}
+	return edge
+}
+
+func isSpace(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
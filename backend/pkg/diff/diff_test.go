@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apply восстанавливает обе последовательности из сценария редактирования
+func apply(ops []Op) (a, b []string) {
+	for _, op := range ops {
+		if op.Kind != Insert {
+			a = append(a, op.Text)
+		}
+		if op.Kind != Delete {
+			b = append(b, op.Text)
+		}
+	}
+	return a, b
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"", "", 0},
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"x y z", "y z x", 2},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		ops := Diff(a, b)
+
+		gotA, gotB := apply(ops)
+		assert.Equal(t, tt.a, strings.Join(gotA, " "))
+		assert.Equal(t, tt.b, strings.Join(gotB, " "))
+
+		edits := 0
+		for _, op := range ops {
+			if op.Kind != Equal {
+				edits++
+			}
+		}
+		assert.Equal(t, tt.edits, edits, "%q -> %q", tt.a, tt.b)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n"
+
+	want := "--- a\n+++ b\n" +
+		"@@ -2,3 +2,3 @@\n 2\n-3\n+three\n 4\n" +
+		"@@ -10 +10,2 @@\n 10\n+11\n"
+	assert.Equal(t, want, Unified(a, b, "a", "b", 1))
+
+	// Близкие изменения объединяются в один фрагмент
+	assert.Equal(t, 2, strings.Count(Unified(a, b, "a", "b", 3), "@@ -"))
+	assert.Equal(t, 1, strings.Count(Unified(a, b, "a", "b", 4), "@@ -"))
+
+	assert.Equal(t, "", Unified(a, a, "a", "b", 3))
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n", Unified("", "x\ny", "a", "b", 3))
+}
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, "Go is a [-compiled-]{+statically typed+} language",
+		Words("Go is a compiled language", "Go is a statically typed language"))
+	assert.Equal(t, "Горутины {+очень +}легкие", Words("Горутины легкие", "Горутины очень легкие"))
+	assert.Equal(t, "same text", Words("same text", "same text"))
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE answers ADD COLUMN IF NOT EXISTS score INTEGER NOT NULL DEFAULT 0;
+
+-- Первичный ключ гарантирует один голос пользователя за ответ
+CREATE TABLE IF NOT EXISTS answer_votes (
+    answer_id INTEGER NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (answer_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_answer_votes_user_id ON answer_votes(user_id);
+CREATE INDEX IF NOT EXISTS idx_answers_question_id_score ON answers(question_id, score DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_answers_question_id_score;
+DROP TABLE IF EXISTS answer_votes;
+ALTER TABLE answers DROP COLUMN IF EXISTS score;
+-- +goose StatementEnd
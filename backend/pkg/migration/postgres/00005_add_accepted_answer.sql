@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- При удалении принятого ответа отметка снимается автоматически
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS accepted_answer_id INTEGER
+    REFERENCES answers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_questions_accepted_answer_id ON questions(accepted_answer_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_questions_accepted_answer_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS accepted_answer_id;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS answer_revisions (
+    answer_id INTEGER NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL CHECK (number > 0),
+    text TEXT NOT NULL,
+    editor_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (answer_id, number)
+);
+
+-- Текущий текст существующих ответов становится их первой версией
+INSERT INTO answer_revisions (answer_id, number, text, editor_id, created_at)
+SELECT id, 1, text, user_id, updated_at FROM answers
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS answer_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(35) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Имя синонима не должно совпадать с именем тега, это проверяет репозиторий
+CREATE TABLE IF NOT EXISTS tag_synonyms (
+    name VARCHAR(35) PRIMARY KEY,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS question_tags (
+    question_id INTEGER NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (question_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_synonyms_tag_id ON tag_synonyms(tag_id);
+CREATE INDEX IF NOT EXISTS idx_question_tags_tag_id ON question_tags(tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS question_tags;
+DROP TABLE IF EXISTS tag_synonyms;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
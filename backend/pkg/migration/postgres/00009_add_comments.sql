@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Своего deleted_at у комментариев нет: они скрыты, пока ответ в корзине,
+-- и удаляются каскадно вместе с ответом или родительским комментарием
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    answer_id INTEGER NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    text TEXT NOT NULL,
+    depth INTEGER NOT NULL DEFAULT 0 CHECK (depth >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_answer_id ON comments(answer_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS comments;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(255) PRIMARY KEY,
+    display_name VARCHAR(255) NOT NULL,
+    bio TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Авторы существующих ответов и комментариев; имя неизвестно, поэтому совпадает с id
+-- и меняется через профиль
+INSERT INTO users (id, display_name, created_at)
+SELECT user_id, user_id, MIN(created_at)
+FROM (
+    SELECT user_id, created_at FROM answers
+    UNION ALL
+    SELECT user_id, created_at FROM comments
+) authors
+GROUP BY user_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE answers ADD CONSTRAINT fk_answers_user
+    FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE comments ADD CONSTRAINT fk_comments_user
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+-- Вопросы, заданные до появления авторов, остаются анонимными
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS author_id VARCHAR(255) REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_questions_author_id ON questions(author_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_questions_author_id;
+ALTER TABLE questions DROP COLUMN IF EXISTS author_id;
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS fk_comments_user;
+ALTER TABLE answers DROP CONSTRAINT IF EXISTS fk_answers_user;
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
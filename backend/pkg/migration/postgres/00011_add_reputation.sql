@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reputation INTEGER NOT NULL DEFAULT 0;
+
+-- Время выбора принятого ответа нужно, чтобы пересчет репутации попадал в те же периоды;
+-- для уже принятых ответов точное время неизвестно, берем время последнего изменения вопроса
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMP;
+UPDATE questions SET accepted_at = updated_at WHERE accepted_answer_id IS NOT NULL;
+
+-- Журнал заполняется при голосовании и выборе ответа, а целиком пересчитывается
+-- командой rebuild-reputation; после этой миграции ее нужно запустить один раз
+CREATE TABLE IF NOT EXISTS reputation_ledger (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INTEGER NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    answer_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reputation_ledger_user_id ON reputation_ledger(user_id);
+CREATE INDEX IF NOT EXISTS idx_reputation_ledger_created_at ON reputation_ledger(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reputation_ledger;
+ALTER TABLE questions DROP COLUMN IF EXISTS accepted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS reputation;
+-- +goose StatementEnd
@@ -0,0 +1,77 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия вопроса растет при любом изменении его представления: самого вопроса, ответов,
+-- комментариев к ним и имен тегов. По ней строятся ETag и Last-Modified.
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS modified_at TIMESTAMP NOT NULL DEFAULT NOW();
+UPDATE questions SET modified_at = updated_at;
+
+CREATE OR REPLACE FUNCTION bump_question_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    NEW.modified_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Изменения ответов, комментариев и тегов "трогают" строку вопроса, а версию поднимает триггер вопроса
+CREATE OR REPLACE FUNCTION touch_answer_question() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE questions SET version = version WHERE id = OLD.question_id;
+    END IF;
+    IF TG_OP = 'INSERT' THEN
+        UPDATE questions SET version = version WHERE id = NEW.question_id;
+    ELSIF TG_OP = 'UPDATE' AND NEW.question_id <> OLD.question_id THEN
+        UPDATE questions SET version = version WHERE id = NEW.question_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION touch_comment_question() RETURNS trigger AS $$
+DECLARE
+    comment_answer_id INTEGER;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        comment_answer_id := OLD.answer_id;
+    ELSE
+        comment_answer_id := NEW.answer_id;
+    END IF;
+    UPDATE questions SET version = version
+    WHERE id = (SELECT question_id FROM answers WHERE id = comment_answer_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION touch_tag_questions() RETURNS trigger AS $$
+BEGIN
+    UPDATE questions SET version = version
+    WHERE id IN (SELECT question_id FROM question_tags WHERE tag_id = NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER questions_bump_version BEFORE UPDATE ON questions
+    FOR EACH ROW EXECUTE FUNCTION bump_question_version();
+CREATE TRIGGER answers_touch_question AFTER INSERT OR UPDATE OR DELETE ON answers
+    FOR EACH ROW EXECUTE FUNCTION touch_answer_question();
+CREATE TRIGGER comments_touch_question AFTER INSERT OR UPDATE OR DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION touch_comment_question();
+CREATE TRIGGER tags_touch_questions AFTER UPDATE OF name ON tags
+    FOR EACH ROW EXECUTE FUNCTION touch_tag_questions();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS tags_touch_questions ON tags;
+DROP TRIGGER IF EXISTS comments_touch_question ON comments;
+DROP TRIGGER IF EXISTS answers_touch_question ON answers;
+DROP TRIGGER IF EXISTS questions_bump_version ON questions;
+DROP FUNCTION IF EXISTS touch_tag_questions();
+DROP FUNCTION IF EXISTS touch_comment_question();
+DROP FUNCTION IF EXISTS touch_answer_question();
+DROP FUNCTION IF EXISTS bump_question_version();
+ALTER TABLE questions DROP COLUMN IF EXISTS modified_at;
+ALTER TABLE questions DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -4,16 +4,58 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"fmt"
+	"sync"
+
 	"github.com/pressly/goose/v3"
 )
 
 //go:embed *.sql
 var embedMigrations embed.FS
 
-func Migrate(db *sql.DB) error {
+// setup настраивает глобальное состояние goose один раз: проверки версии вызываются конкурентно
+var setup = sync.OnceValue(func() error {
 	goose.SetBaseFS(embedMigrations)
-	goose.SetDialect("postgres")
+	return goose.SetDialect("postgres")
+})
+
+func Migrate(db *sql.DB) error {
+	if err := setup(); err != nil {
+		return err
+	}
 
 	// "." означает: использовать файлы .sql из той же директории, что и migrate.go
 	return goose.UpContext(context.Background(), db, ".")
 }
+
+// LatestVersion возвращает номер последней встроенной миграции
+func LatestVersion() (int64, error) {
+	if err := setup(); err != nil {
+		return 0, err
+	}
+	migrations, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return latest.Version, nil
+}
+
+// CheckVersion проверяет, что схема БД на версии последней встроенной миграции
+func CheckVersion(ctx context.Context, db *sql.DB) error {
+	latest, err := LatestVersion()
+	if err != nil {
+		return err
+	}
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current != latest {
+		return fmt.Errorf("database schema is at version %d, expected %d", current, latest)
+	}
+	return nil
+}